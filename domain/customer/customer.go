@@ -2,20 +2,27 @@ package customer
 
 import (
 	"errors"
+	"time"
 
 	"github.com/devsrivatsa/tavernDDD/domain"
 	"github.com/google/uuid"
 )
 
 var (
-	ErrInvalidPerson = errors.New("a customer must have a valid name")
+	ErrInvalidPerson        = errors.New("a customer must have a valid name")
+	ErrUnrelatedTransaction = errors.New("the transaction does not involve the customer")
+	ErrInvalidPurchase      = errors.New("a purchase needs an item and a quantity greater than zero")
+	ErrInvalidDateOfBirth   = errors.New("the date of birth must be set and cannot be in the future")
 )
 
 type Customer struct {
 	//person is the root entity of the customer aggregate
 	person       *domain.Person
-	products     []*domain.Item
+	purchases    []Purchase
 	transactions []domain.Transaction
+	points       []PointsEntry
+	//version is bumped by the repository on every update, to detect concurrent changes
+	version int
 }
 
 func NewCustomer(name string) (Customer, error) {
@@ -29,8 +36,9 @@ func NewCustomer(name string) (Customer, error) {
 
 	return Customer{
 		person:       person,
-		products:     make([]*domain.Item, 0),
+		purchases:    make([]Purchase, 0),
 		transactions: make([]domain.Transaction, 0),
+		points:       make([]PointsEntry, 0),
 	}, nil
 }
 
@@ -39,10 +47,7 @@ func (c *Customer) GetID() uuid.UUID {
 }
 
 func (c *Customer) SetID(id uuid.UUID) {
-	if c.person == nil {
-		c.person = &domain.Person{}
-	}
-	c.person.ID = id
+	c.ownPerson().ID = id
 }
 
 func (c *Customer) GetName() string {
@@ -50,7 +55,117 @@ func (c *Customer) GetName() string {
 }
 
 func (c *Customer) SetName(name string) {
-	if c.person == nil {
-		c.person = &domain.Person{}
+	c.ownPerson().Name = name
+}
+
+// SetDateOfBirth records the date of birth of the customer, verified tells whether it was checked against an ID
+func (c *Customer) SetDateOfBirth(dob time.Time, verified bool) error {
+	if dob.IsZero() || dob.After(time.Now()) {
+		return ErrInvalidDateOfBirth
+	}
+	person := c.ownPerson()
+	person.DateOfBirth = dob
+	person.DateOfBirthVerified = verified
+
+	return nil
+}
+
+// GetDateOfBirth returns the date of birth of the customer and whether it was verified,
+// the date is the zero time when it is not known
+func (c *Customer) GetDateOfBirth() (time.Time, bool) {
+	return c.person.DateOfBirth, c.person.DateOfBirthVerified
+}
+
+// GetVerifiedAgeAt returns the age of the customer at the given time, the bool is false
+// when the date of birth is not known or was not verified
+func (c *Customer) GetVerifiedAgeAt(at time.Time) (int, bool) {
+	if c.person.DateOfBirth.IsZero() || !c.person.DateOfBirthVerified {
+		return 0, false
+	}
+	return c.person.AgeAt(at), true
+}
+
+// ownPerson gives the customer its own copy of the person before it is changed, so copies
+// of the aggregate, e.g. the one kept by a memory repository, never share their details
+func (c *Customer) ownPerson() *domain.Person {
+	person := domain.Person{}
+	if c.person != nil {
+		person = *c.person
+	}
+	c.person = &person
+	return c.person
+}
+
+// GetVersion returns the version of the customer as it was loaded from the repository
+func (c *Customer) GetVersion() int {
+	return c.version
+}
+
+func (c *Customer) SetVersion(version int) {
+	c.version = version
+}
+
+// AddTransaction records a transaction the customer paid or received
+func (c *Customer) AddTransaction(t domain.Transaction) error {
+	if t.GetFrom() != c.GetID() && t.GetTo() != c.GetID() {
+		return ErrUnrelatedTransaction
 	}
+	// the full slice expression makes append copy, so copies of the aggregate never share a ledger
+	c.transactions = append(c.transactions[:len(c.transactions):len(c.transactions)], t)
+
+	return nil
+}
+
+// GetTransactions returns a copy of the transaction history, oldest first
+func (c *Customer) GetTransactions() []domain.Transaction {
+	return append([]domain.Transaction(nil), c.transactions...)
+}
+
+// AddPurchase records an item the customer bought
+func (c *Customer) AddPurchase(p Purchase) error {
+	if p.Item.ID == uuid.Nil || p.Quantity <= 0 {
+		return ErrInvalidPurchase
+	}
+	if p.PurchasedAt.IsZero() {
+		p.PurchasedAt = time.Now()
+	}
+	c.purchases = append(c.purchases[:len(c.purchases):len(c.purchases)], p)
+
+	return nil
+}
+
+// RemovePurchases drops everything bought with the given order from the purchase history
+func (c *Customer) RemovePurchases(orderID uuid.UUID) {
+	purchases := make([]Purchase, 0, len(c.purchases))
+	for _, p := range c.purchases {
+		if p.OrderID != orderID {
+			purchases = append(purchases, p)
+		}
+	}
+	c.purchases = purchases
+}
+
+// PurchaseHistory returns a copy of everything the customer bought, oldest first
+func (c *Customer) PurchaseHistory() []Purchase {
+	return append([]Purchase(nil), c.purchases...)
+}
+
+// GetBalance adds up everything the customer received minus everything they paid, in the given currency
+func (c *Customer) GetBalance(currency string) (domain.Money, error) {
+	balance, err := domain.NewMoney(0, currency)
+	if err != nil {
+		return domain.Money{}, err
+	}
+	for _, t := range c.transactions {
+		if t.GetTo() == c.GetID() {
+			balance, err = balance.Add(t.GetAmount())
+		} else {
+			balance, err = balance.Sub(t.GetAmount())
+		}
+		if err != nil {
+			return domain.Money{}, err
+		}
+	}
+
+	return balance, nil
 }
@@ -1,6 +1,7 @@
 package customer
 
 import (
+	"context"
 	"errors"
 
 	"github.com/google/uuid"
@@ -10,10 +11,13 @@ var (
 	ErrCustomerNotFound    = errors.New("customer not found")
 	ErrFailedToAddCustomer = errors.New("failed to add customer")
 	ErrUpdateCustomer      = errors.New("failed to update customer")
+	ErrConcurrentUpdate    = errors.New("the customer was changed since it was loaded")
 )
 
 type CustomerRepository interface {
-	Get(id uuid.UUID) (Customer, error)
-	Add(customer Customer) error
-	Update(customer Customer) error
+	Get(ctx context.Context, id uuid.UUID) (Customer, error)
+	Add(ctx context.Context, customer Customer) error
+	// Update fails with ErrConcurrentUpdate if the stored customer is not at the version of
+	// the given one, and bumps the version otherwise
+	Update(ctx context.Context, customer Customer) error
 }
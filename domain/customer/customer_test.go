@@ -3,8 +3,11 @@ package customer_test
 import (
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/devsrivatsa/tavernDDD/domain"
 	"github.com/devsrivatsa/tavernDDD/domain/customer"
+	"github.com/google/uuid"
 )
 
 func TestCustomer_NewCustomer(t *testing.T) {
@@ -36,3 +39,168 @@ func TestCustomer_NewCustomer(t *testing.T) {
 		})
 	}
 }
+
+func TestCustomer_Transactions(t *testing.T) {
+	c, err := customer.NewCustomer("John Doe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tavern := uuid.New()
+
+	paid, err := domain.NewTransaction(c.GetID(), tavern, domain.MustNewMoney(897, "EUR"), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	refund, err := domain.NewTransaction(tavern, c.GetID(), domain.MustNewMoney(199, "EUR"), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	unrelated, err := domain.NewTransaction(uuid.New(), tavern, domain.MustNewMoney(100, "EUR"), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tx := range []domain.Transaction{paid, refund} {
+		if err := c.AddTransaction(tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.AddTransaction(unrelated); !errors.Is(err, customer.ErrUnrelatedTransaction) {
+		t.Errorf("expected error %v, got %v", customer.ErrUnrelatedTransaction, err)
+	}
+
+	if len(c.GetTransactions()) != 2 {
+		t.Errorf("expected 2 transactions, got %d", len(c.GetTransactions()))
+	}
+	balance, err := c.GetBalance("EUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !balance.Equals(domain.MustNewMoney(-698, "EUR")) {
+		t.Errorf("expected balance of -6.98 EUR, got %s", balance)
+	}
+}
+
+func TestCustomer_AddPurchase(t *testing.T) {
+	type testCase struct {
+		test          string
+		purchase      customer.Purchase
+		expectedError error
+	}
+
+	beer := domain.Item{ID: uuid.New(), Name: "Beer"}
+	testcases := []testCase{
+		{
+			test:          "Missing item",
+			purchase:      customer.Purchase{Quantity: 1},
+			expectedError: customer.ErrInvalidPurchase,
+		},
+		{
+			test:          "Zero quantity",
+			purchase:      customer.Purchase{Item: beer},
+			expectedError: customer.ErrInvalidPurchase,
+		},
+		{
+			test:          "Valid purchase",
+			purchase:      customer.Purchase{Item: beer, Quantity: 2},
+			expectedError: nil,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.test, func(t *testing.T) {
+			c, err := customer.NewCustomer("John Doe")
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = c.AddPurchase(tc.purchase)
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("expected error %v, got %v", tc.expectedError, err)
+			}
+			if err == nil && c.PurchaseHistory()[0].PurchasedAt.IsZero() {
+				t.Errorf("expected the purchase time to be set")
+			}
+		})
+	}
+}
+
+func TestCustomer_Points(t *testing.T) {
+	c, err := customer.NewCustomer("John Doe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, second := uuid.New(), uuid.New()
+
+	if err := c.EarnPoints(first, 10, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.RedeemPoints(second, 11, time.Time{}); !errors.Is(err, customer.ErrInsufficientPoints) {
+		t.Errorf("expected error %v, got %v", customer.ErrInsufficientPoints, err)
+	}
+	if err := c.RedeemPoints(second, 4, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.EarnPoints(second, 2, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if c.GetPoints() != 8 || c.GetLifetimePoints() != 12 {
+		t.Errorf("expected 8 points and 12 lifetime points, got %d and %d", c.GetPoints(), c.GetLifetimePoints())
+	}
+
+	// calling off the second order gives back the 4 points and takes back the 2
+	c.ReversePoints(second, time.Time{})
+	if c.GetPoints() != 10 || c.GetLifetimePoints() != 10 {
+		t.Errorf("expected 10 points and 10 lifetime points, got %d and %d", c.GetPoints(), c.GetLifetimePoints())
+	}
+	if len(c.PointsHistory()) != 4 {
+		t.Errorf("expected 4 entries in the points history, got %d", len(c.PointsHistory()))
+	}
+}
+
+func TestCustomer_SetDateOfBirth(t *testing.T) {
+	type testCase struct {
+		test          string
+		dob           time.Time
+		verified      bool
+		expectedError error
+	}
+
+	testcases := []testCase{
+		{test: "Missing date of birth", dob: time.Time{}, expectedError: customer.ErrInvalidDateOfBirth},
+		{test: "Date of birth in the future", dob: time.Now().AddDate(1, 0, 0), expectedError: customer.ErrInvalidDateOfBirth},
+		{test: "Unverified date of birth", dob: time.Now().AddDate(-30, 0, 0), verified: false, expectedError: nil},
+		{test: "Verified date of birth", dob: time.Now().AddDate(-30, 0, 0), verified: true, expectedError: nil},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.test, func(t *testing.T) {
+			c, err := customer.NewCustomer("John Doe")
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = c.SetDateOfBirth(tc.dob, tc.verified)
+			if !errors.Is(err, tc.expectedError) {
+				t.Errorf("expected error %v, got %v", tc.expectedError, err)
+			}
+			if _, ok := c.GetVerifiedAgeAt(time.Now()); ok != (err == nil && tc.verified) {
+				t.Errorf("expected a verified age %v, got %v", err == nil && tc.verified, ok)
+			}
+		})
+	}
+}
+
+func TestCustomer_SetName(t *testing.T) {
+	c, err := customer.NewCustomer("John Doe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := c
+
+	c.SetName("Jane Doe")
+	if c.GetName() != "Jane Doe" {
+		t.Errorf("expected name %q, got %q", "Jane Doe", c.GetName())
+	}
+	if stored.GetName() != "John Doe" {
+		t.Errorf("expected the copy to keep name %q, got %q", "John Doe", stored.GetName())
+	}
+}
@@ -0,0 +1,236 @@
+// Package file keeps customers in a JSON file, for a single machine without a database
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/devsrivatsa/tavernDDD/domain"
+	"github.com/devsrivatsa/tavernDDD/domain/customer"
+	"github.com/devsrivatsa/tavernDDD/domain/filestore"
+	"github.com/google/uuid"
+)
+
+// FileStore keeps every customer in memory, like memory.MemoryStore, and writes each change
+// to the file before it is made in memory
+type FileStore struct {
+	customers map[uuid.UUID]customer.Customer
+	store     *filestore.Store
+	sync.Mutex
+}
+
+type fileCustomer struct {
+	ID           uuid.UUID         `json:"id"`
+	Name         string            `json:"name"`
+	DateOfBirth  time.Time         `json:"date_of_birth"`
+	DOBVerified  bool              `json:"date_of_birth_verified"`
+	Purchases    []filePurchase    `json:"purchases"`
+	Transactions []fileTransaction `json:"transactions"`
+	Points       []filePointsEntry `json:"points"`
+	Version      int               `json:"version"`
+}
+
+type filePurchase struct {
+	OrderID     uuid.UUID   `json:"order_id"`
+	Item        domain.Item `json:"item"`
+	Quantity    int         `json:"quantity"`
+	PurchasedAt time.Time   `json:"purchased_at"`
+}
+
+type fileMoney struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+type fileTransaction struct {
+	From      uuid.UUID `json:"from"`
+	To        uuid.UUID `json:"to"`
+	Amount    fileMoney `json:"amount"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type filePointsEntry struct {
+	OrderID uuid.UUID `json:"order_id"`
+	Points  int64     `json:"points"`
+	Reason  string    `json:"reason"`
+	At      time.Time `json:"at"`
+}
+
+func newFromCustomer(c customer.Customer) fileCustomer {
+	purchases := make([]filePurchase, 0)
+	for _, p := range c.PurchaseHistory() {
+		purchases = append(purchases, filePurchase{
+			OrderID:     p.OrderID,
+			Item:        p.Item,
+			Quantity:    p.Quantity,
+			PurchasedAt: p.PurchasedAt,
+		})
+	}
+	transactions := make([]fileTransaction, 0)
+	for _, t := range c.GetTransactions() {
+		transactions = append(transactions, fileTransaction{
+			From:      t.GetFrom(),
+			To:        t.GetTo(),
+			Amount:    fileMoney{Amount: t.GetAmount().GetAmount(), Currency: t.GetAmount().GetCurrency()},
+			CreatedAt: t.GetCreatedAt(),
+		})
+	}
+	points := make([]filePointsEntry, 0)
+	for _, e := range c.PointsHistory() {
+		points = append(points, filePointsEntry{
+			OrderID: e.OrderID,
+			Points:  e.Points,
+			Reason:  string(e.Reason),
+			At:      e.At,
+		})
+	}
+	dob, verified := c.GetDateOfBirth()
+
+	return fileCustomer{
+		ID:           c.GetID(),
+		Name:         c.GetName(),
+		DateOfBirth:  dob,
+		DOBVerified:  verified,
+		Purchases:    purchases,
+		Transactions: transactions,
+		Points:       points,
+		Version:      c.GetVersion(),
+	}
+}
+
+// toAggregate rebuilds the customer, it fails on records the customer rules do not allow
+func (f fileCustomer) toAggregate() (customer.Customer, error) {
+	c := customer.Customer{}
+	c.SetID(f.ID)
+	c.SetName(f.Name)
+	if !f.DateOfBirth.IsZero() {
+		if err := c.SetDateOfBirth(f.DateOfBirth, f.DOBVerified); err != nil {
+			return customer.Customer{}, err
+		}
+	}
+	for _, p := range f.Purchases {
+		err := c.AddPurchase(customer.Purchase{OrderID: p.OrderID, Item: p.Item, Quantity: p.Quantity, PurchasedAt: p.PurchasedAt})
+		if err != nil {
+			return customer.Customer{}, err
+		}
+	}
+	for _, ft := range f.Transactions {
+		amount, err := domain.NewMoney(ft.Amount.Amount, ft.Amount.Currency)
+		if err != nil {
+			return customer.Customer{}, err
+		}
+		t, err := domain.NewTransaction(ft.From, ft.To, amount, ft.CreatedAt)
+		if err != nil {
+			return customer.Customer{}, err
+		}
+		if err := c.AddTransaction(t); err != nil {
+			return customer.Customer{}, err
+		}
+	}
+	for _, e := range f.Points {
+		err := c.AddPointsEntry(customer.PointsEntry{OrderID: e.OrderID, Points: e.Points, Reason: customer.PointsReason(e.Reason), At: e.At})
+		if err != nil {
+			return customer.Customer{}, err
+		}
+	}
+	c.SetVersion(f.Version)
+
+	return c, nil
+}
+
+// New opens the file at path and loads every customer in it, no other process can open it
+// until the store is closed. Pass filestore.WithJournal to append changes instead of
+// rewriting the file each time.
+func New(path string, opts ...filestore.Option) (*FileStore, error) {
+	store, err := filestore.Open(path, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	customers := make(map[uuid.UUID]customer.Customer)
+	for key, data := range store.Records() {
+		var fc fileCustomer
+		if err := json.Unmarshal(data, &fc); err != nil {
+			store.Close()
+			return nil, fmt.Errorf("error reading customer %s: %w", key, err)
+		}
+		c, err := fc.toAggregate()
+		if err != nil {
+			store.Close()
+			return nil, fmt.Errorf("error reading customer %s: %w", key, err)
+		}
+		customers[c.GetID()] = c
+	}
+
+	return &FileStore{
+		customers: customers,
+		store:     store,
+	}, nil
+}
+
+func (fs *FileStore) Get(ctx context.Context, id uuid.UUID) (customer.Customer, error) {
+	if err := ctx.Err(); err != nil {
+		return customer.Customer{}, err
+	}
+	fs.Lock()
+	defer fs.Unlock()
+
+	if c, ok := fs.customers[id]; ok {
+		return c, nil
+	}
+	return customer.Customer{}, customer.ErrCustomerNotFound
+}
+
+func (fs *FileStore) Add(ctx context.Context, c customer.Customer) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	fs.Lock()
+	defer fs.Unlock()
+
+	if _, ok := fs.customers[c.GetID()]; ok {
+		return fmt.Errorf("customer already exists %w", customer.ErrFailedToAddCustomer)
+	}
+	if err := fs.store.Put(c.GetID().String(), newFromCustomer(c)); err != nil {
+		return fmt.Errorf("error adding customer %s: %w", c.GetID(), err)
+	}
+	fs.customers[c.GetID()] = c
+
+	return nil
+}
+
+func (fs *FileStore) Update(ctx context.Context, c customer.Customer) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	fs.Lock()
+	defer fs.Unlock()
+
+	stored, ok := fs.customers[c.GetID()]
+	if !ok {
+		return fmt.Errorf("customer does not exist %w", customer.ErrCustomerNotFound)
+	}
+	if stored.GetVersion() != c.GetVersion() {
+		return fmt.Errorf("customer %s is at version %d, not %d: %w", c.GetID(), stored.GetVersion(), c.GetVersion(), customer.ErrConcurrentUpdate)
+	}
+	c.SetVersion(c.GetVersion() + 1)
+	if err := fs.store.Put(c.GetID().String(), newFromCustomer(c)); err != nil {
+		return fmt.Errorf("error updating customer %s: %w", c.GetID(), err)
+	}
+	fs.customers[c.GetID()] = c
+
+	return nil
+}
+
+// Compact folds the journal into the file, it does nothing without a journal
+func (fs *FileStore) Compact() error {
+	return fs.store.Compact()
+}
+
+// Close releases the file for other processes
+func (fs *FileStore) Close() error {
+	return fs.store.Close()
+}
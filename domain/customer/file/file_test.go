@@ -0,0 +1,99 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/devsrivatsa/tavernDDD/domain"
+	"github.com/devsrivatsa/tavernDDD/domain/customer"
+	"github.com/devsrivatsa/tavernDDD/domain/filestore"
+	"github.com/google/uuid"
+)
+
+func TestFileStore_Reopen(t *testing.T) {
+	for _, opts := range [][]filestore.Option{nil, {filestore.WithJournal(10)}} {
+		path := filepath.Join(t.TempDir(), "customers.json")
+		repo, err := New(path, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		at := time.Date(2024, time.March, 1, 20, 15, 30, 0, time.UTC)
+		c, err := customer.NewCustomer("Percy")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := c.AddPurchase(customer.Purchase{OrderID: uuid.New(), Item: domain.Item{ID: uuid.New(), Name: "Beer"}, Quantity: 2, PurchasedAt: at}); err != nil {
+			t.Fatal(err)
+		}
+		payment, err := domain.NewTransaction(c.GetID(), uuid.New(), domain.MustNewMoney(900, "EUR"), at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := c.AddTransaction(payment); err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.Add(context.Background(), c); err != nil {
+			t.Fatal(err)
+		}
+		c.SetName("Percival")
+		if err := repo.Update(context.Background(), c); err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		repo, err = New(path, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stored, err := repo.Get(context.Background(), c.GetID())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.GetName() != "Percival" || stored.GetVersion() != 1 {
+			t.Errorf("expected Percival at version 1, got %s at version %d", stored.GetName(), stored.GetVersion())
+		}
+		if len(stored.PurchaseHistory()) != 1 || len(stored.GetTransactions()) != 1 {
+			t.Errorf("expected the purchase and the payment, got %d and %d", len(stored.PurchaseHistory()), len(stored.GetTransactions()))
+		}
+		balance, err := stored.GetBalance("EUR")
+		if err != nil || balance.GetAmount() != -900 {
+			t.Errorf("expected a balance of -9.00 EUR, got %v (%v)", balance, err)
+		}
+		repo.Close()
+	}
+}
+
+func TestFileStore_Errors(t *testing.T) {
+	repo, err := New(filepath.Join(t.TempDir(), "customers.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	c, err := customer.NewCustomer("Percy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Add(context.Background(), c); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := repo.Get(context.Background(), uuid.New()); !errors.Is(err, customer.ErrCustomerNotFound) {
+		t.Errorf("expected error %v, got %v", customer.ErrCustomerNotFound, err)
+	}
+	if err := repo.Add(context.Background(), c); !errors.Is(err, customer.ErrFailedToAddCustomer) {
+		t.Errorf("expected error %v, got %v", customer.ErrFailedToAddCustomer, err)
+	}
+	first, _ := repo.Get(context.Background(), c.GetID())
+	second, _ := repo.Get(context.Background(), c.GetID())
+	if err := repo.Update(context.Background(), first); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Update(context.Background(), second); !errors.Is(err, customer.ErrConcurrentUpdate) {
+		t.Errorf("expected error %v, got %v", customer.ErrConcurrentUpdate, err)
+	}
+}
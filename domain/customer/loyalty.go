@@ -0,0 +1,145 @@
+package customer
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/devsrivatsa/tavernDDD/domain"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidPoints      = errors.New("points must be greater than zero")
+	ErrInsufficientPoints = errors.New("the customer does not have enough points")
+)
+
+type PointsReason string
+
+const (
+	PointsEarned   PointsReason = "earned"
+	PointsRedeemed PointsReason = "redeemed"
+	// PointsReversed takes back everything earned and redeemed with an order that was called off
+	PointsReversed PointsReason = "reversed"
+	// PointsRefunded takes back the points earned with the refunded part of an order
+	PointsRefunded PointsReason = "refunded"
+)
+
+// PointsEntry is a value object recording a change to the loyalty points of a customer,
+// Points is negative for redemptions
+type PointsEntry struct {
+	OrderID uuid.UUID
+	Points  int64
+	Reason  PointsReason
+	At      time.Time
+}
+
+// EarnPoints credits the customer with points for an order
+func (c *Customer) EarnPoints(orderID uuid.UUID, points int64, at time.Time) error {
+	if points <= 0 {
+		return ErrInvalidPoints
+	}
+	return c.AddPointsEntry(PointsEntry{OrderID: orderID, Points: points, Reason: PointsEarned, At: at})
+}
+
+// RedeemPoints spends points of the customer on an order
+func (c *Customer) RedeemPoints(orderID uuid.UUID, points int64, at time.Time) error {
+	if points <= 0 {
+		return ErrInvalidPoints
+	}
+	if balance := c.GetPoints(); balance < points {
+		return fmt.Errorf("redeeming %d of %d points: %w", points, balance, ErrInsufficientPoints)
+	}
+	return c.AddPointsEntry(PointsEntry{OrderID: orderID, Points: -points, Reason: PointsRedeemed, At: at})
+}
+
+// ReversePoints takes back the points earned with the order and gives back the points redeemed on it.
+// The balance can go below zero if the points earned with the order have been spent already.
+func (c *Customer) ReversePoints(orderID uuid.UUID, at time.Time) {
+	var points int64
+	for _, e := range c.points {
+		if e.OrderID == orderID {
+			points += e.Points
+		}
+	}
+	if points == 0 {
+		return
+	}
+	_ = c.AddPointsEntry(PointsEntry{OrderID: orderID, Points: -points, Reason: PointsReversed, At: at})
+}
+
+// RefundPoints takes back the share of the points earned with the order that refunded is of
+// total, refunded being everything refunded on the order so far. The points taken back for
+// earlier refunds of the order count towards it, so an order refunded bit by bit loses all its points.
+func (c *Customer) RefundPoints(orderID uuid.UUID, refunded, total domain.Money, at time.Time) error {
+	if _, err := refunded.Compare(total); err != nil {
+		return err
+	}
+	if !total.IsPositive() {
+		return nil
+	}
+	var earned, taken int64
+	for _, e := range c.points {
+		if e.OrderID != orderID {
+			continue
+		}
+		switch e.Reason {
+		case PointsEarned:
+			earned += e.Points
+		case PointsRefunded:
+			taken -= e.Points
+		}
+	}
+	due := min(earned*refunded.GetAmount()/total.GetAmount(), earned)
+	if due <= taken {
+		return nil
+	}
+	return c.AddPointsEntry(PointsEntry{OrderID: orderID, Points: taken - due, Reason: PointsRefunded, At: at})
+}
+
+// AddPointsEntry appends an entry to the points history as is, repositories use it to load a customer
+func (c *Customer) AddPointsEntry(e PointsEntry) error {
+	if e.Points == 0 {
+		return ErrInvalidPoints
+	}
+	if e.At.IsZero() {
+		e.At = time.Now()
+	}
+	c.points = append(c.points[:len(c.points):len(c.points)], e)
+
+	return nil
+}
+
+// GetPoints returns the points the customer can redeem
+func (c *Customer) GetPoints() int64 {
+	var balance int64
+	for _, e := range c.points {
+		balance += e.Points
+	}
+	return balance
+}
+
+// GetLifetimePoints returns the points the customer earned with orders that were not called off,
+// redeeming points does not lower it
+func (c *Customer) GetLifetimePoints() int64 {
+	earned := make(map[uuid.UUID]int64)
+	for _, e := range c.points {
+		switch e.Reason {
+		case PointsEarned, PointsRefunded:
+			earned[e.OrderID] += e.Points
+		case PointsReversed:
+			delete(earned, e.OrderID)
+		}
+	}
+
+	var total int64
+	for _, points := range earned {
+		total += points
+	}
+	return total
+}
+
+// PointsHistory returns a copy of the points history, oldest first
+func (c *Customer) PointsHistory() []PointsEntry {
+	return append([]PointsEntry(nil), c.points...)
+}
@@ -1,6 +1,7 @@
 package memory
 
 import (
+	"context"
 	"fmt"
 	"sync"
 
@@ -18,34 +19,51 @@ func New() *MemoryStore {
 		customers: make(map[uuid.UUID]customer.Customer),
 	}
 }
-func (ms *MemoryStore) Get(id uuid.UUID) (customer.Customer, error) {
+func (ms *MemoryStore) Get(ctx context.Context, id uuid.UUID) (customer.Customer, error) {
+	if err := ctx.Err(); err != nil {
+		return customer.Customer{}, err
+	}
+	ms.Lock()
+	defer ms.Unlock()
+
 	if customer, ok := ms.customers[id]; ok {
 		return customer, nil
 	}
 	return customer.Customer{}, customer.ErrCustomerNotFound
 }
-func (ms *MemoryStore) Add(c customer.Customer) error {
+func (ms *MemoryStore) Add(ctx context.Context, c customer.Customer) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	ms.Lock()
+	defer ms.Unlock()
+
 	if ms.customers == nil {
-		ms.Lock()
 		ms.customers = make(map[uuid.UUID]customer.Customer)
-		ms.Unlock()
 	}
 	if _, ok := ms.customers[c.GetID()]; ok {
 		return fmt.Errorf("customer already exists %w", customer.ErrUpdateCustomer)
 	}
-	ms.Lock()
 	ms.customers[c.GetID()] = c
-	ms.Unlock()
 
 	return nil
 }
-func (ms *MemoryStore) Update(c customer.Customer) error {
-	if _, ok := ms.customers[c.GetID()]; !ok {
-		return fmt.Errorf("customer does not exist %w", customer.ErrCustomerNotFound)
+func (ms *MemoryStore) Update(ctx context.Context, c customer.Customer) error {
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 	ms.Lock()
+	defer ms.Unlock()
+
+	stored, ok := ms.customers[c.GetID()]
+	if !ok {
+		return fmt.Errorf("customer does not exist %w", customer.ErrCustomerNotFound)
+	}
+	if stored.GetVersion() != c.GetVersion() {
+		return fmt.Errorf("customer %s is at version %d, not %d: %w", c.GetID(), stored.GetVersion(), c.GetVersion(), customer.ErrConcurrentUpdate)
+	}
+	c.SetVersion(c.GetVersion() + 1)
 	ms.customers[c.GetID()] = c
-	ms.Unlock()
 
 	return nil
 }
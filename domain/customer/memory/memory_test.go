@@ -1,6 +1,7 @@
 package memory
 
 import (
+	"context"
 	"errors"
 	"testing"
 
@@ -40,10 +41,62 @@ func TestMemoryStore_Get(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			_, err := repo.Get(tc.id)
+			_, err := repo.Get(context.Background(), tc.id)
 			if !errors.Is(err, tc.expectedError) {
 				t.Errorf("expected error %v, got %v", tc.expectedError, err)
 			}
 		})
 	}
 }
+
+func TestMemoryStore_UpdateConcurrent(t *testing.T) {
+	c, err := customer.NewCustomer("Percy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := New()
+	if err := repo.Add(context.Background(), c); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := repo.Get(context.Background(), c.GetID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := repo.Get(context.Background(), c.GetID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Update(context.Background(), first); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Update(context.Background(), second); !errors.Is(err, customer.ErrConcurrentUpdate) {
+		t.Errorf("expected error %v, got %v", customer.ErrConcurrentUpdate, err)
+	}
+}
+
+func TestMemoryStore_Cancelled(t *testing.T) {
+	c, err := customer.NewCustomer("Percy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := New()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := repo.Add(ctx, c); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected error %v, got %v", context.Canceled, err)
+	}
+	if _, err := repo.Get(context.Background(), c.GetID()); !errors.Is(err, customer.ErrCustomerNotFound) {
+		t.Errorf("expected error %v, got %v", customer.ErrCustomerNotFound, err)
+	}
+	if err := repo.Add(context.Background(), c); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Get(ctx, c.GetID()); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected error %v, got %v", context.Canceled, err)
+	}
+	if err := repo.Update(ctx, c); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected error %v, got %v", context.Canceled, err)
+	}
+}
@@ -2,9 +2,12 @@ package mongo
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
 	"time"
 
+	"github.com/devsrivatsa/tavernDDD/domain"
 	"github.com/devsrivatsa/tavernDDD/domain/customer"
 	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/bson"
@@ -18,23 +21,160 @@ type MongoRepository struct {
 }
 
 type mongoCustomer struct {
-	ID   uuid.UUID `bson:"_id"`
-	Name string    `bson:"name"`
+	ID           uuid.UUID          `bson:"_id"`
+	Name         string             `bson:"name"`
+	DateOfBirth  time.Time          `bson:"date_of_birth"`
+	DOBVerified  bool               `bson:"date_of_birth_verified"`
+	Purchases    []mongoPurchase    `bson:"purchases"`
+	Transactions []mongoTransaction `bson:"transactions"`
+	Points       []mongoPointsEntry `bson:"points"`
+	Version      int                `bson:"version"`
+}
+
+type mongoItem struct {
+	ID          uuid.UUID `bson:"id"`
+	Name        string    `bson:"name"`
+	Description string    `bson:"description"`
+}
+
+type mongoPurchase struct {
+	OrderID     uuid.UUID `bson:"order_id"`
+	Item        mongoItem `bson:"item"`
+	Quantity    int       `bson:"quantity"`
+	PurchasedAt time.Time `bson:"purchased_at"`
+}
+
+type mongoMoney struct {
+	Amount   int64  `bson:"amount"`
+	Currency string `bson:"currency"`
+}
+
+type mongoTransaction struct {
+	From      uuid.UUID  `bson:"from"`
+	To        uuid.UUID  `bson:"to"`
+	Amount    mongoMoney `bson:"amount"`
+	CreatedAt time.Time  `bson:"created_at"`
+}
+
+type mongoPointsEntry struct {
+	OrderID uuid.UUID `bson:"order_id"`
+	Points  int64     `bson:"points"`
+	Reason  string    `bson:"reason"`
+	At      time.Time `bson:"at"`
 }
 
 func NewFromCustomer(c customer.Customer) mongoCustomer {
+	purchases := make([]mongoPurchase, 0)
+	for _, p := range c.PurchaseHistory() {
+		purchases = append(purchases, mongoPurchase{
+			OrderID: p.OrderID,
+			Item: mongoItem{
+				ID:          p.Item.ID,
+				Name:        p.Item.Name,
+				Description: p.Item.Description,
+			},
+			Quantity:    p.Quantity,
+			PurchasedAt: p.PurchasedAt,
+		})
+	}
+	transactions := make([]mongoTransaction, 0)
+	for _, t := range c.GetTransactions() {
+		transactions = append(transactions, mongoTransaction{
+			From:      t.GetFrom(),
+			To:        t.GetTo(),
+			Amount:    newMongoMoney(t.GetAmount()),
+			CreatedAt: t.GetCreatedAt(),
+		})
+	}
+
+	points := make([]mongoPointsEntry, 0)
+	for _, e := range c.PointsHistory() {
+		points = append(points, mongoPointsEntry{
+			OrderID: e.OrderID,
+			Points:  e.Points,
+			Reason:  string(e.Reason),
+			At:      e.At,
+		})
+	}
+
+	dob, verified := c.GetDateOfBirth()
+
 	return mongoCustomer{
-		ID:   c.GetID(),
-		Name: c.GetName(),
+		ID:           c.GetID(),
+		Name:         c.GetName(),
+		DateOfBirth:  dob,
+		DOBVerified:  verified,
+		Purchases:    purchases,
+		Transactions: transactions,
+		Points:       points,
+		Version:      c.GetVersion(),
 	}
 }
 
 func (m mongoCustomer) ToAggregate() customer.Customer {
-	customer := customer.Customer{}
-	customer.SetID(m.ID)
-	customer.SetName(m.Name)
+	c := customer.Customer{}
+	c.SetID(m.ID)
+	c.SetName(m.Name)
+	if !m.DateOfBirth.IsZero() {
+		if err := c.SetDateOfBirth(m.DateOfBirth, m.DOBVerified); err != nil {
+			log.Printf("skipping date of birth of customer %s: %v", m.ID, err)
+		}
+	}
+	for _, mp := range m.Purchases {
+		err := c.AddPurchase(customer.Purchase{
+			OrderID: mp.OrderID,
+			Item: domain.Item{
+				ID:          mp.Item.ID,
+				Name:        mp.Item.Name,
+				Description: mp.Item.Description,
+			},
+			Quantity:    mp.Quantity,
+			PurchasedAt: mp.PurchasedAt,
+		})
+		if err != nil {
+			log.Printf("skipping purchase of customer %s: %v", m.ID, err)
+		}
+	}
+	for _, mt := range m.Transactions {
+		amount, err := mt.Amount.toMoney()
+		if err != nil {
+			log.Printf("skipping transaction of customer %s: %v", m.ID, err)
+			continue
+		}
+		t, err := domain.NewTransaction(mt.From, mt.To, amount, mt.CreatedAt)
+		if err != nil {
+			log.Printf("skipping transaction of customer %s: %v", m.ID, err)
+			continue
+		}
+		if err := c.AddTransaction(t); err != nil {
+			log.Printf("skipping transaction of customer %s: %v", m.ID, err)
+		}
+	}
+	for _, mp := range m.Points {
+		err := c.AddPointsEntry(customer.PointsEntry{
+			OrderID: mp.OrderID,
+			Points:  mp.Points,
+			Reason:  customer.PointsReason(mp.Reason),
+			At:      mp.At,
+		})
+		if err != nil {
+			log.Printf("skipping points of customer %s: %v", m.ID, err)
+		}
+	}
+	c.SetVersion(m.Version)
+
+	return c
+}
+
+func newMongoMoney(m domain.Money) mongoMoney {
+	return mongoMoney{
+		Amount:   m.GetAmount(),
+		Currency: m.GetCurrency(),
+	}
+}
 
-	return customer
+func (m mongoMoney) toMoney() (domain.Money, error) {
+	return domain.NewMoney(m.Amount, m.Currency)
 }
 
 func New(ctx context.Context, connString string) (*MongoRepository, error) {
@@ -52,22 +192,20 @@ func New(ctx context.Context, connString string) (*MongoRepository, error) {
 	}, nil
 }
 
-func (mr *MongoRepository) Get(id uuid.UUID) (customer.Customer, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+func (mr *MongoRepository) Get(ctx context.Context, id uuid.UUID) (customer.Customer, error) {
 	result := mr.customer.FindOne(ctx, bson.M{"_id": id})
 	var c mongoCustomer
 	if err := result.Decode(&c); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return customer.Customer{}, customer.ErrCustomerNotFound
+		}
 		return customer.Customer{}, err
 	}
 
 	return c.ToAggregate(), nil
 }
 
-func (mr *MongoRepository) Add(c customer.Customer) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
+func (mr *MongoRepository) Add(ctx context.Context, c customer.Customer) error {
 	internal := NewFromCustomer(c)
 	_, err := mr.customer.InsertOne(ctx, internal)
 	if err != nil {
@@ -77,28 +215,34 @@ func (mr *MongoRepository) Add(c customer.Customer) error {
 	return nil
 }
 
-func (mr *MongoRepository) Update(c customer.Customer) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	//check if customer exists
-	_, err := mr.Get(c.GetID())
-	if err != nil {
-		return fmt.Errorf("customer not found: %w", err)
-	}
+func (mr *MongoRepository) Update(ctx context.Context, c customer.Customer) error {
 	internal := NewFromCustomer(c)
-	_, err = mr.customer.UpdateOne(ctx, bson.M{"_id": c.GetID()}, bson.M{"$set": internal})
+	internal.Version++
+	// only update the document if nobody else did since it was loaded,
+	// documents stored before versioning have no version field
+	filter := bson.M{"_id": c.GetID(), "version": c.GetVersion()}
+	if c.GetVersion() == 0 {
+		filter = bson.M{"_id": c.GetID(), "$or": bson.A{
+			bson.M{"version": 0},
+			bson.M{"version": bson.M{"$exists": false}},
+		}}
+	}
+	result, err := mr.customer.UpdateOne(ctx, filter, bson.M{"$set": internal})
 	if err != nil {
 		return err
 	}
+	if result.MatchedCount == 0 {
+		//check if customer exists
+		if _, err := mr.Get(ctx, c.GetID()); err != nil {
+			return fmt.Errorf("customer not found: %w", err)
+		}
+		return fmt.Errorf("customer %s: %w", c.GetID(), customer.ErrConcurrentUpdate)
+	}
 
 	return nil
 }
 
-func (mr *MongoRepository) Delete(id uuid.UUID) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
+func (mr *MongoRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	_, err := mr.customer.DeleteOne(ctx, bson.M{"_id": id})
 	if err != nil {
 		return err
@@ -5,10 +5,12 @@ import (
 	"testing"
 	"time"
 
+	"github.com/devsrivatsa/tavernDDD/domain"
 	"github.com/devsrivatsa/tavernDDD/domain/customer"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
 )
 
 const (
@@ -16,6 +18,7 @@ const (
 	testTimeout           = 30 * time.Second
 )
 
+// setupTestRepo connects to a local server, the test is skipped when no server is running
 func setupTestRepo(t *testing.T) *MongoRepository {
 	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
 	defer cancel()
@@ -23,6 +26,12 @@ func setupTestRepo(t *testing.T) *MongoRepository {
 	repo, err := New(ctx, mongoConnectionString)
 	require.NoError(t, err, "Failed to create MongoDB repository")
 
+	pingCtx, cancelPing := context.WithTimeout(ctx, 2*time.Second)
+	defer cancelPing()
+	if err := repo.db.Client().Ping(pingCtx, nil); err != nil {
+		t.Skipf("no MongoDB server at %s: %v", mongoConnectionString, err)
+	}
+
 	// Clean up any existing test data
 	cleanupTestData(t, repo)
 
@@ -104,7 +113,7 @@ func TestMongoRepository_Add(t *testing.T) {
 			customer, err := customer.NewCustomer(tt.customerName)
 			require.NoError(t, err)
 
-			err = repo.Add(customer)
+			err = repo.Add(context.Background(), customer)
 
 			if tt.expectError {
 				assert.Error(t, err)
@@ -112,7 +121,7 @@ func TestMongoRepository_Add(t *testing.T) {
 				assert.NoError(t, err)
 
 				// Verify the customer was actually added
-				retrievedCustomer, err := repo.Get(customer.GetID())
+				retrievedCustomer, err := repo.Get(context.Background(), customer.GetID())
 				assert.NoError(t, err)
 				assert.Equal(t, customer.GetID(), retrievedCustomer.GetID())
 				assert.Equal(t, customer.GetName(), retrievedCustomer.GetName())
@@ -129,7 +138,7 @@ func TestMongoRepository_Get(t *testing.T) {
 	testCustomer, err := customer.NewCustomer("Test Customer")
 	require.NoError(t, err)
 
-	err = repo.Add(testCustomer)
+	err = repo.Add(context.Background(), testCustomer)
 	require.NoError(t, err)
 
 	tests := []struct {
@@ -151,7 +160,7 @@ func TestMongoRepository_Get(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			retrievedCustomer, err := repo.Get(tt.customerID)
+			retrievedCustomer, err := repo.Get(context.Background(), tt.customerID)
 
 			if tt.expectError {
 				assert.Error(t, err)
@@ -173,7 +182,7 @@ func TestMongoRepository_Update(t *testing.T) {
 	originalCustomer, err := customer.NewCustomer("Original Name")
 	require.NoError(t, err)
 
-	err = repo.Add(originalCustomer)
+	err = repo.Add(context.Background(), originalCustomer)
 	require.NoError(t, err)
 
 	tests := []struct {
@@ -204,7 +213,7 @@ func TestMongoRepository_Update(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			customerToUpdate := tt.setupCustomer()
 
-			err := repo.Update(customerToUpdate)
+			err := repo.Update(context.Background(), customerToUpdate)
 
 			if tt.expectError {
 				assert.Error(t, err)
@@ -212,7 +221,7 @@ func TestMongoRepository_Update(t *testing.T) {
 				assert.NoError(t, err)
 
 				// Verify the update was successful
-				retrievedCustomer, err := repo.Get(customerToUpdate.GetID())
+				retrievedCustomer, err := repo.Get(context.Background(), customerToUpdate.GetID())
 				assert.NoError(t, err)
 				assert.Equal(t, customerToUpdate.GetName(), retrievedCustomer.GetName())
 			}
@@ -228,7 +237,7 @@ func TestMongoRepository_Delete(t *testing.T) {
 	testCustomer, err := customer.NewCustomer("Customer To Delete")
 	require.NoError(t, err)
 
-	err = repo.Add(testCustomer)
+	err = repo.Add(context.Background(), testCustomer)
 	require.NoError(t, err)
 
 	tests := []struct {
@@ -250,7 +259,7 @@ func TestMongoRepository_Delete(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := repo.Delete(tt.customerID)
+			err := repo.Delete(context.Background(), tt.customerID)
 
 			if tt.expectError {
 				assert.Error(t, err)
@@ -259,7 +268,7 @@ func TestMongoRepository_Delete(t *testing.T) {
 
 				// Verify the customer was actually deleted (only for the first test case)
 				if tt.name == "Delete existing customer" {
-					_, err := repo.Get(tt.customerID)
+					_, err := repo.Get(context.Background(), tt.customerID)
 					assert.Error(t, err, "Customer should not exist after deletion")
 				}
 			}
@@ -301,30 +310,175 @@ func TestMongoRepository_FullCRUDCycle(t *testing.T) {
 	originalCustomer, err := customer.NewCustomer("CRUD Test Customer")
 	require.NoError(t, err)
 
-	err = repo.Add(originalCustomer)
+	err = repo.Add(context.Background(), originalCustomer)
 	require.NoError(t, err)
 
 	// Read
-	retrievedCustomer, err := repo.Get(originalCustomer.GetID())
+	retrievedCustomer, err := repo.Get(context.Background(), originalCustomer.GetID())
 	require.NoError(t, err)
 	assert.Equal(t, originalCustomer.GetID(), retrievedCustomer.GetID())
 	assert.Equal(t, originalCustomer.GetName(), retrievedCustomer.GetName())
 
 	// Update
 	retrievedCustomer.SetName("Updated CRUD Customer")
-	err = repo.Update(retrievedCustomer)
+	err = repo.Update(context.Background(), retrievedCustomer)
 	require.NoError(t, err)
 
 	// Verify Update
-	updatedCustomer, err := repo.Get(retrievedCustomer.GetID())
+	updatedCustomer, err := repo.Get(context.Background(), retrievedCustomer.GetID())
 	require.NoError(t, err)
 	assert.Equal(t, "Updated CRUD Customer", updatedCustomer.GetName())
 
 	// Delete
-	err = repo.Delete(updatedCustomer.GetID())
+	err = repo.Delete(context.Background(), updatedCustomer.GetID())
 	require.NoError(t, err)
 
 	// Verify Delete
-	_, err = repo.Get(updatedCustomer.GetID())
+	_, err = repo.Get(context.Background(), updatedCustomer.GetID())
 	assert.Error(t, err, "Customer should not exist after deletion")
 }
+
+func TestMongoCustomer_Transactions(t *testing.T) {
+	testCustomer, err := customer.NewCustomer("Test Customer")
+	require.NoError(t, err)
+
+	payment, err := domain.NewTransaction(testCustomer.GetID(), uuid.New(), domain.MustNewMoney(897, "EUR"), time.Now())
+	require.NoError(t, err)
+	require.NoError(t, testCustomer.AddTransaction(payment))
+
+	aggregate := NewFromCustomer(testCustomer).ToAggregate()
+
+	require.Len(t, aggregate.GetTransactions(), 1)
+	assert.Equal(t, payment, aggregate.GetTransactions()[0])
+}
+
+func TestMongoCustomer_Purchases(t *testing.T) {
+	testCustomer, err := customer.NewCustomer("Test Customer")
+	require.NoError(t, err)
+
+	purchase := customer.Purchase{
+		Item:        domain.Item{ID: uuid.New(), Name: "Beer", Description: "A refreshing beer"},
+		Quantity:    3,
+		PurchasedAt: time.Now(),
+	}
+	require.NoError(t, testCustomer.AddPurchase(purchase))
+
+	aggregate := NewFromCustomer(testCustomer).ToAggregate()
+
+	assert.Equal(t, []customer.Purchase{purchase}, aggregate.PurchaseHistory())
+}
+
+func TestMongoCustomer_Points(t *testing.T) {
+	testCustomer, err := customer.NewCustomer("Test Customer")
+	require.NoError(t, err)
+	require.NoError(t, testCustomer.EarnPoints(uuid.New(), 12, time.Now()))
+	require.NoError(t, testCustomer.RedeemPoints(uuid.New(), 5, time.Now()))
+	testCustomer.SetVersion(3)
+
+	aggregate := NewFromCustomer(testCustomer).ToAggregate()
+
+	assert.Equal(t, testCustomer.PointsHistory(), aggregate.PointsHistory())
+	assert.Equal(t, int64(7), aggregate.GetPoints())
+	assert.Equal(t, 3, aggregate.GetVersion())
+}
+
+func TestMongoCustomer_DateOfBirth(t *testing.T) {
+	testCustomer, err := customer.NewCustomer("Test Customer")
+	require.NoError(t, err)
+	dob := time.Date(1990, time.January, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, testCustomer.SetDateOfBirth(dob, true))
+
+	aggregate := NewFromCustomer(testCustomer).ToAggregate()
+
+	gotDOB, verified := aggregate.GetDateOfBirth()
+	assert.True(t, dob.Equal(gotDOB))
+	assert.True(t, verified)
+}
+
+// TestMongoCustomer_RoundTrip stores a customer with everything set as a BSON document
+// and checks that nothing is lost on the way back
+func TestMongoCustomer_RoundTrip(t *testing.T) {
+	// BSON keeps times to the millisecond
+	at := time.Date(2024, time.March, 1, 20, 15, 30, 123000000, time.UTC)
+	original, err := customer.NewCustomer("Test Customer")
+	require.NoError(t, err)
+	original.SetName("Renamed Customer")
+	require.NoError(t, original.SetDateOfBirth(time.Date(1990, time.January, 1, 0, 0, 0, 0, time.UTC), true))
+	orderID := uuid.New()
+	require.NoError(t, original.AddPurchase(customer.Purchase{
+		OrderID:     orderID,
+		Item:        domain.Item{ID: uuid.New(), Name: "Beer", Description: "A refreshing beer"},
+		Quantity:    2,
+		PurchasedAt: at,
+	}))
+	payment, err := domain.NewTransaction(original.GetID(), uuid.New(), domain.MustNewMoney(398, "EUR"), at)
+	require.NoError(t, err)
+	require.NoError(t, original.AddTransaction(payment))
+	refund, err := domain.NewTransaction(uuid.New(), original.GetID(), domain.MustNewMoney(199, "EUR"), at.Add(time.Minute))
+	require.NoError(t, err)
+	require.NoError(t, original.AddTransaction(refund))
+	require.NoError(t, original.EarnPoints(orderID, 39, at))
+	require.NoError(t, original.RedeemPoints(uuid.New(), 10, at.Add(time.Hour)))
+	original.SetVersion(4)
+
+	doc, err := bson.Marshal(NewFromCustomer(original))
+	require.NoError(t, err)
+	var stored mongoCustomer
+	require.NoError(t, bson.Unmarshal(doc, &stored))
+	aggregate := stored.ToAggregate()
+
+	assert.Equal(t, original.GetID(), aggregate.GetID())
+	assert.Equal(t, "Renamed Customer", aggregate.GetName())
+	dob, verified := original.GetDateOfBirth()
+	gotDOB, gotVerified := aggregate.GetDateOfBirth()
+	assert.True(t, dob.Equal(gotDOB))
+	assert.Equal(t, verified, gotVerified)
+	assert.Equal(t, original.GetVersion(), aggregate.GetVersion())
+
+	require.Len(t, aggregate.PurchaseHistory(), 1)
+	purchase, gotPurchase := original.PurchaseHistory()[0], aggregate.PurchaseHistory()[0]
+	assert.True(t, purchase.PurchasedAt.Equal(gotPurchase.PurchasedAt))
+	purchase.PurchasedAt, gotPurchase.PurchasedAt = time.Time{}, time.Time{}
+	assert.Equal(t, purchase, gotPurchase)
+
+	require.Len(t, aggregate.GetTransactions(), 2)
+	for i, tr := range original.GetTransactions() {
+		got := aggregate.GetTransactions()[i]
+		assert.Equal(t, tr.GetFrom(), got.GetFrom())
+		assert.Equal(t, tr.GetTo(), got.GetTo())
+		assert.True(t, tr.GetAmount().Equals(got.GetAmount()))
+		assert.True(t, tr.GetCreatedAt().Equal(got.GetCreatedAt()))
+	}
+
+	require.Len(t, aggregate.PointsHistory(), 2)
+	for i, e := range original.PointsHistory() {
+		got := aggregate.PointsHistory()[i]
+		assert.True(t, e.At.Equal(got.At))
+		e.At, got.At = time.Time{}, time.Time{}
+		assert.Equal(t, e, got)
+	}
+	assert.Equal(t, original.GetPoints(), aggregate.GetPoints())
+	assert.Equal(t, original.GetLifetimePoints(), aggregate.GetLifetimePoints())
+}
+
+func TestMongoRepository_RoundTrip(t *testing.T) {
+	repo := setupTestRepo(t)
+	defer cleanupTestData(t, repo)
+
+	original, err := customer.NewCustomer("Round Trip Customer")
+	require.NoError(t, err)
+	require.NoError(t, original.EarnPoints(uuid.New(), 25, time.Now()))
+	payment, err := domain.NewTransaction(original.GetID(), uuid.New(), domain.MustNewMoney(250, "EUR"), time.Now())
+	require.NoError(t, err)
+	require.NoError(t, original.AddTransaction(payment))
+	require.NoError(t, repo.Add(context.Background(), original))
+
+	stored, err := repo.Get(context.Background(), original.GetID())
+	require.NoError(t, err)
+	assert.Equal(t, original.GetName(), stored.GetName())
+	assert.Len(t, stored.GetTransactions(), 1)
+	assert.Equal(t, int64(25), stored.GetPoints())
+
+	_, err = repo.Get(context.Background(), uuid.New())
+	assert.ErrorIs(t, err, customer.ErrCustomerNotFound)
+}
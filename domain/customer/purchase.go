@@ -0,0 +1,16 @@
+package customer
+
+import (
+	"time"
+
+	"github.com/devsrivatsa/tavernDDD/domain"
+	"github.com/google/uuid"
+)
+
+// Purchase is a value object recording an item the customer bought. It has no identifier
+type Purchase struct {
+	OrderID     uuid.UUID
+	Item        domain.Item
+	Quantity    int
+	PurchasedAt time.Time
+}
@@ -0,0 +1,358 @@
+// Package sql keeps customers in a relational database through database/sql. The queries use
+// ? placeholders and portable types, it is tested against SQLite.
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/devsrivatsa/tavernDDD/domain"
+	"github.com/devsrivatsa/tavernDDD/domain/customer"
+	"github.com/devsrivatsa/tavernDDD/domain/migration"
+	"github.com/google/uuid"
+)
+
+// migrations of the customer tables, append new ones, never change one that was released
+var migrations = []migration.Migration{
+	{
+		Version: 1,
+		Name:    "create customers",
+		Statements: []string{
+			`CREATE TABLE customers (
+				id                     TEXT    PRIMARY KEY,
+				name                   TEXT    NOT NULL,
+				date_of_birth          TEXT    NOT NULL DEFAULT '',
+				date_of_birth_verified BOOLEAN NOT NULL DEFAULT FALSE,
+				version                INTEGER NOT NULL DEFAULT 0
+			)`,
+			`CREATE TABLE customer_purchases (
+				customer_id      TEXT    NOT NULL REFERENCES customers (id),
+				position         INTEGER NOT NULL,
+				order_id         TEXT    NOT NULL,
+				item_id          TEXT    NOT NULL,
+				item_name        TEXT    NOT NULL,
+				item_description TEXT    NOT NULL,
+				quantity         INTEGER NOT NULL,
+				purchased_at     TEXT    NOT NULL,
+				PRIMARY KEY (customer_id, position)
+			)`,
+			`CREATE TABLE customer_transactions (
+				customer_id TEXT    NOT NULL REFERENCES customers (id),
+				position    INTEGER NOT NULL,
+				from_id     TEXT    NOT NULL,
+				to_id       TEXT    NOT NULL,
+				amount      INTEGER NOT NULL,
+				currency    TEXT    NOT NULL,
+				created_at  TEXT    NOT NULL,
+				PRIMARY KEY (customer_id, position)
+			)`,
+			`CREATE TABLE customer_points (
+				customer_id TEXT    NOT NULL REFERENCES customers (id),
+				position    INTEGER NOT NULL,
+				order_id    TEXT    NOT NULL,
+				points      INTEGER NOT NULL,
+				reason      TEXT    NOT NULL,
+				at          TEXT    NOT NULL,
+				PRIMARY KEY (customer_id, position)
+			)`,
+		},
+	},
+}
+
+type SQLRepository struct {
+	db *sql.DB
+}
+
+// New brings the customer tables up to date and returns a repository keeping customers in them
+func New(ctx context.Context, db *sql.DB) (*SQLRepository, error) {
+	if err := migration.Apply(ctx, db, "customers", migrations); err != nil {
+		return nil, err
+	}
+	return &SQLRepository{db: db}, nil
+}
+
+func (sr *SQLRepository) Get(ctx context.Context, id uuid.UUID) (customer.Customer, error) {
+	var (
+		name, dob string
+		verified  bool
+		version   int
+	)
+	err := sr.db.QueryRowContext(ctx, `SELECT name, date_of_birth, date_of_birth_verified, version FROM customers WHERE id = ?`, id.String()).
+		Scan(&name, &dob, &verified, &version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return customer.Customer{}, customer.ErrCustomerNotFound
+	}
+	if err != nil {
+		return customer.Customer{}, err
+	}
+
+	c := customer.Customer{}
+	c.SetID(id)
+	c.SetName(name)
+	if dob != "" {
+		at, err := parseTime(dob)
+		if err != nil {
+			return customer.Customer{}, fmt.Errorf("customer %s: %w", id, err)
+		}
+		if err := c.SetDateOfBirth(at, verified); err != nil {
+			return customer.Customer{}, fmt.Errorf("customer %s: %w", id, err)
+		}
+	}
+	if err := sr.loadPurchases(ctx, &c); err != nil {
+		return customer.Customer{}, fmt.Errorf("customer %s: %w", id, err)
+	}
+	if err := sr.loadTransactions(ctx, &c); err != nil {
+		return customer.Customer{}, fmt.Errorf("customer %s: %w", id, err)
+	}
+	if err := sr.loadPoints(ctx, &c); err != nil {
+		return customer.Customer{}, fmt.Errorf("customer %s: %w", id, err)
+	}
+	c.SetVersion(version)
+
+	return c, nil
+}
+
+func (sr *SQLRepository) Add(ctx context.Context, c customer.Customer) error {
+	tx, err := sr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists int
+	err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM customers WHERE id = ?`, c.GetID().String()).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if exists > 0 {
+		return fmt.Errorf("customer %s already exists: %w", c.GetID(), customer.ErrFailedToAddCustomer)
+	}
+	dob, verified := c.GetDateOfBirth()
+	_, err = tx.ExecContext(ctx, `INSERT INTO customers (id, name, date_of_birth, date_of_birth_verified, version) VALUES (?, ?, ?, ?, ?)`,
+		c.GetID().String(), c.GetName(), formatTime(dob), verified, c.GetVersion())
+	if err != nil {
+		return err
+	}
+	if err := insertHistory(ctx, tx, c); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Update only changes the row if nobody else did since the customer was loaded, the histories
+// are rewritten in the same transaction
+func (sr *SQLRepository) Update(ctx context.Context, c customer.Customer) error {
+	tx, err := sr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	dob, verified := c.GetDateOfBirth()
+	result, err := tx.ExecContext(ctx, `UPDATE customers SET name = ?, date_of_birth = ?, date_of_birth_verified = ?, version = version + 1
+		WHERE id = ? AND version = ?`,
+		c.GetName(), formatTime(dob), verified, c.GetID().String(), c.GetVersion())
+	if err != nil {
+		return err
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		var exists int
+		if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM customers WHERE id = ?`, c.GetID().String()).Scan(&exists); err != nil {
+			return err
+		}
+		if exists == 0 {
+			return fmt.Errorf("customer does not exist %w", customer.ErrCustomerNotFound)
+		}
+		return fmt.Errorf("customer %s: %w", c.GetID(), customer.ErrConcurrentUpdate)
+	}
+
+	for _, table := range []string{"customer_purchases", "customer_transactions", "customer_points"} {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE customer_id = ?`, c.GetID().String()); err != nil {
+			return err
+		}
+	}
+	if err := insertHistory(ctx, tx, c); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (sr *SQLRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	tx, err := sr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"customer_purchases", "customer_transactions", "customer_points"} {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE customer_id = ?`, id.String()); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM customers WHERE id = ?`, id.String()); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// insertHistory stores the purchases, transactions and points of the customer, oldest first
+func insertHistory(ctx context.Context, tx *sql.Tx, c customer.Customer) error {
+	id := c.GetID().String()
+	for i, p := range c.PurchaseHistory() {
+		_, err := tx.ExecContext(ctx, `INSERT INTO customer_purchases
+			(customer_id, position, order_id, item_id, item_name, item_description, quantity, purchased_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			id, i, p.OrderID.String(), p.Item.ID.String(), p.Item.Name, p.Item.Description, p.Quantity, formatTime(p.PurchasedAt))
+		if err != nil {
+			return err
+		}
+	}
+	for i, t := range c.GetTransactions() {
+		_, err := tx.ExecContext(ctx, `INSERT INTO customer_transactions
+			(customer_id, position, from_id, to_id, amount, currency, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			id, i, t.GetFrom().String(), t.GetTo().String(), t.GetAmount().GetAmount(), t.GetAmount().GetCurrency(), formatTime(t.GetCreatedAt()))
+		if err != nil {
+			return err
+		}
+	}
+	for i, e := range c.PointsHistory() {
+		_, err := tx.ExecContext(ctx, `INSERT INTO customer_points (customer_id, position, order_id, points, reason, at) VALUES (?, ?, ?, ?, ?, ?)`,
+			id, i, e.OrderID.String(), e.Points, string(e.Reason), formatTime(e.At))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (sr *SQLRepository) loadPurchases(ctx context.Context, c *customer.Customer) error {
+	rows, err := sr.db.QueryContext(ctx, `SELECT order_id, item_id, item_name, item_description, quantity, purchased_at
+		FROM customer_purchases WHERE customer_id = ? ORDER BY position`, c.GetID().String())
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			orderID, itemID, purchasedAt string
+			p                            customer.Purchase
+		)
+		if err := rows.Scan(&orderID, &itemID, &p.Item.Name, &p.Item.Description, &p.Quantity, &purchasedAt); err != nil {
+			return err
+		}
+		if p.OrderID, err = uuid.Parse(orderID); err != nil {
+			return err
+		}
+		if p.Item.ID, err = uuid.Parse(itemID); err != nil {
+			return err
+		}
+		if p.PurchasedAt, err = parseTime(purchasedAt); err != nil {
+			return err
+		}
+		if err := c.AddPurchase(p); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (sr *SQLRepository) loadTransactions(ctx context.Context, c *customer.Customer) error {
+	rows, err := sr.db.QueryContext(ctx, `SELECT from_id, to_id, amount, currency, created_at
+		FROM customer_transactions WHERE customer_id = ? ORDER BY position`, c.GetID().String())
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			from, to, currency, createdAt string
+			amount                        int64
+		)
+		if err := rows.Scan(&from, &to, &amount, &currency, &createdAt); err != nil {
+			return err
+		}
+		fromID, err := uuid.Parse(from)
+		if err != nil {
+			return err
+		}
+		toID, err := uuid.Parse(to)
+		if err != nil {
+			return err
+		}
+		money, err := domain.NewMoney(amount, currency)
+		if err != nil {
+			return err
+		}
+		at, err := parseTime(createdAt)
+		if err != nil {
+			return err
+		}
+		t, err := domain.NewTransaction(fromID, toID, money, at)
+		if err != nil {
+			return err
+		}
+		if err := c.AddTransaction(t); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (sr *SQLRepository) loadPoints(ctx context.Context, c *customer.Customer) error {
+	rows, err := sr.db.QueryContext(ctx, `SELECT order_id, points, reason, at
+		FROM customer_points WHERE customer_id = ? ORDER BY position`, c.GetID().String())
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			orderID, reason, at string
+			e                   customer.PointsEntry
+		)
+		if err := rows.Scan(&orderID, &e.Points, &reason, &at); err != nil {
+			return err
+		}
+		if e.OrderID, err = uuid.Parse(orderID); err != nil {
+			return err
+		}
+		if e.At, err = parseTime(at); err != nil {
+			return err
+		}
+		e.Reason = customer.PointsReason(reason)
+		if err := c.AddPointsEntry(e); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// times are stored as RFC 3339 text so they read back the same with every driver,
+// the zero time is stored as an empty string
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339Nano, s)
+}
@@ -0,0 +1,112 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/devsrivatsa/tavernDDD/domain"
+	"github.com/devsrivatsa/tavernDDD/domain/customer"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+// setupTestRepo returns a repository on a fresh in-memory SQLite database
+func setupTestRepo(t *testing.T) *SQLRepository {
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	// every connection to :memory: is a database of its own
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	repo, err := New(context.Background(), db)
+	require.NoError(t, err)
+	return repo
+}
+
+func TestNew_Migrates(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	// starting again on the same database keeps the tables and their rows
+	c, err := customer.NewCustomer("Percy")
+	require.NoError(t, err)
+	require.NoError(t, repo.Add(context.Background(), c))
+	again, err := New(context.Background(), repo.db)
+	require.NoError(t, err)
+	_, err = again.Get(context.Background(), c.GetID())
+	assert.NoError(t, err)
+}
+
+func TestSQLRepository_RoundTrip(t *testing.T) {
+	repo := setupTestRepo(t)
+	at := time.Date(2024, time.March, 1, 20, 15, 30, 123456789, time.UTC)
+	original, err := customer.NewCustomer("Test Customer")
+	require.NoError(t, err)
+	require.NoError(t, original.SetDateOfBirth(time.Date(1990, time.January, 1, 0, 0, 0, 0, time.UTC), true))
+	orderID := uuid.New()
+	require.NoError(t, original.AddPurchase(customer.Purchase{
+		OrderID:     orderID,
+		Item:        domain.Item{ID: uuid.New(), Name: "Beer", Description: "A refreshing beer"},
+		Quantity:    2,
+		PurchasedAt: at,
+	}))
+	payment, err := domain.NewTransaction(original.GetID(), uuid.New(), domain.MustNewMoney(398, "EUR"), at)
+	require.NoError(t, err)
+	require.NoError(t, original.AddTransaction(payment))
+	require.NoError(t, original.EarnPoints(orderID, 39, at))
+	require.NoError(t, original.RedeemPoints(uuid.New(), 10, at.Add(time.Hour)))
+
+	require.NoError(t, repo.Add(context.Background(), original))
+	stored, err := repo.Get(context.Background(), original.GetID())
+	require.NoError(t, err)
+
+	assert.Equal(t, original.GetID(), stored.GetID())
+	assert.Equal(t, original.GetName(), stored.GetName())
+	dob, verified := original.GetDateOfBirth()
+	gotDOB, gotVerified := stored.GetDateOfBirth()
+	assert.True(t, dob.Equal(gotDOB))
+	assert.Equal(t, verified, gotVerified)
+	assert.Equal(t, original.PurchaseHistory(), stored.PurchaseHistory())
+	require.Len(t, stored.GetTransactions(), 1)
+	got := stored.GetTransactions()[0]
+	assert.Equal(t, payment.GetFrom(), got.GetFrom())
+	assert.True(t, payment.GetAmount().Equals(got.GetAmount()))
+	assert.True(t, payment.GetCreatedAt().Equal(got.GetCreatedAt()))
+	assert.Equal(t, original.PointsHistory(), stored.PointsHistory())
+
+	// an update replaces the histories and bumps the version
+	stored.SetName("Renamed Customer")
+	stored.RemovePurchases(orderID)
+	require.NoError(t, repo.Update(context.Background(), stored))
+	updated, err := repo.Get(context.Background(), original.GetID())
+	require.NoError(t, err)
+	assert.Equal(t, "Renamed Customer", updated.GetName())
+	assert.Empty(t, updated.PurchaseHistory())
+	assert.Len(t, updated.PointsHistory(), 2)
+	assert.Equal(t, 1, updated.GetVersion())
+}
+
+func TestSQLRepository_Errors(t *testing.T) {
+	repo := setupTestRepo(t)
+	c, err := customer.NewCustomer("Percy")
+	require.NoError(t, err)
+	require.NoError(t, repo.Add(context.Background(), c))
+
+	_, err = repo.Get(context.Background(), uuid.New())
+	assert.ErrorIs(t, err, customer.ErrCustomerNotFound)
+	assert.ErrorIs(t, repo.Add(context.Background(), c), customer.ErrFailedToAddCustomer)
+
+	stranger, err := customer.NewCustomer("Stranger")
+	require.NoError(t, err)
+	assert.ErrorIs(t, repo.Update(context.Background(), stranger), customer.ErrCustomerNotFound)
+
+	first, err := repo.Get(context.Background(), c.GetID())
+	require.NoError(t, err)
+	second, err := repo.Get(context.Background(), c.GetID())
+	require.NoError(t, err)
+	require.NoError(t, repo.Update(context.Background(), first))
+	assert.ErrorIs(t, repo.Update(context.Background(), second), customer.ErrConcurrentUpdate)
+}
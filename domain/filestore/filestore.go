@@ -0,0 +1,362 @@
+// Package filestore keeps JSON records in a file on disk, for repositories of a single machine
+// that need to survive a restart without a database.
+package filestore
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var (
+	ErrLocked = errors.New("the store is in use by another process")
+	ErrClosed = errors.New("the store is closed")
+	ErrFailed = errors.New("the store could not undo a failed write and must be reopened")
+)
+
+// Option configures a store (the same configuration pattern the services use)
+type Option func(s *Store) error
+
+// WithJournal appends every change to a journal next to the snapshot instead of rewriting the
+// snapshot each time, the journal is folded into the snapshot after the given number of changes
+func WithJournal(every int) Option {
+	return func(s *Store) error {
+		if every <= 0 {
+			return fmt.Errorf("compacting every %d journal entries: %w", every, os.ErrInvalid)
+		}
+		s.compactEvery = every
+		return nil
+	}
+}
+
+// entry is a line of the journal, a nil value deletes the record
+type entry struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// journalFile is the part of *os.File the journal is written with
+type journalFile interface {
+	io.Writer
+	Sync() error
+	Stat() (os.FileInfo, error)
+	Truncate(size int64) error
+	Close() error
+}
+
+// Store is a set of JSON records by key. Without a journal every change rewrites the snapshot
+// atomically, by writing a temporary file and renaming it over the snapshot. The store holds a
+// lock on the file until it is closed, so a second process cannot open it and write at once.
+type Store struct {
+	path    string
+	records map[string]json.RawMessage
+	lock    *os.File
+	//compactEvery is 0 without a journal
+	compactEvery int
+	journal      journalFile
+	entries      int
+	//failed is set when a journal entry that failed could not be taken out again
+	failed bool
+	sync.Mutex
+}
+
+// Open locks the store at path and loads its records, it is created on the first write
+func Open(path string, opts ...Option) (*Store, error) {
+	s := &Store{
+		path:    path,
+		records: make(map[string]json.RawMessage),
+	}
+	for _, opt := range opts {
+		if err := opt(s); err != nil {
+			return nil, err
+		}
+	}
+
+	lock, err := lockFile(path + ".lock")
+	if err != nil {
+		return nil, fmt.Errorf("error locking %s: %w", path, err)
+	}
+	s.lock = lock
+	if err := s.load(); err != nil {
+		s.Close()
+		return nil, err
+	}
+	if s.compactEvery > 0 {
+		// a journal left by a run without one is replayed above, so it can be started afresh
+		if err := s.compact(); err != nil {
+			s.Close()
+			return nil, err
+		}
+	} else if _, err := os.Stat(s.journalPath()); err == nil {
+		if err := s.writeSnapshot(); err != nil {
+			s.Close()
+			return nil, err
+		}
+		if err := os.Remove(s.journalPath()); err != nil {
+			s.Close()
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// Records returns a copy of the records by key
+func (s *Store) Records() map[string]json.RawMessage {
+	s.Lock()
+	defer s.Unlock()
+
+	records := make(map[string]json.RawMessage, len(s.records))
+	for k, v := range s.records {
+		records[k] = append(json.RawMessage(nil), v...)
+	}
+	return records
+}
+
+// Put stores the value as JSON under the key, replacing what was there
+func (s *Store) Put(key string, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	return s.write(entry{Key: key, Value: data})
+}
+
+func (s *Store) Delete(key string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.write(entry{Key: key})
+}
+
+// Compact folds the journal into the snapshot, e.g. from a ticker when the store is quiet
+func (s *Store) Compact() error {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.lock == nil {
+		return ErrClosed
+	}
+	if s.failed {
+		return ErrFailed
+	}
+	if s.compactEvery == 0 {
+		return nil
+	}
+	return s.compact()
+}
+
+// Close releases the lock, the store cannot be used afterwards
+func (s *Store) Close() error {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.lock == nil {
+		return ErrClosed
+	}
+	var err error
+	if s.journal != nil {
+		err = s.journal.Close()
+		s.journal = nil
+	}
+	err = errors.Join(err, unlockFile(s.lock))
+	s.lock = nil
+
+	return err
+}
+
+func (s *Store) write(e entry) error {
+	if s.lock == nil {
+		return ErrClosed
+	}
+	if s.failed {
+		return ErrFailed
+	}
+	previous, existed := s.records[e.Key]
+	s.apply(e)
+
+	var err error
+	if s.compactEvery == 0 {
+		err = s.writeSnapshot()
+	} else {
+		err = s.appendJournal(e)
+	}
+	if err != nil {
+		// keep the records as they are on disk
+		if existed {
+			s.records[e.Key] = previous
+		} else {
+			delete(s.records, e.Key)
+		}
+		return err
+	}
+
+	if s.compactEvery > 0 && s.entries >= s.compactEvery {
+		// the change is safe in the journal, compacting is tried again with the next one
+		if err := s.compact(); err != nil {
+			log.Printf("error compacting %s: %v", s.path, err)
+		}
+	}
+	return nil
+}
+
+func (s *Store) apply(e entry) {
+	if e.Value == nil {
+		delete(s.records, e.Key)
+		return
+	}
+	s.records[e.Key] = e.Value
+}
+
+func (s *Store) journalPath() string {
+	return s.path + ".journal"
+}
+
+// load reads the snapshot and replays the journal on top of it
+func (s *Store) load() error {
+	data, err := os.ReadFile(s.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &s.records); err != nil {
+			return fmt.Errorf("error reading %s: %w", s.path, err)
+		}
+	}
+
+	journal, err := os.Open(s.journalPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer journal.Close()
+
+	reader := bufio.NewReader(journal)
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// a last line without a newline was cut off by a crash while it was written
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var e entry
+		if err := json.Unmarshal(bytes.TrimSpace(data), &e); err != nil {
+			return fmt.Errorf("error reading line %d of %s: %w", line, s.journalPath(), err)
+		}
+		s.apply(e)
+	}
+}
+
+func (s *Store) appendJournal(e entry) error {
+	if s.journal == nil {
+		journal, err := os.OpenFile(s.journalPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return err
+		}
+		s.journal = journal
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	info, err := s.journal.Stat()
+	if err != nil {
+		return err
+	}
+	offset := info.Size()
+
+	line := append(data, '\n')
+	n, err := s.journal.Write(line)
+	if err == nil && n < len(line) {
+		err = io.ErrShortWrite
+	}
+	if err == nil {
+		err = s.journal.Sync()
+	}
+	if err != nil {
+		return s.undoAppend(offset, err)
+	}
+	s.entries++
+
+	return nil
+}
+
+// undoAppend cuts the journal back to where the failed entry started, so the change is not
+// replayed on the next open. When that fails too the store stops writing until it is reopened.
+func (s *Store) undoAppend(offset int64, err error) error {
+	truncateErr := s.journal.Truncate(offset)
+	if truncateErr == nil {
+		return err
+	}
+	s.journal.Close()
+	s.journal = nil
+	s.failed = true
+
+	return errors.Join(err, fmt.Errorf("error truncating %s: %w", s.journalPath(), truncateErr), ErrFailed)
+}
+
+// compact writes the snapshot and only then empties the journal, a crash in between replays
+// entries the snapshot already has, which leaves the same records
+func (s *Store) compact() error {
+	if err := s.writeSnapshot(); err != nil {
+		return err
+	}
+	if s.journal != nil {
+		if err := s.journal.Close(); err != nil {
+			return err
+		}
+		s.journal = nil
+	}
+	if err := os.Remove(s.journalPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	s.entries = 0
+
+	return nil
+}
+
+// writeSnapshot replaces the snapshot atomically, readers see either the old or the new file
+func (s *Store) writeSnapshot() error {
+	data, err := json.MarshalIndent(s.records, "", "  ")
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(s.path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return err
+	}
+
+	return syncDir(dir)
+}
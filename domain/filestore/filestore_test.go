@@ -0,0 +1,230 @@
+package filestore
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type drink struct {
+	Name  string `json:"name"`
+	Price int    `json:"price"`
+}
+
+func readDrink(t *testing.T, s *Store, key string) (drink, bool) {
+	data, ok := s.Records()[key]
+	if !ok {
+		return drink{}, false
+	}
+	var d drink
+	if err := json.Unmarshal(data, &d); err != nil {
+		t.Fatal(err)
+	}
+	return d, true
+}
+
+func TestStore_Snapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "drinks.json")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put("beer", drink{Name: "Beer", Price: 450}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put("wine", drink{Name: "Wine", Price: 600}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete("wine"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the snapshot is complete after every write, and no temporary files are left behind
+	files, err := filepath.Glob(path + ".*.tmp")
+	if err != nil || len(files) > 0 {
+		t.Errorf("expected no temporary files, got %v (%v)", files, err)
+	}
+	var onDisk map[string]drink
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &onDisk); err != nil {
+		t.Fatal(err)
+	}
+	if len(onDisk) != 1 || onDisk["beer"].Price != 450 {
+		t.Errorf("expected only the beer on disk, got %v", onDisk)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put("beer", drink{}); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected error %v, got %v", ErrClosed, err)
+	}
+	s, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if d, ok := readDrink(t, s, "beer"); !ok || d.Price != 450 {
+		t.Errorf("expected the beer to be reloaded, got %v", d)
+	}
+}
+
+func TestStore_Locked(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "drinks.json")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Open(path); !errors.Is(err, ErrLocked) {
+		t.Errorf("expected error %v, got %v", ErrLocked, err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	again, err := Open(path)
+	if err != nil {
+		t.Fatalf("expected the store to open once it is closed, got %v", err)
+	}
+	again.Close()
+}
+
+func TestStore_Journal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "drinks.json")
+	s, err := Open(path, WithJournal(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put("beer", drink{Name: "Beer", Price: 450}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put("beer", drink{Name: "Beer", Price: 500}); err != nil {
+		t.Fatal(err)
+	}
+	// the changes are only in the journal so far
+	if _, err := os.Stat(path + ".journal"); err != nil {
+		t.Errorf("expected a journal, got %v", err)
+	}
+
+	// the third change compacts the journal into the snapshot
+	if err := s.Put("wine", drink{Name: "Wine", Price: 600}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path + ".journal"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected the journal to be compacted, got %v", err)
+	}
+	if err := s.Delete("wine"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a change cut off by a crash is dropped when the journal is replayed
+	journal, err := os.OpenFile(path+".journal", os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := journal.WriteString(`{"key":"rum","value":{"na`); err != nil {
+		t.Fatal(err)
+	}
+	journal.Close()
+
+	s, err = Open(path, WithJournal(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	records := s.Records()
+	if len(records) != 1 {
+		t.Errorf("expected 1 record, got %d", len(records))
+	}
+	if d, ok := readDrink(t, s, "beer"); !ok || d.Price != 500 {
+		t.Errorf("expected the last price of the beer, got %v", d)
+	}
+}
+
+// failingJournal writes half of the first entry it is given and fails
+type failingJournal struct {
+	journalFile
+	truncate error
+}
+
+func (j failingJournal) Write(p []byte) (int, error) {
+	n, _ := j.journalFile.Write(p[:len(p)/2])
+	return n, errors.New("disk full")
+}
+
+func (j failingJournal) Truncate(size int64) error {
+	if j.truncate != nil {
+		return j.truncate
+	}
+	return j.journalFile.Truncate(size)
+}
+
+func TestStore_FailedAppend(t *testing.T) {
+	type testCase struct {
+		test           string
+		truncate       error
+		expectedErr    error
+		expectedRecord bool
+	}
+	testcases := []testCase{
+		{test: "Cut back", truncate: nil, expectedErr: nil, expectedRecord: true},
+		{test: "Not cut back", truncate: os.ErrPermission, expectedErr: ErrFailed, expectedRecord: false},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.test, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "drinks.json")
+			s, err := Open(path, WithJournal(10))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := s.Put("beer", drink{Name: "Beer", Price: 450}); err != nil {
+				t.Fatal(err)
+			}
+			s.journal = failingJournal{journalFile: s.journal, truncate: tc.truncate}
+			if err := s.Put("wine", drink{Name: "Wine", Price: 600}); err == nil {
+				t.Fatal("expected the append to fail")
+			}
+			if j, ok := s.journal.(failingJournal); ok {
+				s.journal = j.journalFile
+			}
+			// the store keeps writing while its journal is sound
+			err = s.Put("rum", drink{Name: "Rum", Price: 700})
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected error %v, got %v", tc.expectedErr, err)
+			}
+			if err := s.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			s, err = Open(path, WithJournal(10))
+			if err != nil {
+				t.Fatalf("expected the store to reopen, got %v", err)
+			}
+			defer s.Close()
+			if _, ok := readDrink(t, s, "beer"); !ok {
+				t.Error("expected the beer to be kept")
+			}
+			if _, ok := readDrink(t, s, "wine"); ok {
+				t.Error("expected the failed wine to be dropped")
+			}
+			if _, ok := readDrink(t, s, "rum"); ok != tc.expectedRecord {
+				t.Errorf("expected the rum to be kept %v, got %v", tc.expectedRecord, ok)
+			}
+		})
+	}
+}
+
+func TestWithJournal(t *testing.T) {
+	if _, err := Open(filepath.Join(t.TempDir(), "drinks.json"), WithJournal(0)); !errors.Is(err, os.ErrInvalid) {
+		t.Errorf("expected error %v, got %v", os.ErrInvalid, err)
+	}
+}
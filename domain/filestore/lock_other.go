@@ -0,0 +1,30 @@
+//go:build !unix
+
+package filestore
+
+import (
+	"errors"
+	"os"
+)
+
+// lockFile creates the lock file, it fails while another process has it. Unlike a lock held by
+// the kernel the file stays behind when the process dies and has to be removed by hand.
+func lockFile(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_RDWR, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		return nil, ErrLocked
+	}
+	return f, err
+}
+
+func unlockFile(f *os.File) error {
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Remove(f.Name())
+}
+
+// syncDir does nothing, directories cannot be synced on every platform
+func syncDir(dir string) error {
+	return nil
+}
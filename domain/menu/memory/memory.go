@@ -0,0 +1,120 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/devsrivatsa/tavernDDD/domain/menu"
+	"github.com/google/uuid"
+)
+
+type MemoryMenuRepository struct {
+	menus map[uuid.UUID]menu.Menu
+	sync.Mutex
+}
+
+func New() *MemoryMenuRepository {
+	return &MemoryMenuRepository{
+		menus: make(map[uuid.UUID]menu.Menu),
+	}
+}
+
+func (m *MemoryMenuRepository) Get(ctx context.Context, id uuid.UUID) (menu.Menu, error) {
+	if err := ctx.Err(); err != nil {
+		return menu.Menu{}, err
+	}
+	m.Lock()
+	defer m.Unlock()
+
+	if mn, ok := m.menus[id]; ok {
+		return mn, nil
+	}
+
+	return menu.Menu{}, menu.ErrMenuNotFound
+}
+
+func (m *MemoryMenuRepository) GetActive(ctx context.Context) (menu.Menu, error) {
+	if err := ctx.Err(); err != nil {
+		return menu.Menu{}, err
+	}
+	m.Lock()
+	defer m.Unlock()
+
+	for _, mn := range m.menus {
+		if mn.IsActive() {
+			return mn, nil
+		}
+	}
+
+	return menu.Menu{}, menu.ErrNoActiveMenu
+}
+
+// GetAll returns every menu sorted by name
+func (m *MemoryMenuRepository) GetAll(ctx context.Context) ([]menu.Menu, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.Lock()
+	defer m.Unlock()
+
+	menus := make([]menu.Menu, 0, len(m.menus))
+	for _, mn := range m.menus {
+		menus = append(menus, mn)
+	}
+	sort.Slice(menus, func(i, j int) bool {
+		return menus[i].GetName() < menus[j].GetName()
+	})
+
+	return menus, nil
+}
+
+func (m *MemoryMenuRepository) Add(ctx context.Context, mn menu.Menu) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.Lock()
+	defer m.Unlock()
+
+	if _, ok := m.menus[mn.GetID()]; ok {
+		return fmt.Errorf("error adding menu %s: %w", mn.GetID(), menu.ErrMenuAlreadyExists)
+	}
+	if err := m.checkActive(mn); err != nil {
+		return fmt.Errorf("error adding menu %s: %w", mn.GetID(), err)
+	}
+	m.menus[mn.GetID()] = mn
+
+	return nil
+}
+
+func (m *MemoryMenuRepository) Update(ctx context.Context, mn menu.Menu) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.Lock()
+	defer m.Unlock()
+
+	if _, ok := m.menus[mn.GetID()]; !ok {
+		return fmt.Errorf("error updating menu %s: %w", mn.GetID(), menu.ErrMenuNotFound)
+	}
+	if err := m.checkActive(mn); err != nil {
+		return fmt.Errorf("error updating menu %s: %w", mn.GetID(), err)
+	}
+	m.menus[mn.GetID()] = mn
+
+	return nil
+}
+
+// checkActive fails if mn is active while another menu is
+func (m *MemoryMenuRepository) checkActive(mn menu.Menu) error {
+	if !mn.IsActive() {
+		return nil
+	}
+	for id, other := range m.menus {
+		if id != mn.GetID() && other.IsActive() {
+			return menu.ErrActiveMenuExists
+		}
+	}
+	return nil
+}
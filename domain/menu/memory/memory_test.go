@@ -0,0 +1,48 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/devsrivatsa/tavernDDD/domain/menu"
+)
+
+func TestMemoryMenuRepository_GetActive(t *testing.T) {
+	repo := New()
+	if _, err := repo.GetActive(context.Background()); !errors.Is(err, menu.ErrNoActiveMenu) {
+		t.Errorf("expected error %v, got %v", menu.ErrNoActiveMenu, err)
+	}
+
+	lunch, err := menu.NewMenu("Lunch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lunch.Activate()
+	if err := repo.Add(context.Background(), lunch); err != nil {
+		t.Fatal(err)
+	}
+	evening, err := menu.NewMenu("Evening")
+	if err != nil {
+		t.Fatal(err)
+	}
+	evening.Activate()
+	if err := repo.Add(context.Background(), evening); !errors.Is(err, menu.ErrActiveMenuExists) {
+		t.Errorf("expected error %v, got %v", menu.ErrActiveMenuExists, err)
+	}
+
+	lunch.Deactivate()
+	if err := repo.Update(context.Background(), lunch); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Add(context.Background(), evening); err != nil {
+		t.Fatal(err)
+	}
+	active, err := repo.GetActive(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if active.GetID() != evening.GetID() {
+		t.Errorf("expected the evening menu to be active, got %s", active.GetName())
+	}
+}
@@ -0,0 +1,281 @@
+package menu
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrMissingName      = errors.New("a menu and its categories need a name")
+	ErrCategoryExists   = errors.New("the menu already has the category")
+	ErrCategoryNotFound = errors.New("the menu has no such category")
+	ErrInvalidItem      = errors.New("a menu item needs a product")
+	ErrItemOnMenu       = errors.New("the product is already on the menu")
+	ErrNotOnMenu        = errors.New("the product is not on the menu")
+	ErrItemUnavailable  = errors.New("the product is temporarily unavailable")
+	ErrOutsideHours     = errors.New("the product is not served at this time")
+	ErrInvalidWindow    = errors.New("a window runs between two different times of day")
+	ErrInvalidPosition  = errors.New("the position is outside the list")
+)
+
+// Window is a value object for the part of the day something is served, given as the time since midnight.
+// A window ending before it starts runs past midnight, e.g. 22:00 to 02:00.
+type Window struct {
+	From time.Duration
+	To   time.Duration
+}
+
+// NewWindow creates a window from one time of day to another
+func NewWindow(from, to time.Duration) (Window, error) {
+	day := 24 * time.Hour
+	if from < 0 || to < 0 || from >= day || to > day || from == to {
+		return Window{}, ErrInvalidWindow
+	}
+	return Window{From: from, To: to}, nil
+}
+
+// Contains reports whether the time of day of at falls in the window
+func (w Window) Contains(at time.Time) bool {
+	// the clock time rather than the time since midnight, which is an hour off when the clocks change
+	now := time.Duration(at.Hour())*time.Hour + time.Duration(at.Minute())*time.Minute + time.Duration(at.Second())*time.Second
+	if w.From <= w.To {
+		return now >= w.From && now < w.To
+	}
+	return now >= w.From || now < w.To
+}
+
+// Item is a product on the menu. Without windows it is served all day.
+type Item struct {
+	ProductID uuid.UUID
+	Windows   []Window
+	// Unavailable takes the item off temporarily, e.g. when the kitchen runs out
+	Unavailable bool
+}
+
+// AvailableAt reports why the item cannot be served at the given time, it returns nil when it can
+func (i Item) AvailableAt(at time.Time) error {
+	if i.Unavailable {
+		return ErrItemUnavailable
+	}
+	if len(i.Windows) == 0 {
+		return nil
+	}
+	for _, w := range i.Windows {
+		if w.Contains(at) {
+			return nil
+		}
+	}
+	return ErrOutsideHours
+}
+
+// Category groups items on the menu, e.g. Draught or Snacks. Items are in display order.
+type Category struct {
+	Name  string
+	Items []Item
+}
+
+type Menu struct {
+	//id is the root entity identifier of the menu aggregate
+	id   uuid.UUID
+	name string
+	//categories in display order
+	categories []Category
+	//active marks the menu the tavern is currently serving from
+	active bool
+}
+
+// factory function to create a new empty, inactive menu
+func NewMenu(name string) (Menu, error) {
+	if name == "" {
+		return Menu{}, ErrMissingName
+	}
+
+	return Menu{
+		id:         uuid.New(),
+		name:       name,
+		categories: make([]Category, 0),
+	}, nil
+}
+
+func (m Menu) GetID() uuid.UUID {
+	return m.id
+}
+
+func (m Menu) GetName() string {
+	return m.name
+}
+
+// GetCategories returns a copy of the categories and their items, in display order
+func (m Menu) GetCategories() []Category {
+	return cloneCategories(m.categories)
+}
+
+func (m Menu) IsActive() bool {
+	return m.active
+}
+
+func (m *Menu) Activate() {
+	m.active = true
+}
+
+func (m *Menu) Deactivate() {
+	m.active = false
+}
+
+// AddCategory appends an empty category to the menu
+func (m *Menu) AddCategory(name string) error {
+	if name == "" {
+		return ErrMissingName
+	}
+	if m.categoryIndex(name) >= 0 {
+		return fmt.Errorf("category %q: %w", name, ErrCategoryExists)
+	}
+	m.categories = append(cloneCategories(m.categories), Category{Name: name, Items: make([]Item, 0)})
+
+	return nil
+}
+
+// MoveCategory moves a category to the given position, 0 being the first
+func (m *Menu) MoveCategory(name string, position int) error {
+	i := m.categoryIndex(name)
+	if i < 0 {
+		return fmt.Errorf("category %q: %w", name, ErrCategoryNotFound)
+	}
+	categories := cloneCategories(m.categories)
+	if err := move(categories, i, position); err != nil {
+		return err
+	}
+	m.categories = categories
+
+	return nil
+}
+
+// AddItem appends the item to a category. A product can only be on the menu once.
+func (m *Menu) AddItem(category string, item Item) error {
+	if item.ProductID == uuid.Nil {
+		return ErrInvalidItem
+	}
+	for _, w := range item.Windows {
+		if _, err := NewWindow(w.From, w.To); err != nil {
+			return err
+		}
+	}
+	i := m.categoryIndex(category)
+	if i < 0 {
+		return fmt.Errorf("category %q: %w", category, ErrCategoryNotFound)
+	}
+	if _, _, ok := m.itemIndex(item.ProductID); ok {
+		return fmt.Errorf("product %s: %w", item.ProductID, ErrItemOnMenu)
+	}
+
+	categories := cloneCategories(m.categories)
+	item.Windows = append([]Window(nil), item.Windows...)
+	categories[i].Items = append(categories[i].Items, item)
+	m.categories = categories
+
+	return nil
+}
+
+// MoveItem moves an item to the given position within its category, 0 being the first
+func (m *Menu) MoveItem(productID uuid.UUID, position int) error {
+	c, i, ok := m.itemIndex(productID)
+	if !ok {
+		return fmt.Errorf("product %s: %w", productID, ErrNotOnMenu)
+	}
+	categories := cloneCategories(m.categories)
+	if err := move(categories[c].Items, i, position); err != nil {
+		return err
+	}
+	m.categories = categories
+
+	return nil
+}
+
+// RemoveItem takes the product off the menu
+func (m *Menu) RemoveItem(productID uuid.UUID) error {
+	c, i, ok := m.itemIndex(productID)
+	if !ok {
+		return fmt.Errorf("product %s: %w", productID, ErrNotOnMenu)
+	}
+	categories := cloneCategories(m.categories)
+	categories[c].Items = append(categories[c].Items[:i], categories[c].Items[i+1:]...)
+	m.categories = categories
+
+	return nil
+}
+
+// SetUnavailable takes an item off temporarily or puts it back on
+func (m *Menu) SetUnavailable(productID uuid.UUID, unavailable bool) error {
+	c, i, ok := m.itemIndex(productID)
+	if !ok {
+		return fmt.Errorf("product %s: %w", productID, ErrNotOnMenu)
+	}
+	categories := cloneCategories(m.categories)
+	categories[c].Items[i].Unavailable = unavailable
+	m.categories = categories
+
+	return nil
+}
+
+// AvailableAt reports why the product cannot be ordered from the menu at the given time,
+// it returns nil when it can
+func (m Menu) AvailableAt(productID uuid.UUID, at time.Time) error {
+	c, i, ok := m.itemIndex(productID)
+	if !ok {
+		return ErrNotOnMenu
+	}
+	return m.categories[c].Items[i].AvailableAt(at)
+}
+
+func (m Menu) categoryIndex(name string) int {
+	for i, c := range m.categories {
+		if c.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m Menu) itemIndex(productID uuid.UUID) (int, int, bool) {
+	for c, category := range m.categories {
+		for i, item := range category.Items {
+			if item.ProductID == productID {
+				return c, i, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// cloneCategories copies the categories deep enough that changing the copy never changes
+// another copy of the menu
+func cloneCategories(categories []Category) []Category {
+	cloned := make([]Category, len(categories))
+	for i, c := range categories {
+		cloned[i] = Category{Name: c.Name, Items: make([]Item, len(c.Items))}
+		for j, item := range c.Items {
+			item.Windows = append([]Window(nil), item.Windows...)
+			cloned[i].Items[j] = item
+		}
+	}
+	return cloned
+}
+
+// move shifts the element at from to position to, keeping the order of the others
+func move[T any](s []T, from, to int) error {
+	if to < 0 || to >= len(s) {
+		return fmt.Errorf("position %d of %d: %w", to, len(s), ErrInvalidPosition)
+	}
+	v := s[from]
+	if from < to {
+		copy(s[from:to], s[from+1:to+1])
+	} else {
+		copy(s[to+1:from+1], s[to:from])
+	}
+	s[to] = v
+
+	return nil
+}
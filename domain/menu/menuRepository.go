@@ -0,0 +1,26 @@
+package menu
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrMenuNotFound      = errors.New("menu not found")
+	ErrMenuAlreadyExists = errors.New("menu already exists")
+	ErrNoActiveMenu      = errors.New("no menu is active")
+	ErrActiveMenuExists  = errors.New("another menu is already active")
+)
+
+// manage menu aggregates, at most one menu is active at a time
+type MenuRepository interface {
+	Get(ctx context.Context, id uuid.UUID) (Menu, error)
+	// GetActive fails with ErrNoActiveMenu when no menu is active
+	GetActive(ctx context.Context) (Menu, error)
+	GetAll(ctx context.Context) ([]Menu, error)
+	// Add and Update fail with ErrActiveMenuExists when activating a second menu
+	Add(ctx context.Context, menu Menu) error
+	Update(ctx context.Context, menu Menu) error
+}
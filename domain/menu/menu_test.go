@@ -0,0 +1,111 @@
+package menu_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/devsrivatsa/tavernDDD/domain/menu"
+	"github.com/google/uuid"
+)
+
+func TestMenu_Ordering(t *testing.T) {
+	m, err := menu.NewMenu("Evening")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []string{"Draught", "Wine", "Snacks"} {
+		if err := m.AddCategory(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.AddCategory("Wine"); !errors.Is(err, menu.ErrCategoryExists) {
+		t.Errorf("expected error %v, got %v", menu.ErrCategoryExists, err)
+	}
+	lager, stout := uuid.New(), uuid.New()
+	for _, id := range []uuid.UUID{lager, stout} {
+		if err := m.AddItem("Draught", menu.Item{ProductID: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.AddItem("Wine", menu.Item{ProductID: lager}); !errors.Is(err, menu.ErrItemOnMenu) {
+		t.Errorf("expected error %v, got %v", menu.ErrItemOnMenu, err)
+	}
+
+	before := m.GetCategories()
+	if err := m.MoveCategory("Snacks", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.MoveItem(stout, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.MoveItem(stout, 2); !errors.Is(err, menu.ErrInvalidPosition) {
+		t.Errorf("expected error %v, got %v", menu.ErrInvalidPosition, err)
+	}
+
+	categories := m.GetCategories()
+	names := []string{categories[0].Name, categories[1].Name, categories[2].Name}
+	if names[0] != "Snacks" || names[1] != "Draught" || names[2] != "Wine" {
+		t.Errorf("expected Snacks, Draught, Wine, got %v", names)
+	}
+	if categories[1].Items[0].ProductID != stout {
+		t.Errorf("expected the stout first in Draught")
+	}
+	if before[0].Name != "Draught" || before[0].Items[0].ProductID != lager {
+		t.Errorf("expected an earlier copy of the categories to be left alone")
+	}
+}
+
+func TestMenu_AvailableAt(t *testing.T) {
+	type testCase struct {
+		test          string
+		at            time.Time
+		unavailable   bool
+		expectedError error
+	}
+
+	kitchen, err := menu.NewWindow(12*time.Hour, 22*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	late, err := menu.NewWindow(23*time.Hour, 2*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testcases := []testCase{
+		{test: "Kitchen open", at: day.Add(21 * time.Hour), expectedError: nil},
+		{test: "Kitchen closed", at: day.Add(22 * time.Hour), expectedError: menu.ErrOutsideHours},
+		{test: "Late window past midnight", at: day.Add(25 * time.Hour), expectedError: nil},
+		{test: "Marked unavailable", at: day.Add(13 * time.Hour), unavailable: true, expectedError: menu.ErrItemUnavailable},
+		{test: "Kitchen closed the day the clocks go forward", at: time.Date(2025, time.March, 30, 22, 30, 0, 0, berlin), expectedError: menu.ErrOutsideHours},
+		{test: "Kitchen open the day the clocks go back", at: time.Date(2025, time.October, 26, 21, 30, 0, 0, berlin), expectedError: nil},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.test, func(t *testing.T) {
+			m, err := menu.NewMenu("Evening")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := m.AddCategory("Snacks"); err != nil {
+				t.Fatal(err)
+			}
+			fries := uuid.New()
+			if err := m.AddItem("Snacks", menu.Item{ProductID: fries, Windows: []menu.Window{kitchen, late}}); err != nil {
+				t.Fatal(err)
+			}
+			if err := m.SetUnavailable(fries, tc.unavailable); err != nil {
+				t.Fatal(err)
+			}
+			err = m.AvailableAt(fries, tc.at)
+			if !errors.Is(err, tc.expectedError) {
+				t.Errorf("expected error %v, got %v", tc.expectedError, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrMissingComponent = errors.New("migrations need the component they belong to")
+	ErrInvalidVersion   = errors.New("migration versions start at 1 and go up one at a time")
+)
+
+// Migration is one versioned change to the schema of a component, e.g. the customer tables.
+// The statements of a migration are applied together or not at all.
+type Migration struct {
+	Version    int
+	Name       string
+	Statements []string
+}
+
+// schema_migrations records the migrations applied to the database, per component, so every
+// repository can keep its own schema without agreeing on version numbers with the others
+const createTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	component  TEXT    NOT NULL,
+	version    INTEGER NOT NULL,
+	name       TEXT    NOT NULL,
+	applied_at TEXT    NOT NULL,
+	PRIMARY KEY (component, version)
+)`
+
+// Apply brings the schema of the component up to the last of the migrations, applying the
+// ones the database has not seen yet in order. It is safe to call on every start, also from
+// several instances starting at once: each migration is checked again inside the transaction
+// that applies it, so only one of them applies it and the others skip it. The database has to
+// make them wait for each other rather than fail, e.g. with a busy timeout for SQLite.
+func Apply(ctx context.Context, db *sql.DB, component string, migrations []Migration) error {
+	if component == "" {
+		return ErrMissingComponent
+	}
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return fmt.Errorf("migration %q of %s: %w", m.Name, component, ErrInvalidVersion)
+		}
+	}
+	// outside the transactions of the migrations, so each of them starts with a write and
+	// instances starting at once queue up instead of locking each other out
+	if _, err := db.ExecContext(ctx, createTable); err != nil {
+		return fmt.Errorf("error creating schema_migrations: %w", err)
+	}
+
+	for _, m := range migrations {
+		if err := apply(ctx, db, component, m); err != nil {
+			return fmt.Errorf("error applying migration %d %q of %s: %w", m.Version, m.Name, component, err)
+		}
+	}
+
+	return nil
+}
+
+// Version returns the last migration applied for the component, 0 when there is none
+func Version(ctx context.Context, db *sql.DB, component string) (int, error) {
+	var version sql.NullInt64
+	err := db.QueryRowContext(ctx, `SELECT MAX(version) FROM schema_migrations WHERE component = ?`, component).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("error reading schema version of %s: %w", component, err)
+	}
+	return int(version.Int64), nil
+}
+
+func apply(ctx context.Context, db *sql.DB, component string, m Migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// recording the migration first takes the write lock, so an instance starting at the
+	// same time waits for this one and then finds the migration applied
+	res, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (component, version, name, applied_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (component, version) DO NOTHING`,
+		component, m.Version, m.Name, time.Now().UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return err
+	}
+	recorded, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if recorded == 0 {
+		// applied already, on an earlier start or by another instance
+		return nil
+	}
+
+	for _, stmt := range m.Statements {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,153 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+func openDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// every connection to :memory: is a database of its own
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestApply(t *testing.T) {
+	db := openDB(t)
+	migrations := []Migration{
+		{Version: 1, Name: "create drinks", Statements: []string{`CREATE TABLE drinks (name TEXT)`}},
+	}
+
+	if err := Apply(context.Background(), db, "drinks", migrations); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	// applying again on the next start leaves the schema alone
+	if err := Apply(context.Background(), db, "drinks", migrations); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	migrations = append(migrations, Migration{Version: 2, Name: "add price", Statements: []string{`ALTER TABLE drinks ADD COLUMN price INTEGER`}})
+	if err := Apply(context.Background(), db, "drinks", migrations); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if version, err := Version(context.Background(), db, "drinks"); err != nil || version != 2 {
+		t.Errorf("expected version 2, got %d (%v)", version, err)
+	}
+	if version, err := Version(context.Background(), db, "snacks"); err != nil || version != 0 {
+		t.Errorf("expected version 0 for another component, got %d (%v)", version, err)
+	}
+	if _, err := db.Exec(`INSERT INTO drinks (name, price) VALUES ('Beer', 450)`); err != nil {
+		t.Errorf("expected the migrated table, got %v", err)
+	}
+}
+
+func TestApply_Failure(t *testing.T) {
+	db := openDB(t)
+
+	type testCase struct {
+		name          string
+		migrations    []Migration
+		expectedError error
+	}
+	testCases := []testCase{
+		{
+			name:          "missing version",
+			migrations:    []Migration{{Version: 2, Name: "skips one", Statements: []string{`CREATE TABLE a (id TEXT)`}}},
+			expectedError: ErrInvalidVersion,
+		},
+		{
+			name: "failing statement",
+			migrations: []Migration{{Version: 1, Name: "half done", Statements: []string{
+				`CREATE TABLE b (id TEXT)`,
+				`CREATE TABLE b (id TEXT)`,
+			}}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Apply(context.Background(), db, tc.name, tc.migrations)
+			if err == nil {
+				t.Fatal("expected an error, got nil")
+			}
+			if tc.expectedError != nil && !errors.Is(err, tc.expectedError) {
+				t.Errorf("expected error %v, got %v", tc.expectedError, err)
+			}
+			if version, _ := Version(context.Background(), db, tc.name); version != 0 {
+				t.Errorf("expected nothing to be applied, got version %d", version)
+			}
+		})
+	}
+	// the failed migration was rolled back as a whole
+	if _, err := db.Exec(`CREATE TABLE b (id TEXT)`); err != nil {
+		t.Errorf("expected table b to be rolled back, got %v", err)
+	}
+}
+
+func TestApply_Concurrent(t *testing.T) {
+	// a file, so the instances share the database but not a connection
+	dsn := "file:" + filepath.Join(t.TempDir(), "tavern.db") + "?_pragma=busy_timeout(5000)"
+	migrations := []Migration{
+		{Version: 1, Name: "create drinks", Statements: []string{`CREATE TABLE drinks (name TEXT)`}},
+		{Version: 2, Name: "add price", Statements: []string{`ALTER TABLE drinks ADD COLUMN price INTEGER`}},
+	}
+	open := func() *sql.DB {
+		db, err := sql.Open("sqlite", dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		return db
+	}
+
+	// hold the write lock while the instances start, so they all find the schema behind
+	// before any of them gets to migrate it
+	lock, err := open().Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := lock.Exec(createTable); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := lock.Exec(`INSERT INTO schema_migrations (component, version, name, applied_at) VALUES ('lock', 1, 'lock', '')`); err != nil {
+		t.Fatal(err)
+	}
+	if err := lock.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	lock, err = open().Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := lock.Exec(`DELETE FROM schema_migrations WHERE component = 'lock'`); err != nil {
+		t.Fatal(err)
+	}
+
+	const instances = 5
+	errs := make(chan error, instances)
+	for range instances {
+		db := open()
+		go func() { errs <- Apply(context.Background(), db, "drinks", migrations) }()
+	}
+	time.Sleep(100 * time.Millisecond)
+	lock.Rollback()
+
+	for range instances {
+		if err := <-errs; err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	}
+	if version, err := Version(context.Background(), open(), "drinks"); err != nil || version != 2 {
+		t.Errorf("expected version 2, got %d (%v)", version, err)
+	}
+}
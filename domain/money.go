@@ -0,0 +1,220 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrInvalidCurrency   = errors.New("currency must be a 3 letter ISO 4217 code")
+	ErrCurrencyMismatch  = errors.New("money amounts have different currencies")
+	ErrInvalidAllocation = errors.New("allocation ratios must be non-negative and add up to more than zero")
+)
+
+// minorUnits holds the ISO 4217 currencies that do not use 2 decimal places
+var minorUnits = map[string]int{
+	"BHD": 3,
+	"JPY": 0,
+	"KRW": 0,
+	"KWD": 3,
+	"OMR": 3,
+	"TND": 3,
+}
+
+// Money is a value object that represents an amount of money in the minor unit of its currency
+// (cents for EUR and USD). It has no identifier and is never modified in place.
+type Money struct {
+	amount   int64
+	currency string
+}
+
+// factory function to create a new money value, amount is given in minor units (199 EUR is 1.99 EUR)
+func NewMoney(amount int64, currency string) (Money, error) {
+	currency = strings.ToUpper(currency)
+	if len(currency) != 3 {
+		return Money{}, ErrInvalidCurrency
+	}
+	for _, r := range currency {
+		if r < 'A' || r > 'Z' {
+			return Money{}, ErrInvalidCurrency
+		}
+	}
+
+	return Money{
+		amount:   amount,
+		currency: currency,
+	}, nil
+}
+
+// MustNewMoney is like NewMoney but panics on an invalid currency. Meant for fixed values and tests.
+func MustNewMoney(amount int64, currency string) Money {
+	m, err := NewMoney(amount, currency)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func (m Money) GetAmount() int64 {
+	return m.amount
+}
+
+func (m Money) GetCurrency() string {
+	return m.currency
+}
+
+func (m Money) IsZero() bool {
+	return m.amount == 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.amount < 0
+}
+
+func (m Money) IsPositive() bool {
+	return m.amount > 0
+}
+
+func (m Money) Add(other Money) (Money, error) {
+	if err := m.assertSameCurrency(other); err != nil {
+		return Money{}, err
+	}
+	return Money{amount: m.amount + other.amount, currency: m.currency}, nil
+}
+
+func (m Money) Sub(other Money) (Money, error) {
+	if err := m.assertSameCurrency(other); err != nil {
+		return Money{}, err
+	}
+	return Money{amount: m.amount - other.amount, currency: m.currency}, nil
+}
+
+func (m Money) Multiply(n int64) Money {
+	return Money{amount: m.amount * n, currency: m.currency}
+}
+
+// MultiplyFraction returns m * numerator / denominator, rounded half away from zero.
+// The denominator must be greater than zero.
+func (m Money) MultiplyFraction(numerator, denominator int64) Money {
+	product := m.amount * numerator
+	quotient := product / denominator
+	remainder := product % denominator
+	if remainder < 0 {
+		remainder = -remainder
+	}
+	if remainder*2 >= denominator {
+		if product < 0 {
+			quotient--
+		} else {
+			quotient++
+		}
+	}
+
+	return Money{amount: quotient, currency: m.currency}
+}
+
+func (m Money) Negate() Money {
+	return Money{amount: -m.amount, currency: m.currency}
+}
+
+// Equals reports whether both values have the same amount and currency
+func (m Money) Equals(other Money) bool {
+	return m.amount == other.amount && m.currency == other.currency
+}
+
+// Compare returns -1, 0 or 1 depending on whether m is less than, equal to or greater than other
+func (m Money) Compare(other Money) (int, error) {
+	if err := m.assertSameCurrency(other); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.amount < other.amount:
+		return -1, nil
+	case m.amount > other.amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func (m Money) LessThan(other Money) (bool, error) {
+	cmp, err := m.Compare(other)
+	return cmp < 0, err
+}
+
+func (m Money) GreaterThan(other Money) (bool, error) {
+	cmp, err := m.Compare(other)
+	return cmp > 0, err
+}
+
+// Allocate splits the money according to the given ratios without losing any minor units.
+// Leftover units are handed out one by one starting from the first share.
+func (m Money) Allocate(ratios ...int) ([]Money, error) {
+	var total int64
+	for _, r := range ratios {
+		if r < 0 {
+			return nil, ErrInvalidAllocation
+		}
+		total += int64(r)
+	}
+	if total == 0 {
+		return nil, ErrInvalidAllocation
+	}
+
+	shares := make([]Money, len(ratios))
+	remainder := m.amount
+	for i, r := range ratios {
+		share := m.amount * int64(r) / total
+		shares[i] = Money{amount: share, currency: m.currency}
+		remainder -= share
+	}
+
+	step := int64(1)
+	if remainder < 0 {
+		step = -1
+	}
+	for i := 0; remainder != 0; i = (i + 1) % len(shares) {
+		if ratios[i] == 0 {
+			continue
+		}
+		shares[i].amount += step
+		remainder -= step
+	}
+
+	return shares, nil
+}
+
+// String formats the money in major units followed by the currency, e.g. "8.97 EUR"
+func (m Money) String() string {
+	exp := m.exponent()
+	if exp == 0 {
+		return fmt.Sprintf("%d %s", m.amount, m.currency)
+	}
+
+	sign := ""
+	amount := m.amount
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	div := int64(1)
+	for i := 0; i < exp; i++ {
+		div *= 10
+	}
+
+	return fmt.Sprintf("%s%d.%0*d %s", sign, amount/div, exp, amount%div, m.currency)
+}
+
+func (m Money) exponent() int {
+	if exp, ok := minorUnits[m.currency]; ok {
+		return exp
+	}
+	return 2
+}
+
+func (m Money) assertSameCurrency(other Money) error {
+	if m.currency != other.currency {
+		return fmt.Errorf("%s and %s: %w", m.currency, other.currency, ErrCurrencyMismatch)
+	}
+	return nil
+}
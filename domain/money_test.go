@@ -0,0 +1,169 @@
+package domain_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/devsrivatsa/tavernDDD/domain"
+)
+
+func TestMoney_NewMoney(t *testing.T) {
+	type testCase struct {
+		test          string
+		currency      string
+		expectedError error
+	}
+
+	testcases := []testCase{
+		{
+			test:          "Empty currency",
+			currency:      "",
+			expectedError: domain.ErrInvalidCurrency,
+		},
+		{
+			test:          "Invalid currency",
+			currency:      "EU1",
+			expectedError: domain.ErrInvalidCurrency,
+		},
+		{
+			test:          "Lower case currency",
+			currency:      "eur",
+			expectedError: nil,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.test, func(t *testing.T) {
+			_, err := domain.NewMoney(100, tc.currency)
+			if !errors.Is(err, tc.expectedError) {
+				t.Errorf("expected error %v, got %v", tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestMoney_Add(t *testing.T) {
+	total := domain.MustNewMoney(199, "EUR")
+	for _, m := range []domain.Money{domain.MustNewMoney(99, "EUR"), domain.MustNewMoney(599, "EUR")} {
+		var err error
+		total, err = total.Add(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !total.Equals(domain.MustNewMoney(897, "EUR")) {
+		t.Errorf("expected 8.97 EUR, got %s", total)
+	}
+
+	_, err := total.Add(domain.MustNewMoney(100, "USD"))
+	if !errors.Is(err, domain.ErrCurrencyMismatch) {
+		t.Errorf("expected error %v, got %v", domain.ErrCurrencyMismatch, err)
+	}
+}
+
+func TestMoney_Allocate(t *testing.T) {
+	type testCase struct {
+		test          string
+		amount        int64
+		ratios        []int
+		expected      []int64
+		expectedError error
+	}
+
+	testcases := []testCase{
+		{
+			test:     "Even split with remainder",
+			amount:   100,
+			ratios:   []int{1, 1, 1},
+			expected: []int64{34, 33, 33},
+		},
+		{
+			test:     "Weighted split",
+			amount:   1000,
+			ratios:   []int{70, 30},
+			expected: []int64{700, 300},
+		},
+		{
+			test:     "Negative amount",
+			amount:   -5,
+			ratios:   []int{1, 1},
+			expected: []int64{-3, -2},
+		},
+		{
+			test:     "Zero ratio gets nothing",
+			amount:   5,
+			ratios:   []int{0, 1, 1},
+			expected: []int64{0, 3, 2},
+		},
+		{
+			test:          "No ratios",
+			amount:        100,
+			ratios:        []int{},
+			expectedError: domain.ErrInvalidAllocation,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.test, func(t *testing.T) {
+			shares, err := domain.MustNewMoney(tc.amount, "EUR").Allocate(tc.ratios...)
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("expected error %v, got %v", tc.expectedError, err)
+			}
+			for i, share := range shares {
+				if share.GetAmount() != tc.expected[i] {
+					t.Errorf("share %d: expected %d, got %d", i, tc.expected[i], share.GetAmount())
+				}
+			}
+		})
+	}
+}
+
+func TestMoney_String(t *testing.T) {
+	type testCase struct {
+		money    domain.Money
+		expected string
+	}
+
+	testcases := []testCase{
+		{money: domain.MustNewMoney(897, "EUR"), expected: "8.97 EUR"},
+		{money: domain.MustNewMoney(5, "USD"), expected: "0.05 USD"},
+		{money: domain.MustNewMoney(-150, "GBP"), expected: "-1.50 GBP"},
+		{money: domain.MustNewMoney(500, "JPY"), expected: "500 JPY"},
+		{money: domain.MustNewMoney(1234, "KWD"), expected: "1.234 KWD"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.expected, func(t *testing.T) {
+			if got := tc.money.String(); got != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestMoney_MultiplyFraction(t *testing.T) {
+	type testCase struct {
+		test        string
+		amount      int64
+		numerator   int64
+		denominator int64
+		expected    int64
+	}
+
+	testcases := []testCase{
+		{test: "20 percent", amount: 199, numerator: 2000, denominator: 10000, expected: 40},
+		{test: "Round half up", amount: 250, numerator: 1, denominator: 100, expected: 3},
+		{test: "Round down", amount: 249, numerator: 1, denominator: 100, expected: 2},
+		{test: "Negative rounds away from zero", amount: -250, numerator: 1, denominator: 100, expected: -3},
+		{test: "Tax included in a gross price", amount: 1200, numerator: 2000, denominator: 12000, expected: 200},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.test, func(t *testing.T) {
+			got := domain.MustNewMoney(tc.amount, "EUR").MultiplyFraction(tc.numerator, tc.denominator)
+			if got.GetAmount() != tc.expected {
+				t.Errorf("expected %d, got %d", tc.expected, got.GetAmount())
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/devsrivatsa/tavernDDD/domain/order"
+	"github.com/google/uuid"
+)
+
+type MemoryOrderRepository struct {
+	orders map[uuid.UUID]order.Order
+	sync.Mutex
+}
+
+func New() *MemoryOrderRepository {
+	return &MemoryOrderRepository{
+		orders: make(map[uuid.UUID]order.Order),
+	}
+}
+
+func (m *MemoryOrderRepository) Get(ctx context.Context, id uuid.UUID) (order.Order, error) {
+	if err := ctx.Err(); err != nil {
+		return order.Order{}, err
+	}
+	m.Lock()
+	defer m.Unlock()
+
+	if o, ok := m.orders[id]; ok {
+		return o, nil
+	}
+
+	return order.Order{}, order.ErrOrderNotFound
+}
+
+// GetByCustomer returns the orders of a customer, oldest first
+func (m *MemoryOrderRepository) GetByCustomer(ctx context.Context, customerID uuid.UUID) ([]order.Order, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.Lock()
+	defer m.Unlock()
+
+	var orders []order.Order
+	for _, o := range m.orders {
+		if o.GetCustomerID() == customerID {
+			orders = append(orders, o)
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool {
+		return orders[i].GetCreatedAt().Before(orders[j].GetCreatedAt())
+	})
+
+	return orders, nil
+}
+
+func (m *MemoryOrderRepository) Add(ctx context.Context, o order.Order) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.Lock()
+	defer m.Unlock()
+
+	if _, ok := m.orders[o.GetID()]; ok {
+		return fmt.Errorf("error adding order %s: %w", o.GetID(), order.ErrOrderAlreadyExists)
+	}
+	m.orders[o.GetID()] = o
+
+	return nil
+}
+
+func (m *MemoryOrderRepository) Update(ctx context.Context, o order.Order) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.Lock()
+	defer m.Unlock()
+
+	stored, ok := m.orders[o.GetID()]
+	if !ok {
+		return fmt.Errorf("error updating order %s: %w", o.GetID(), order.ErrOrderNotFound)
+	}
+	if stored.GetVersion() != o.GetVersion() {
+		return fmt.Errorf("order %s is at version %d, not %d: %w", o.GetID(), stored.GetVersion(), o.GetVersion(), order.ErrConcurrentUpdate)
+	}
+	o.SetVersion(o.GetVersion() + 1)
+	m.orders[o.GetID()] = o
+
+	return nil
+}
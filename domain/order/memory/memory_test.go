@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/devsrivatsa/tavernDDD/domain"
+	"github.com/devsrivatsa/tavernDDD/domain/order"
+	"github.com/google/uuid"
+)
+
+func TestMemoryOrderRepository_GetByCustomer(t *testing.T) {
+	customerID := uuid.New()
+	line := order.Line{ProductID: uuid.New(), Name: "Beer", UnitPrice: domain.MustNewMoney(199, "EUR"), Quantity: 1}
+
+	repo := New()
+	for _, c := range []uuid.UUID{customerID, customerID, uuid.New()} {
+		o, err := order.NewOrder(c, []order.Line{line})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.Add(context.Background(), o); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	orders, err := repo.GetByCustomer(context.Background(), customerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 2 {
+		t.Errorf("expected 2 orders, got %d", len(orders))
+	}
+}
+
+func TestMemoryOrderRepository_Get(t *testing.T) {
+	type testCase struct {
+		name          string
+		id            uuid.UUID
+		expectedError error
+	}
+
+	o, err := order.NewOrder(uuid.New(), []order.Line{
+		{ProductID: uuid.New(), Name: "Beer", UnitPrice: domain.MustNewMoney(199, "EUR"), Quantity: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := MemoryOrderRepository{
+		orders: map[uuid.UUID]order.Order{
+			o.GetID(): o,
+		},
+	}
+
+	testCases := []testCase{
+		{
+			name:          "no order by id",
+			id:            uuid.New(),
+			expectedError: order.ErrOrderNotFound,
+		},
+		{
+			name:          "order by id",
+			id:            o.GetID(),
+			expectedError: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := repo.Get(context.Background(), tc.id)
+			if !errors.Is(err, tc.expectedError) {
+				t.Errorf("expected error %v, got %v", tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestMemoryOrderRepository_UpdateConcurrent(t *testing.T) {
+	o, err := order.NewOrder(uuid.New(), []order.Line{
+		{ProductID: uuid.New(), Name: "Beer", UnitPrice: domain.MustNewMoney(199, "EUR"), Quantity: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := New()
+	if err := repo.Add(context.Background(), o); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := repo.Get(context.Background(), o.GetID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := repo.Get(context.Background(), o.GetID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Update(context.Background(), first); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Update(context.Background(), second); !errors.Is(err, order.ErrConcurrentUpdate) {
+		t.Errorf("expected error %v, got %v", order.ErrConcurrentUpdate, err)
+	}
+}
@@ -0,0 +1,537 @@
+package order
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/devsrivatsa/tavernDDD/domain"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrMissingCustomer = errors.New("an order must belong to a customer")
+	ErrNoLines         = errors.New("an order must have at least one line")
+	ErrInvalidLine     = errors.New("an order line needs a product and a quantity greater than zero")
+	ErrInvalidStatus   = errors.New("the order cannot move to the requested status")
+	ErrAlreadyPaid     = errors.New("the order is already paid, refund it instead")
+	ErrNotPaid         = errors.New("only paid orders can be refunded, cancel it instead")
+	ErrLineNotFound    = errors.New("the order has no line for the product")
+	ErrAmbiguousLine   = errors.New("the order has several lines for the product, the refund needs the line")
+	ErrRefundTooLarge  = errors.New("cannot refund more than was sold")
+	ErrInvalidDiscount = errors.New("discounts must be positive and cannot exceed the line amount")
+	ErrMissingStaff    = errors.New("a void needs the staff member who made it")
+)
+
+type Status string
+
+const (
+	StatusPlaced    Status = "placed"
+	StatusConfirmed Status = "confirmed"
+	StatusRejected  Status = "rejected"
+	StatusPaid      Status = "paid"
+	StatusCancelled Status = "cancelled"
+	// StatusPartiallyRefunded is a paid order with some of its lines refunded
+	StatusPartiallyRefunded Status = "partially_refunded"
+	StatusRefunded          Status = "refunded"
+)
+
+// Line is a value object that snapshots a product as it was sold, so later
+// price or name changes on the product do not rewrite the order
+type Line struct {
+	ProductID uuid.UUID
+	// SKU and Size are set when a variant of the product was sold
+	SKU       string
+	Size      string
+	Name      string
+	UnitPrice domain.Money
+	Quantity  int
+	// Draw is the stock units of the product one item takes, lines without a draw take 1
+	Draw int
+	// Ingredients are taken from stock instead of the product itself when it is made to a recipe
+	Ingredients []Ingredient
+	Notes       string
+	// Station is where the line is prepared, e.g. bar or kitchen
+	Station string
+	// Overridden marks a unit price set by staff instead of taken from the product
+	Overridden bool
+	// Discounts itemise what was taken off UnitPrice times Quantity before tax
+	Discounts []Discount
+	// Net, Tax and Gross are the amounts charged for the whole line. Lines without a Gross
+	// amount are charged UnitPrice times Quantity less discounts, without tax.
+	Net   domain.Money
+	Tax   domain.Money
+	Gross domain.Money
+	// Refunded is the part of Quantity that has been refunded
+	Refunded int
+}
+
+// Ingredient is a value object for the stock units of another product that go into one item of a line
+type Ingredient struct {
+	ProductID uuid.UUID
+	Amount    int
+}
+
+// Discount is a value object explaining an amount taken off an order line
+type Discount struct {
+	//PromotionID is empty for discounts that do not come from a promotion
+	PromotionID uuid.UUID
+	Name        string
+	Amount      domain.Money
+}
+
+// GetSubtotal returns the line amount before discounts and tax
+func (l Line) GetSubtotal() domain.Money {
+	return l.UnitPrice.Multiply(int64(l.Quantity))
+}
+
+// GetDiscount returns the sum of the discounts on the line
+func (l Line) GetDiscount() (domain.Money, error) {
+	total := l.UnitPrice.Multiply(0)
+	for _, d := range l.Discounts {
+		var err error
+		total, err = total.Add(d.Amount)
+		if err != nil {
+			return domain.Money{}, err
+		}
+	}
+
+	return total, nil
+}
+
+// GetStockUnits returns the stock units of the product taken by quantity items of the line
+func (l Line) GetStockUnits(quantity int) int {
+	if l.Draw <= 0 {
+		return quantity
+	}
+	return quantity * l.Draw
+}
+
+// GetStockDraws returns the stock units taken by quantity items of the line, per product.
+// Lines made to a recipe take their ingredients, other lines take the product itself.
+func (l Line) GetStockDraws(quantity int) map[uuid.UUID]int {
+	draws := make(map[uuid.UUID]int)
+	if len(l.Ingredients) == 0 {
+		draws[l.ProductID] = l.GetStockUnits(quantity)
+		return draws
+	}
+	for _, i := range l.Ingredients {
+		draws[i.ProductID] += i.Amount * quantity
+	}
+	return draws
+}
+
+// GetTotal returns what the customer pays for the line, tax included
+func (l Line) GetTotal() domain.Money {
+	return l.Gross
+}
+
+// SumLines adds up the totals of the lines, they must all be in the same currency
+func SumLines(lines []Line) (domain.Money, error) {
+	return sum(lines, func(l Line) domain.Money { return l.Gross })
+}
+
+func sum(lines []Line, amount func(l Line) domain.Money) (domain.Money, error) {
+	var total domain.Money
+	for i, l := range lines {
+		if i == 0 {
+			total = amount(l)
+			continue
+		}
+		var err error
+		total, err = total.Add(amount(l))
+		if err != nil {
+			return domain.Money{}, fmt.Errorf("line %d: %w", i, err)
+		}
+	}
+
+	return total, nil
+}
+
+// withAmounts fills in the amounts of an untaxed line and checks that net plus tax is gross
+func (l Line) withAmounts() (Line, error) {
+	if l.ProductID == uuid.Nil || l.Quantity <= 0 || l.Draw < 0 || l.Refunded != 0 {
+		return Line{}, ErrInvalidLine
+	}
+	for _, d := range l.Discounts {
+		if !d.Amount.IsPositive() {
+			return Line{}, fmt.Errorf("discount %q: %w", d.Name, ErrInvalidDiscount)
+		}
+	}
+	discount, err := l.GetDiscount()
+	if err != nil {
+		return Line{}, err
+	}
+	if tooMuch, err := discount.GreaterThan(l.GetSubtotal()); err != nil || tooMuch {
+		return Line{}, fmt.Errorf("%s off %s: %w", discount, l.GetSubtotal(), ErrInvalidDiscount)
+	}
+	for _, i := range l.Ingredients {
+		if i.ProductID == uuid.Nil || i.Amount <= 0 {
+			return Line{}, fmt.Errorf("ingredient %s: %w", i.ProductID, ErrInvalidLine)
+		}
+	}
+	l.Discounts = append([]Discount(nil), l.Discounts...)
+	l.Ingredients = append([]Ingredient(nil), l.Ingredients...)
+
+	if l.Gross.GetCurrency() == "" {
+		l.Net, _ = l.GetSubtotal().Sub(discount)
+		l.Tax = l.Net.Multiply(0)
+		l.Gross = l.Net
+		return l, nil
+	}
+	gross, err := l.Net.Add(l.Tax)
+	if err != nil {
+		return Line{}, err
+	}
+	if !gross.Equals(l.Gross) {
+		return Line{}, fmt.Errorf("net %s plus tax %s is not %s: %w", l.Net, l.Tax, l.Gross, ErrInvalidLine)
+	}
+
+	return l, nil
+}
+
+// portion returns the amounts for units from+1 up to to out of the line quantity. Working
+// from cumulative shares makes the portions of a line always add up to the line amounts.
+func (l Line) portion(from, to int) Line {
+	share := func(m domain.Money) domain.Money {
+		upTo := m.MultiplyFraction(int64(to), int64(l.Quantity))
+		before := m.MultiplyFraction(int64(from), int64(l.Quantity))
+		diff, _ := upTo.Sub(before)
+		return diff
+	}
+	l.Gross = share(l.Gross)
+	l.Tax = share(l.Tax)
+	l.Net, _ = l.Gross.Sub(l.Tax)
+	l.Quantity = to - from
+	l.Refunded = 0
+
+	return l
+}
+
+// LineRefund asks to refund quantity units of the line for a product, or one of its variants when SKU is set.
+// An order can have several lines for the same variant, e.g. a paid and a comped one, refunds
+// for those name the Line, its position in the order counted from 1.
+type LineRefund struct {
+	ProductID uuid.UUID
+	SKU       string
+	Quantity  int
+	Line      int
+}
+
+type Order struct {
+	//id is the root entity identifier of the order aggregate
+	id         uuid.UUID
+	customerID uuid.UUID
+	//tableID is set when the order was placed for a table
+	tableID uuid.UUID
+	//takenBy is the staff member who took the order, voidedBy the one who voided it
+	takenBy  uuid.UUID
+	voidedBy uuid.UUID
+	lines    []Line
+	net      domain.Money
+	tax      domain.Money
+	total    domain.Money
+	status   Status
+	//authorizationID references the card hold taken for the order, if any
+	authorizationID string
+	createdAt       time.Time
+	updatedAt       time.Time
+	//version is bumped by the repository on every update, to detect concurrent changes
+	version int
+}
+
+// factory function to create a new placed order
+func NewOrder(customerID uuid.UUID, lines []Line) (Order, error) {
+	if customerID == uuid.Nil {
+		return Order{}, ErrMissingCustomer
+	}
+	if len(lines) == 0 {
+		return Order{}, ErrNoLines
+	}
+
+	priced := make([]Line, len(lines))
+	for i, l := range lines {
+		var err error
+		priced[i], err = l.withAmounts()
+		if err != nil {
+			return Order{}, fmt.Errorf("line %d: %w", i, err)
+		}
+	}
+	net, err := sum(priced, func(l Line) domain.Money { return l.Net })
+	if err != nil {
+		return Order{}, err
+	}
+	tax, err := sum(priced, func(l Line) domain.Money { return l.Tax })
+	if err != nil {
+		return Order{}, err
+	}
+	total, err := SumLines(priced)
+	if err != nil {
+		return Order{}, err
+	}
+
+	now := time.Now()
+	return Order{
+		id:         uuid.New(),
+		customerID: customerID,
+		lines:      priced,
+		net:        net,
+		tax:        tax,
+		total:      total,
+		status:     StatusPlaced,
+		createdAt:  now,
+		updatedAt:  now,
+	}, nil
+}
+
+func (o Order) GetID() uuid.UUID {
+	return o.id
+}
+
+func (o Order) GetCustomerID() uuid.UUID {
+	return o.customerID
+}
+
+// GetTableID returns the table the order was placed for, it is empty for orders at the bar
+func (o Order) GetTableID() uuid.UUID {
+	return o.tableID
+}
+
+// SetTableID records the table the order is served at
+func (o *Order) SetTableID(tableID uuid.UUID) {
+	o.tableID = tableID
+}
+
+func (o Order) GetTakenBy() uuid.UUID {
+	return o.takenBy
+}
+
+// SetTakenBy records the staff member who took the order
+func (o *Order) SetTakenBy(staffID uuid.UUID) {
+	o.takenBy = staffID
+}
+
+// GetVoidedBy returns the staff member who voided the order, it is empty unless the order was voided
+func (o Order) GetVoidedBy() uuid.UUID {
+	return o.voidedBy
+}
+
+// GetLines returns a copy of the order lines
+func (o Order) GetLines() []Line {
+	return append([]Line(nil), o.lines...)
+}
+
+// GetDiscount returns the sum of the discounts on all lines
+func (o Order) GetDiscount() (domain.Money, error) {
+	return sum(o.lines, func(l Line) domain.Money {
+		discount, _ := l.GetDiscount()
+		return discount
+	})
+}
+
+// GetNet returns the order total before tax
+func (o Order) GetNet() domain.Money {
+	return o.net
+}
+
+func (o Order) GetTax() domain.Money {
+	return o.tax
+}
+
+// GetTotal returns what the customer pays for the order, tax included
+func (o Order) GetTotal() domain.Money {
+	return o.total
+}
+
+// GetRefunded returns what has been refunded on the order so far, tax included
+func (o Order) GetRefunded() (domain.Money, error) {
+	return sum(o.lines, func(l Line) domain.Money {
+		return l.portion(0, l.Refunded).Gross
+	})
+}
+
+func (o Order) GetStatus() Status {
+	return o.status
+}
+
+func (o Order) GetCreatedAt() time.Time {
+	return o.createdAt
+}
+
+func (o Order) GetUpdatedAt() time.Time {
+	return o.updatedAt
+}
+
+// GetVersion returns the version of the order as it was loaded from the repository
+func (o Order) GetVersion() int {
+	return o.version
+}
+
+func (o *Order) SetVersion(version int) {
+	o.version = version
+}
+
+func (o Order) GetAuthorizationID() string {
+	return o.authorizationID
+}
+
+// Confirm accepts a placed order, authorizationID is empty when the order was not paid by card
+func (o *Order) Confirm(authorizationID string) error {
+	if err := o.transition(StatusPlaced, StatusConfirmed); err != nil {
+		return err
+	}
+	o.authorizationID = authorizationID
+
+	return nil
+}
+
+// Reject marks a placed order as refused, e.g. because the payment was declined
+func (o *Order) Reject() error {
+	return o.transition(StatusPlaced, StatusRejected)
+}
+
+func (o *Order) MarkPaid() error {
+	return o.transition(StatusConfirmed, StatusPaid)
+}
+
+// MarkPaidBy marks a confirmed order paid with a card payment taken after it was confirmed, e.g.
+// for a whole tab. The order keeps the card payment it was confirmed with, if any.
+func (o *Order) MarkPaidBy(authorizationID string) error {
+	if err := o.MarkPaid(); err != nil {
+		return err
+	}
+	if o.authorizationID == "" {
+		o.authorizationID = authorizationID
+	}
+
+	return nil
+}
+
+// Cancel calls off an order that has not been paid yet
+func (o *Order) Cancel() error {
+	switch o.status {
+	case StatusPlaced, StatusConfirmed:
+		o.status = StatusCancelled
+		o.updatedAt = time.Now()
+		return nil
+	case StatusPaid, StatusPartiallyRefunded, StatusRefunded:
+		return fmt.Errorf("order %s: %w", o.id, ErrAlreadyPaid)
+	}
+
+	return fmt.Errorf("order %s is %s, cannot become %s: %w", o.id, o.status, StatusCancelled, ErrInvalidStatus)
+}
+
+// Void cancels the order on behalf of a staff member, see Cancel
+func (o *Order) Void(staffID uuid.UUID) error {
+	if staffID == uuid.Nil {
+		return ErrMissingStaff
+	}
+	if err := o.Cancel(); err != nil {
+		return err
+	}
+	o.voidedBy = staffID
+
+	return nil
+}
+
+// RefundLines refunds part of a paid order and returns the order lines affected,
+// with Quantity holding the refunded quantity. Nothing is refunded if one of the refunds is invalid.
+func (o *Order) RefundLines(refunds []LineRefund) ([]Line, error) {
+	if o.status != StatusPaid && o.status != StatusPartiallyRefunded {
+		return nil, fmt.Errorf("order %s is %s: %w", o.id, o.status, ErrNotPaid)
+	}
+
+	lines := o.GetLines()
+	refunded := make([]Line, 0, len(refunds))
+	for _, r := range refunds {
+		i, err := o.lineIndex(r)
+		if err != nil {
+			return nil, err
+		}
+		if r.Quantity <= 0 {
+			return nil, fmt.Errorf("product %s: %w", r.ProductID, ErrInvalidLine)
+		}
+		if lines[i].Refunded+r.Quantity > lines[i].Quantity {
+			return nil, fmt.Errorf("%d of %s left to refund: %w", lines[i].Quantity-lines[i].Refunded, lines[i].Name, ErrRefundTooLarge)
+		}
+		refunded = append(refunded, lines[i].portion(lines[i].Refunded, lines[i].Refunded+r.Quantity))
+		lines[i].Refunded += r.Quantity
+	}
+
+	o.lines = lines
+	o.status = StatusRefunded
+	for _, l := range lines {
+		if l.Refunded < l.Quantity {
+			o.status = StatusPartiallyRefunded
+		}
+	}
+	o.updatedAt = time.Now()
+
+	return refunded, nil
+}
+
+// UndoRefund takes back refunds made with RefundLines, e.g. when the money could not be given
+// back. Nothing is changed if one of them was not refunded.
+func (o *Order) UndoRefund(refunds []LineRefund) error {
+	if o.status != StatusRefunded && o.status != StatusPartiallyRefunded {
+		return fmt.Errorf("order %s is %s: %w", o.id, o.status, ErrInvalidStatus)
+	}
+
+	lines := o.GetLines()
+	for _, r := range refunds {
+		i, err := o.lineIndex(r)
+		if err != nil {
+			return err
+		}
+		if r.Quantity <= 0 || r.Quantity > lines[i].Refunded {
+			return fmt.Errorf("%d of %s refunded: %w", lines[i].Refunded, lines[i].Name, ErrInvalidLine)
+		}
+		lines[i].Refunded -= r.Quantity
+	}
+
+	o.lines = lines
+	o.status = StatusPaid
+	for _, l := range lines {
+		if l.Refunded > 0 {
+			o.status = StatusPartiallyRefunded
+		}
+	}
+	o.updatedAt = time.Now()
+
+	return nil
+}
+
+// lineIndex finds the line the refund is for, by its position when it has one
+func (o Order) lineIndex(r LineRefund) (int, error) {
+	if r.Line > 0 {
+		if r.Line > len(o.lines) || o.lines[r.Line-1].ProductID != r.ProductID || o.lines[r.Line-1].SKU != r.SKU {
+			return -1, fmt.Errorf("line %d for product %s %s: %w", r.Line, r.ProductID, r.SKU, ErrLineNotFound)
+		}
+		return r.Line - 1, nil
+	}
+
+	found := -1
+	for i, l := range o.lines {
+		if l.ProductID != r.ProductID || l.SKU != r.SKU {
+			continue
+		}
+		if found >= 0 {
+			return -1, fmt.Errorf("product %s %s: %w", r.ProductID, r.SKU, ErrAmbiguousLine)
+		}
+		found = i
+	}
+	if found < 0 {
+		return -1, fmt.Errorf("product %s %s: %w", r.ProductID, r.SKU, ErrLineNotFound)
+	}
+	return found, nil
+}
+
+func (o *Order) transition(from, to Status) error {
+	if o.status != from {
+		return fmt.Errorf("order %s is %s, cannot become %s: %w", o.id, o.status, to, ErrInvalidStatus)
+	}
+	o.status = to
+	o.updatedAt = time.Now()
+
+	return nil
+}
@@ -0,0 +1,24 @@
+package order
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrOrderNotFound      = errors.New("order not found")
+	ErrOrderAlreadyExists = errors.New("order already exists")
+	ErrConcurrentUpdate   = errors.New("the order was changed since it was loaded")
+)
+
+// manage order aggregates
+type OrderRepository interface {
+	Get(ctx context.Context, id uuid.UUID) (Order, error)
+	GetByCustomer(ctx context.Context, customerID uuid.UUID) ([]Order, error)
+	Add(ctx context.Context, order Order) error
+	// Update fails with ErrConcurrentUpdate if the stored order is not at the version of
+	// the given one, and bumps the version otherwise
+	Update(ctx context.Context, order Order) error
+}
@@ -0,0 +1,401 @@
+package order_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/devsrivatsa/tavernDDD/domain"
+	"github.com/devsrivatsa/tavernDDD/domain/order"
+	"github.com/google/uuid"
+)
+
+func TestOrder_NewOrder(t *testing.T) {
+	type testCase struct {
+		test          string
+		customerID    uuid.UUID
+		lines         []order.Line
+		expectedError error
+	}
+
+	beer := order.Line{ProductID: uuid.New(), Name: "Beer", UnitPrice: domain.MustNewMoney(199, "EUR"), Quantity: 2}
+	testcases := []testCase{
+		{
+			test:          "Missing customer",
+			customerID:    uuid.Nil,
+			lines:         []order.Line{beer},
+			expectedError: order.ErrMissingCustomer,
+		},
+		{
+			test:          "No lines",
+			customerID:    uuid.New(),
+			expectedError: order.ErrNoLines,
+		},
+		{
+			test:       "Zero quantity",
+			customerID: uuid.New(),
+			lines: []order.Line{
+				{ProductID: uuid.New(), Name: "Wine", UnitPrice: domain.MustNewMoney(599, "EUR"), Quantity: 0},
+			},
+			expectedError: order.ErrInvalidLine,
+		},
+		{
+			test:       "Mixed currencies",
+			customerID: uuid.New(),
+			lines: []order.Line{
+				beer,
+				{ProductID: uuid.New(), Name: "Wine", UnitPrice: domain.MustNewMoney(599, "USD"), Quantity: 1},
+			},
+			expectedError: domain.ErrCurrencyMismatch,
+		},
+		{
+			test:          "Valid order",
+			customerID:    uuid.New(),
+			lines:         []order.Line{beer},
+			expectedError: nil,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.test, func(t *testing.T) {
+			o, err := order.NewOrder(tc.customerID, tc.lines)
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("expected error %v, got %v", tc.expectedError, err)
+			}
+			if err == nil && !o.GetTotal().Equals(domain.MustNewMoney(398, "EUR")) {
+				t.Errorf("expected total 3.98 EUR, got %s", o.GetTotal())
+			}
+		})
+	}
+}
+
+func TestOrder_Cancel(t *testing.T) {
+	o, err := order.NewOrder(uuid.New(), []order.Line{
+		{ProductID: uuid.New(), Name: "Beer", UnitPrice: domain.MustNewMoney(199, "EUR"), Quantity: 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	paid := o
+	if err := paid.Confirm("auth-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := paid.MarkPaid(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := paid.Cancel(); !errors.Is(err, order.ErrAlreadyPaid) {
+		t.Errorf("expected error %v, got %v", order.ErrAlreadyPaid, err)
+	}
+	if err := o.Cancel(); err != nil {
+		t.Fatalf("expected a placed order to be cancelled, got %v", err)
+	}
+	if err := o.Cancel(); !errors.Is(err, order.ErrInvalidStatus) {
+		t.Errorf("expected error %v, got %v", order.ErrInvalidStatus, err)
+	}
+}
+
+func TestOrder_Void(t *testing.T) {
+	o, err := order.NewOrder(uuid.New(), []order.Line{
+		{ProductID: uuid.New(), Name: "Beer", UnitPrice: domain.MustNewMoney(199, "EUR"), Quantity: 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	managerID := uuid.New()
+
+	if err := o.Void(uuid.Nil); !errors.Is(err, order.ErrMissingStaff) {
+		t.Errorf("expected error %v, got %v", order.ErrMissingStaff, err)
+	}
+	if err := o.Void(managerID); err != nil {
+		t.Fatalf("expected a placed order to be voided, got %v", err)
+	}
+	if o.GetStatus() != order.StatusCancelled || o.GetVoidedBy() != managerID {
+		t.Errorf("expected the order cancelled by %s, got %s by %s", managerID, o.GetStatus(), o.GetVoidedBy())
+	}
+	if err := o.Void(managerID); !errors.Is(err, order.ErrInvalidStatus) {
+		t.Errorf("expected error %v, got %v", order.ErrInvalidStatus, err)
+	}
+}
+
+func TestOrder_MarkPaidBy(t *testing.T) {
+	line := order.Line{ProductID: uuid.New(), Name: "Beer", UnitPrice: domain.MustNewMoney(199, "EUR"), Quantity: 1}
+
+	type testCase struct {
+		test          string
+		confirmedWith string
+		expectedAuth  string
+	}
+	testcases := []testCase{
+		{test: "Paid on a tab", confirmedWith: "", expectedAuth: "auth-2"},
+		{test: "Paid by card already", confirmedWith: "auth-1", expectedAuth: "auth-1"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.test, func(t *testing.T) {
+			o, err := order.NewOrder(uuid.New(), []order.Line{line})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := o.MarkPaidBy("auth-2"); !errors.Is(err, order.ErrInvalidStatus) {
+				t.Errorf("expected error %v, got %v", order.ErrInvalidStatus, err)
+			}
+			if err := o.Confirm(tc.confirmedWith); err != nil {
+				t.Fatal(err)
+			}
+			if err := o.MarkPaidBy("auth-2"); err != nil {
+				t.Fatal(err)
+			}
+			if o.GetStatus() != order.StatusPaid || o.GetAuthorizationID() != tc.expectedAuth {
+				t.Errorf("expected the order paid with %s, got %s with %s", tc.expectedAuth, o.GetStatus(), o.GetAuthorizationID())
+			}
+		})
+	}
+}
+
+func TestOrder_RefundLines(t *testing.T) {
+	beer := uuid.New()
+	wine := uuid.New()
+	o, err := order.NewOrder(uuid.New(), []order.Line{
+		{ProductID: beer, Name: "Beer", UnitPrice: domain.MustNewMoney(199, "EUR"), Quantity: 2},
+		{ProductID: wine, Name: "Wine", UnitPrice: domain.MustNewMoney(599, "EUR"), Quantity: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := o.RefundLines([]order.LineRefund{{ProductID: beer, Quantity: 1}}); !errors.Is(err, order.ErrNotPaid) {
+		t.Errorf("expected error %v, got %v", order.ErrNotPaid, err)
+	}
+	if err := o.Confirm(""); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.MarkPaid(); err != nil {
+		t.Fatal(err)
+	}
+
+	type testCase struct {
+		test           string
+		refunds        []order.LineRefund
+		expectedError  error
+		expectedStatus order.Status
+	}
+	testcases := []testCase{
+		{
+			test:           "Unknown product",
+			refunds:        []order.LineRefund{{ProductID: uuid.New(), Quantity: 1}},
+			expectedError:  order.ErrLineNotFound,
+			expectedStatus: order.StatusPaid,
+		},
+		{
+			test:           "More than was sold",
+			refunds:        []order.LineRefund{{ProductID: beer, Quantity: 1}, {ProductID: wine, Quantity: 2}},
+			expectedError:  order.ErrRefundTooLarge,
+			expectedStatus: order.StatusPaid,
+		},
+		{
+			test:           "One beer",
+			refunds:        []order.LineRefund{{ProductID: beer, Quantity: 1}},
+			expectedStatus: order.StatusPartiallyRefunded,
+		},
+		{
+			test:           "Second beer twice",
+			refunds:        []order.LineRefund{{ProductID: beer, Quantity: 1}, {ProductID: beer, Quantity: 1}},
+			expectedError:  order.ErrRefundTooLarge,
+			expectedStatus: order.StatusPartiallyRefunded,
+		},
+		{
+			test:           "Everything else",
+			refunds:        []order.LineRefund{{ProductID: beer, Quantity: 1}, {ProductID: wine, Quantity: 1}},
+			expectedStatus: order.StatusRefunded,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.test, func(t *testing.T) {
+			_, err := o.RefundLines(tc.refunds)
+			if !errors.Is(err, tc.expectedError) {
+				t.Errorf("expected error %v, got %v", tc.expectedError, err)
+			}
+			if o.GetStatus() != tc.expectedStatus {
+				t.Errorf("expected status %s, got %s", tc.expectedStatus, o.GetStatus())
+			}
+		})
+	}
+}
+
+func TestOrder_UndoRefund(t *testing.T) {
+	beer := uuid.New()
+	wine := uuid.New()
+	o, err := order.NewOrder(uuid.New(), []order.Line{
+		{ProductID: beer, Name: "Beer", UnitPrice: domain.MustNewMoney(199, "EUR"), Quantity: 2},
+		{ProductID: wine, Name: "Wine", UnitPrice: domain.MustNewMoney(599, "EUR"), Quantity: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Confirm(""); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.MarkPaid(); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.UndoRefund([]order.LineRefund{{ProductID: beer, Quantity: 1}}); !errors.Is(err, order.ErrInvalidStatus) {
+		t.Errorf("expected error %v, got %v", order.ErrInvalidStatus, err)
+	}
+	if _, err := o.RefundLines([]order.LineRefund{{ProductID: beer, Quantity: 2}, {ProductID: wine, Quantity: 1}}); err != nil {
+		t.Fatal(err)
+	}
+
+	type testCase struct {
+		test           string
+		refunds        []order.LineRefund
+		expectedError  error
+		expectedStatus order.Status
+	}
+	testcases := []testCase{
+		{
+			test:           "More than was refunded",
+			refunds:        []order.LineRefund{{ProductID: beer, Quantity: 1}, {ProductID: wine, Quantity: 2}},
+			expectedError:  order.ErrInvalidLine,
+			expectedStatus: order.StatusRefunded,
+		},
+		{
+			test:           "The wine",
+			refunds:        []order.LineRefund{{ProductID: wine, Quantity: 1}},
+			expectedStatus: order.StatusPartiallyRefunded,
+		},
+		{
+			test:           "The beers",
+			refunds:        []order.LineRefund{{ProductID: beer, Quantity: 2}},
+			expectedStatus: order.StatusPaid,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.test, func(t *testing.T) {
+			err := o.UndoRefund(tc.refunds)
+			if !errors.Is(err, tc.expectedError) {
+				t.Errorf("expected error %v, got %v", tc.expectedError, err)
+			}
+			if o.GetStatus() != tc.expectedStatus {
+				t.Errorf("expected status %s, got %s", tc.expectedStatus, o.GetStatus())
+			}
+		})
+	}
+	if refunded, _ := o.GetRefunded(); refunded.IsPositive() {
+		t.Errorf("expected nothing refunded, got %s", refunded)
+	}
+}
+
+func TestOrder_RefundLinesAddUp(t *testing.T) {
+	beer := uuid.New()
+	// 3 beers of 1.99 with 20% tax, 1.19 of tax does not split evenly over the beers
+	o, err := order.NewOrder(uuid.New(), []order.Line{{
+		ProductID: beer,
+		Name:      "Beer",
+		UnitPrice: domain.MustNewMoney(199, "EUR"),
+		Quantity:  3,
+		Net:       domain.MustNewMoney(597, "EUR"),
+		Tax:       domain.MustNewMoney(119, "EUR"),
+		Gross:     domain.MustNewMoney(716, "EUR"),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Confirm(""); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.MarkPaid(); err != nil {
+		t.Fatal(err)
+	}
+
+	var refunded int64
+	var tax int64
+	for i := 0; i < 3; i++ {
+		lines, err := o.RefundLines([]order.LineRefund{{ProductID: beer, Quantity: 1}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		refunded += lines[0].GetTotal().GetAmount()
+		tax += lines[0].Tax.GetAmount()
+	}
+	if refunded != 716 || tax != 119 {
+		t.Errorf("expected the refunds to add up to 7.16 EUR with 1.19 EUR of tax, got %d and %d", refunded, tax)
+	}
+}
+
+func TestOrder_RefundVariantLines(t *testing.T) {
+	beer := uuid.New()
+	o, err := order.NewOrder(uuid.New(), []order.Line{
+		{ProductID: beer, SKU: "BEER-PINT", Size: "Pint", Name: "Beer", UnitPrice: domain.MustNewMoney(599, "EUR"), Quantity: 2, Draw: 2},
+		{ProductID: beer, SKU: "BEER-HALF", Size: "Half pint", Name: "Beer", UnitPrice: domain.MustNewMoney(350, "EUR"), Quantity: 1, Draw: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Confirm(""); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.MarkPaid(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := o.RefundLines([]order.LineRefund{{ProductID: beer, Quantity: 1}}); !errors.Is(err, order.ErrLineNotFound) {
+		t.Errorf("expected error %v, got %v", order.ErrLineNotFound, err)
+	}
+	refunded, err := o.RefundLines([]order.LineRefund{{ProductID: beer, SKU: "BEER-PINT", Quantity: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !refunded[0].GetTotal().Equals(domain.MustNewMoney(599, "EUR")) || refunded[0].GetStockUnits(refunded[0].Quantity) != 2 {
+		t.Errorf("expected a pint of 5.99 EUR drawing 2 units refunded, got %s drawing %d", refunded[0].GetTotal(), refunded[0].GetStockUnits(refunded[0].Quantity))
+	}
+}
+
+func TestOrder_RefundLinesOfTheSameVariant(t *testing.T) {
+	beer := uuid.New()
+	o, err := order.NewOrder(uuid.New(), []order.Line{
+		{ProductID: beer, Name: "Beer", UnitPrice: domain.MustNewMoney(199, "EUR"), Quantity: 2},
+		{ProductID: beer, Name: "Beer", UnitPrice: domain.MustNewMoney(199, "EUR"), Quantity: 1, Discounts: []order.Discount{
+			{Name: "Comp", Amount: domain.MustNewMoney(199, "EUR")},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Confirm(""); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.MarkPaid(); err != nil {
+		t.Fatal(err)
+	}
+
+	type testCase struct {
+		test          string
+		refund        order.LineRefund
+		expectedTotal domain.Money
+		expectedError error
+	}
+	testcases := []testCase{
+		{test: "Without the line", refund: order.LineRefund{ProductID: beer, Quantity: 1}, expectedError: order.ErrAmbiguousLine},
+		{test: "Line of another product", refund: order.LineRefund{ProductID: uuid.New(), Quantity: 1, Line: 1}, expectedError: order.ErrLineNotFound},
+		{test: "Line past the end", refund: order.LineRefund{ProductID: beer, Quantity: 1, Line: 3}, expectedError: order.ErrLineNotFound},
+		{test: "Comped beer", refund: order.LineRefund{ProductID: beer, Quantity: 1, Line: 2}, expectedTotal: domain.MustNewMoney(0, "EUR")},
+		{test: "Paid beer", refund: order.LineRefund{ProductID: beer, Quantity: 1, Line: 1}, expectedTotal: domain.MustNewMoney(199, "EUR")},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.test, func(t *testing.T) {
+			refunded, err := o.RefundLines([]order.LineRefund{tc.refund})
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("expected error %v, got %v", tc.expectedError, err)
+			}
+			if err != nil {
+				return
+			}
+			if !refunded[0].GetTotal().Equals(tc.expectedTotal) {
+				t.Errorf("expected %s refunded, got %s", tc.expectedTotal, refunded[0].GetTotal())
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package domain
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 )
 
@@ -8,5 +10,21 @@ import (
 type Person struct {
 	ID   uuid.UUID
 	Name string
-	Age  int
+	// DateOfBirth is the zero time when it is not known
+	DateOfBirth time.Time
+	// DateOfBirthVerified is set once the date of birth was checked against an ID
+	DateOfBirthVerified bool
+}
+
+// AgeAt returns the age of the person in whole years at the given time, or -1 when the date of birth is not known
+func (p Person) AgeAt(at time.Time) int {
+	if p.DateOfBirth.IsZero() {
+		return -1
+	}
+	dob := p.DateOfBirth.In(at.Location())
+	age := at.Year() - dob.Year()
+	if at.Month() < dob.Month() || (at.Month() == dob.Month() && at.Day() < dob.Day()) {
+		age--
+	}
+	return age
 }
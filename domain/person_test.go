@@ -0,0 +1,33 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/devsrivatsa/tavernDDD/domain"
+)
+
+func TestPerson_AgeAt(t *testing.T) {
+	type testCase struct {
+		test     string
+		dob      time.Time
+		expected int
+	}
+
+	at := time.Date(2024, time.June, 15, 20, 0, 0, 0, time.UTC)
+	testcases := []testCase{
+		{test: "Unknown date of birth", dob: time.Time{}, expected: -1},
+		{test: "Birthday today", dob: time.Date(2006, time.June, 15, 0, 0, 0, 0, time.UTC), expected: 18},
+		{test: "Birthday tomorrow", dob: time.Date(2006, time.June, 16, 0, 0, 0, 0, time.UTC), expected: 17},
+		{test: "Birthday last month", dob: time.Date(2006, time.May, 31, 0, 0, 0, 0, time.UTC), expected: 18},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.test, func(t *testing.T) {
+			p := domain.Person{DateOfBirth: tc.dob}
+			if got := p.AgeAt(at); got != tc.expected {
+				t.Errorf("expected %d, got %d", tc.expected, got)
+			}
+		})
+	}
+}
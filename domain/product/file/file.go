@@ -0,0 +1,310 @@
+// Package file keeps the product catalog in a JSON file, for a single machine without a database
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/devsrivatsa/tavernDDD/domain"
+	"github.com/devsrivatsa/tavernDDD/domain/filestore"
+	"github.com/devsrivatsa/tavernDDD/domain/product"
+	"github.com/google/uuid"
+)
+
+// FileProductRepository keeps every product in memory, like memory.MemoryProductRepository,
+// and writes each change to the file before it is made in memory
+type FileProductRepository struct {
+	products map[uuid.UUID]product.Product
+	store    *filestore.Store
+	sync.Mutex
+}
+
+type fileProduct struct {
+	ID          uuid.UUID        `json:"id"`
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Price       fileMoney        `json:"price"`
+	Quantity    int              `json:"quantity"`
+	TaxClass    string           `json:"tax_class"`
+	Station     string           `json:"station"`
+	MinAge      int              `json:"min_age"`
+	Variants    []fileVariant    `json:"variants"`
+	Recipe      []fileIngredient `json:"recipe"`
+}
+
+type fileMoney struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+type fileVariant struct {
+	SKU   string    `json:"sku"`
+	Size  string    `json:"size"`
+	Price fileMoney `json:"price"`
+	Draw  int       `json:"draw"`
+}
+
+type fileIngredient struct {
+	ProductID uuid.UUID `json:"product_id"`
+	Amount    int       `json:"amount"`
+}
+
+func newFromProduct(p product.Product) fileProduct {
+	variants := make([]fileVariant, 0)
+	for _, v := range p.GetVariants() {
+		variants = append(variants, fileVariant{SKU: v.SKU, Size: v.Size, Price: newFileMoney(v.Price), Draw: v.Draw})
+	}
+	recipe := make([]fileIngredient, 0)
+	for _, i := range p.GetRecipe() {
+		recipe = append(recipe, fileIngredient{ProductID: i.ProductID, Amount: i.Amount})
+	}
+
+	return fileProduct{
+		ID:          p.GetID(),
+		Name:        p.GetItem().Name,
+		Description: p.GetItem().Description,
+		Price:       newFileMoney(p.GetPrice()),
+		Quantity:    p.GetQuantity(),
+		TaxClass:    string(p.GetTaxClass()),
+		Station:     string(p.GetStation()),
+		MinAge:      p.GetMinAge(),
+		Variants:    variants,
+		Recipe:      recipe,
+	}
+}
+
+// toAggregate rebuilds the product, it fails on records the product rules do not allow
+func (f fileProduct) toAggregate() (product.Product, error) {
+	price, err := f.Price.toMoney()
+	if err != nil {
+		return product.Product{}, err
+	}
+	p, err := product.NewProduct(f.Name, f.Description, price, f.Quantity)
+	if err != nil {
+		return product.Product{}, err
+	}
+	p.SetID(f.ID)
+	if err := p.SetTaxClass(product.TaxClass(f.TaxClass)); err != nil {
+		return product.Product{}, err
+	}
+	if err := p.SetStation(product.Station(f.Station)); err != nil {
+		return product.Product{}, err
+	}
+	if err := p.SetMinAge(f.MinAge); err != nil {
+		return product.Product{}, err
+	}
+	for _, fv := range f.Variants {
+		price, err := fv.Price.toMoney()
+		if err != nil {
+			return product.Product{}, err
+		}
+		if err := p.AddVariant(product.Variant{SKU: fv.SKU, Size: fv.Size, Price: price, Draw: fv.Draw}); err != nil {
+			return product.Product{}, err
+		}
+	}
+	if len(f.Recipe) > 0 {
+		recipe := make([]product.Ingredient, 0, len(f.Recipe))
+		for _, fi := range f.Recipe {
+			recipe = append(recipe, product.Ingredient{ProductID: fi.ProductID, Amount: fi.Amount})
+		}
+		if err := p.SetRecipe(recipe...); err != nil {
+			return product.Product{}, err
+		}
+	}
+
+	return p, nil
+}
+
+func newFileMoney(m domain.Money) fileMoney {
+	return fileMoney{Amount: m.GetAmount(), Currency: m.GetCurrency()}
+}
+
+func (m fileMoney) toMoney() (domain.Money, error) {
+	return domain.NewMoney(m.Amount, m.Currency)
+}
+
+// New opens the file at path and loads the catalog in it, no other process can open it until
+// the repository is closed. Pass filestore.WithJournal to append changes instead of rewriting
+// the file each time, which suits the many small stock changes of a busy night.
+func New(path string, opts ...filestore.Option) (*FileProductRepository, error) {
+	store, err := filestore.Open(path, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	products := make(map[uuid.UUID]product.Product)
+	for key, data := range store.Records() {
+		var fp fileProduct
+		if err := json.Unmarshal(data, &fp); err != nil {
+			store.Close()
+			return nil, fmt.Errorf("error reading product %s: %w", key, err)
+		}
+		p, err := fp.toAggregate()
+		if err != nil {
+			store.Close()
+			return nil, fmt.Errorf("error reading product %s: %w", key, err)
+		}
+		products[p.GetID()] = p
+	}
+
+	return &FileProductRepository{
+		products: products,
+		store:    store,
+	}, nil
+}
+
+// GetAll returns every product sorted by name
+func (f *FileProductRepository) GetAll(ctx context.Context) ([]product.Product, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	f.Lock()
+	defer f.Unlock()
+
+	products := make([]product.Product, 0, len(f.products))
+	for _, p := range f.products {
+		products = append(products, p)
+	}
+	sort.Slice(products, func(i, j int) bool {
+		return products[i].GetItem().Name < products[j].GetItem().Name
+	})
+
+	return products, nil
+}
+
+func (f *FileProductRepository) GetByID(ctx context.Context, id uuid.UUID) (product.Product, error) {
+	if err := ctx.Err(); err != nil {
+		return product.Product{}, err
+	}
+	f.Lock()
+	defer f.Unlock()
+
+	if prd, ok := f.products[id]; ok {
+		return prd, nil
+	}
+	return product.Product{}, product.ErrProductNotFound
+}
+
+func (f *FileProductRepository) GetBySKU(ctx context.Context, sku string) (product.Product, error) {
+	if err := ctx.Err(); err != nil {
+		return product.Product{}, err
+	}
+	f.Lock()
+	defer f.Unlock()
+
+	for _, prd := range f.products {
+		if _, err := prd.GetVariant(sku); err == nil {
+			return prd, nil
+		}
+	}
+	return product.Product{}, product.ErrProductNotFound
+}
+
+func (f *FileProductRepository) Add(ctx context.Context, prd product.Product) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	f.Lock()
+	defer f.Unlock()
+
+	if _, ok := f.products[prd.GetID()]; ok {
+		return fmt.Errorf("error adding product %v due to error: %w", prd.GetItem(), product.ErrProductAlreadyExists)
+	}
+	if err := f.checkSKUs(prd); err != nil {
+		return err
+	}
+	return f.save(prd)
+}
+
+func (f *FileProductRepository) Update(ctx context.Context, prd product.Product) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	f.Lock()
+	defer f.Unlock()
+
+	if _, ok := f.products[prd.GetID()]; !ok {
+		return product.ErrProductNotFound
+	}
+	if err := f.checkSKUs(prd); err != nil {
+		return err
+	}
+	return f.save(prd)
+}
+
+func (f *FileProductRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	f.Lock()
+	defer f.Unlock()
+
+	if _, ok := f.products[id]; !ok {
+		return product.ErrProductNotFound
+	}
+	if err := f.store.Delete(id.String()); err != nil {
+		return fmt.Errorf("error deleting product %s: %w", id, err)
+	}
+	delete(f.products, id)
+
+	return nil
+}
+
+func (f *FileProductRepository) AdjustStock(ctx context.Context, id uuid.UUID, delta int) (product.Product, error) {
+	if err := ctx.Err(); err != nil {
+		return product.Product{}, err
+	}
+	f.Lock()
+	defer f.Unlock()
+
+	prd, ok := f.products[id]
+	if !ok {
+		return product.Product{}, product.ErrProductNotFound
+	}
+	if err := prd.Adjust(delta); err != nil {
+		return product.Product{}, err
+	}
+	if err := f.save(prd); err != nil {
+		return product.Product{}, err
+	}
+
+	return prd, nil
+}
+
+// Compact folds the journal into the file, it does nothing without a journal
+func (f *FileProductRepository) Compact() error {
+	return f.store.Compact()
+}
+
+// Close releases the file for other processes
+func (f *FileProductRepository) Close() error {
+	return f.store.Close()
+}
+
+// save writes the product to the file and then keeps it in memory
+func (f *FileProductRepository) save(prd product.Product) error {
+	if err := f.store.Put(prd.GetID().String(), newFromProduct(prd)); err != nil {
+		return fmt.Errorf("error saving product %s: %w", prd.GetID(), err)
+	}
+	f.products[prd.GetID()] = prd
+
+	return nil
+}
+
+// checkSKUs fails if another product has a variant with one of the SKUs of prd
+func (f *FileProductRepository) checkSKUs(prd product.Product) error {
+	for id, other := range f.products {
+		if id == prd.GetID() {
+			continue
+		}
+		for _, v := range prd.GetVariants() {
+			if _, err := other.GetVariant(v.SKU); err == nil {
+				return fmt.Errorf("SKU %q of %s is used by %s: %w", v.SKU, prd.GetItem().Name, other.GetItem().Name, product.ErrVariantExists)
+			}
+		}
+	}
+	return nil
+}
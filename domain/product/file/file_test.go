@@ -0,0 +1,83 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/devsrivatsa/tavernDDD/domain"
+	"github.com/devsrivatsa/tavernDDD/domain/filestore"
+	"github.com/devsrivatsa/tavernDDD/domain/product"
+)
+
+func newBeer(t *testing.T, name, sku string) product.Product {
+	beer, err := product.NewProduct(name, "A refreshing beer", domain.MustNewMoney(199, "EUR"), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := beer.SetMinAge(18); err != nil {
+		t.Fatal(err)
+	}
+	if err := beer.AddVariant(product.Variant{SKU: sku, Size: "pint", Price: domain.MustNewMoney(450, "EUR"), Draw: 2}); err != nil {
+		t.Fatal(err)
+	}
+	return beer
+}
+
+func TestFileProductRepository_Reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "products.json")
+	repo, err := New(path, filestore.WithJournal(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lager := newBeer(t, "Lager", "LAGER-PINT")
+	stout := newBeer(t, "Stout", "STOUT-PINT")
+	for _, p := range []product.Product{stout, lager} {
+		if err := repo.Add(context.Background(), p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repo.Add(context.Background(), newBeer(t, "Porter", "LAGER-PINT")); !errors.Is(err, product.ErrVariantExists) {
+		t.Errorf("expected error %v, got %v", product.ErrVariantExists, err)
+	}
+
+	// 20 bartenders pour at once, only 10 can get a beer
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := repo.AdjustStock(context.Background(), lager.GetID(), -1); err != nil && !errors.Is(err, product.ErrOutOfStock) {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := repo.Delete(context.Background(), stout.GetID()); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err = New(path, filestore.WithJournal(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	all, err := repo.GetAll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 1 || all[0].GetID() != lager.GetID() {
+		t.Fatalf("expected only the lager, got %d products", len(all))
+	}
+	if all[0].GetQuantity() != 0 || all[0].GetMinAge() != 18 || !all[0].HasVariants() {
+		t.Errorf("expected the lager sold out with its age limit and variant, got %d, %d and %v", all[0].GetQuantity(), all[0].GetMinAge(), all[0].GetVariants())
+	}
+	if _, err := repo.GetBySKU(context.Background(), "STOUT-PINT"); !errors.Is(err, product.ErrProductNotFound) {
+		t.Errorf("expected error %v, got %v", product.ErrProductNotFound, err)
+	}
+}
@@ -1,6 +1,7 @@
 package memory
 
 import (
+	"context"
 	"fmt"
 	"sync"
 
@@ -19,7 +20,13 @@ func New() *MemoryProductRepository {
 	}
 }
 
-func (m *MemoryProductRepository) GetAll() ([]product.Product, error) {
+func (m *MemoryProductRepository) GetAll(ctx context.Context) ([]product.Product, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.Lock()
+	defer m.Unlock()
+
 	var products []product.Product
 
 	for _, product := range m.products {
@@ -29,7 +36,13 @@ func (m *MemoryProductRepository) GetAll() ([]product.Product, error) {
 	return products, nil
 }
 
-func (m *MemoryProductRepository) GetByID(id uuid.UUID) (product.Product, error) {
+func (m *MemoryProductRepository) GetByID(ctx context.Context, id uuid.UUID) (product.Product, error) {
+	if err := ctx.Err(); err != nil {
+		return product.Product{}, err
+	}
+	m.Lock()
+	defer m.Unlock()
+
 	if prd, ok := m.products[id]; ok {
 		return prd, nil
 	}
@@ -37,19 +50,44 @@ func (m *MemoryProductRepository) GetByID(id uuid.UUID) (product.Product, error)
 	return product.Product{}, product.ErrProductNotFound
 }
 
-func (m *MemoryProductRepository) Update(prd product.Product) error {
+func (m *MemoryProductRepository) GetBySKU(ctx context.Context, sku string) (product.Product, error) {
+	if err := ctx.Err(); err != nil {
+		return product.Product{}, err
+	}
+	m.Lock()
+	defer m.Unlock()
+
+	for _, prd := range m.products {
+		if _, err := prd.GetVariant(sku); err == nil {
+			return prd, nil
+		}
+	}
+
+	return product.Product{}, product.ErrProductNotFound
+}
+
+func (m *MemoryProductRepository) Update(ctx context.Context, prd product.Product) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	m.Lock()
 	defer m.Unlock()
 
 	if _, ok := m.products[prd.GetID()]; !ok {
 		return product.ErrProductNotFound
 	}
+	if err := m.checkSKUs(prd); err != nil {
+		return err
+	}
 	m.products[prd.GetID()] = prd
 
 	return nil
 }
 
-func (m *MemoryProductRepository) Delete(id uuid.UUID) error {
+func (m *MemoryProductRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	m.Lock()
 	defer m.Unlock()
 
@@ -61,13 +99,53 @@ func (m *MemoryProductRepository) Delete(id uuid.UUID) error {
 	return nil
 }
 
-func (m *MemoryProductRepository) Add(prd product.Product) error {
+func (m *MemoryProductRepository) Add(ctx context.Context, prd product.Product) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	m.Lock()
 	defer m.Unlock()
 	if _, ok := m.products[prd.GetID()]; ok {
 		return fmt.Errorf("error adding product %v due to error: %w", prd.GetItem(), product.ErrProductAlreadyExists)
 	}
+	if err := m.checkSKUs(prd); err != nil {
+		return err
+	}
 	m.products[prd.GetID()] = prd
 
 	return nil
 }
+
+func (m *MemoryProductRepository) AdjustStock(ctx context.Context, id uuid.UUID, delta int) (product.Product, error) {
+	if err := ctx.Err(); err != nil {
+		return product.Product{}, err
+	}
+	m.Lock()
+	defer m.Unlock()
+
+	prd, ok := m.products[id]
+	if !ok {
+		return product.Product{}, product.ErrProductNotFound
+	}
+	if err := prd.Adjust(delta); err != nil {
+		return product.Product{}, err
+	}
+	m.products[id] = prd
+
+	return prd, nil
+}
+
+// checkSKUs fails if another product has a variant with one of the SKUs of prd
+func (m *MemoryProductRepository) checkSKUs(prd product.Product) error {
+	for id, other := range m.products {
+		if id == prd.GetID() {
+			continue
+		}
+		for _, v := range prd.GetVariants() {
+			if _, err := other.GetVariant(v.SKU); err == nil {
+				return fmt.Errorf("SKU %q of %s is used by %s: %w", v.SKU, prd.GetItem().Name, other.GetItem().Name, product.ErrVariantExists)
+			}
+		}
+	}
+	return nil
+}
@@ -1,9 +1,97 @@
 package memory
 
 import (
+	"context"
+	"errors"
+	"sync"
 	"testing"
+
+	"github.com/devsrivatsa/tavernDDD/domain"
+	"github.com/devsrivatsa/tavernDDD/domain/product"
 )
 
 func TestMemoryProductRepository_Add(t *testing.T) {
+	beer, err := product.NewProduct("Beer", "A refreshing beer", domain.MustNewMoney(199, "EUR"), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := New()
+	if err := repo.Add(context.Background(), beer); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := repo.Add(context.Background(), beer); !errors.Is(err, product.ErrProductAlreadyExists) {
+		t.Errorf("expected error %v, got %v", product.ErrProductAlreadyExists, err)
+	}
+}
+
+func TestMemoryProductRepository_AdjustStock(t *testing.T) {
+	beer, err := product.NewProduct("Beer", "A refreshing beer", domain.MustNewMoney(199, "EUR"), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := New()
+	if err := repo.Add(context.Background(), beer); err != nil {
+		t.Fatal(err)
+	}
+
+	// 20 bartenders pour at once, only 10 can get a beer
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	outOfStock := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := repo.AdjustStock(context.Background(), beer.GetID(), -1); errors.Is(err, product.ErrOutOfStock) {
+				mu.Lock()
+				outOfStock++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if outOfStock != 10 {
+		t.Errorf("expected 10 orders to run out of stock, got %d", outOfStock)
+	}
+	stored, err := repo.GetByID(context.Background(), beer.GetID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.GetQuantity() != 0 {
+		t.Errorf("expected no stock left, got %d", stored.GetQuantity())
+	}
+}
+
+func TestMemoryProductRepository_SKUs(t *testing.T) {
+	newBeer := func(name string) product.Product {
+		beer, err := product.NewProduct(name, "A refreshing beer", domain.MustNewMoney(199, "EUR"), 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := beer.AddVariant(product.Variant{SKU: "PINT", Size: "Pint", Price: domain.MustNewMoney(599, "EUR"), Draw: 2}); err != nil {
+			t.Fatal(err)
+		}
+		return beer
+	}
 
+	repo := New()
+	lager := newBeer("Lager")
+	if err := repo.Add(context.Background(), lager); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Add(context.Background(), newBeer("Stout")); !errors.Is(err, product.ErrVariantExists) {
+		t.Errorf("expected error %v, got %v", product.ErrVariantExists, err)
+	}
+	found, err := repo.GetBySKU(context.Background(), "PINT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.GetID() != lager.GetID() {
+		t.Errorf("expected the lager, got %s", found.GetItem().Name)
+	}
+	if _, err := repo.GetBySKU(context.Background(), "PITCHER"); !errors.Is(err, product.ErrProductNotFound) {
+		t.Errorf("expected error %v, got %v", product.ErrProductNotFound, err)
+	}
 }
@@ -0,0 +1,285 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/devsrivatsa/tavernDDD/domain"
+	"github.com/devsrivatsa/tavernDDD/domain/product"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// skuIndex is the name of the unique index keeping a SKU on a single product
+const skuIndex = "variants_sku_unique"
+
+type MongoRepository struct {
+	db      *mongo.Database
+	product *mongo.Collection
+}
+
+type mongoProduct struct {
+	ID          uuid.UUID         `bson:"_id"`
+	Name        string            `bson:"name"`
+	Description string            `bson:"description"`
+	Price       mongoMoney        `bson:"price"`
+	Quantity    int               `bson:"quantity"`
+	TaxClass    string            `bson:"tax_class"`
+	Station     string            `bson:"station"`
+	MinAge      int               `bson:"min_age"`
+	Variants    []mongoVariant    `bson:"variants"`
+	Recipe      []mongoIngredient `bson:"recipe"`
+}
+
+type mongoMoney struct {
+	Amount   int64  `bson:"amount"`
+	Currency string `bson:"currency"`
+}
+
+type mongoVariant struct {
+	SKU   string     `bson:"sku"`
+	Size  string     `bson:"size"`
+	Price mongoMoney `bson:"price"`
+	Draw  int        `bson:"draw"`
+}
+
+type mongoIngredient struct {
+	ProductID uuid.UUID `bson:"product_id"`
+	Amount    int       `bson:"amount"`
+}
+
+func NewFromProduct(p product.Product) mongoProduct {
+	variants := make([]mongoVariant, 0)
+	for _, v := range p.GetVariants() {
+		variants = append(variants, mongoVariant{
+			SKU:   v.SKU,
+			Size:  v.Size,
+			Price: newMongoMoney(v.Price),
+			Draw:  v.Draw,
+		})
+	}
+	recipe := make([]mongoIngredient, 0)
+	for _, i := range p.GetRecipe() {
+		recipe = append(recipe, mongoIngredient{
+			ProductID: i.ProductID,
+			Amount:    i.Amount,
+		})
+	}
+
+	return mongoProduct{
+		ID:          p.GetID(),
+		Name:        p.GetItem().Name,
+		Description: p.GetItem().Description,
+		Price:       newMongoMoney(p.GetPrice()),
+		Quantity:    p.GetQuantity(),
+		TaxClass:    string(p.GetTaxClass()),
+		Station:     string(p.GetStation()),
+		MinAge:      p.GetMinAge(),
+		Variants:    variants,
+		Recipe:      recipe,
+	}
+}
+
+// ToAggregate rebuilds the product, it fails on documents the product rules do not allow.
+// Documents stored before tax classes or stations existed get the defaults.
+func (m mongoProduct) ToAggregate() (product.Product, error) {
+	price, err := m.Price.toMoney()
+	if err != nil {
+		return product.Product{}, fmt.Errorf("product %s: %w", m.ID, err)
+	}
+	p, err := product.NewProduct(m.Name, m.Description, price, m.Quantity)
+	if err != nil {
+		return product.Product{}, fmt.Errorf("product %s: %w", m.ID, err)
+	}
+	p.SetID(m.ID)
+	if m.TaxClass != "" {
+		if err := p.SetTaxClass(product.TaxClass(m.TaxClass)); err != nil {
+			return product.Product{}, fmt.Errorf("product %s: %w", m.ID, err)
+		}
+	}
+	if m.Station != "" {
+		if err := p.SetStation(product.Station(m.Station)); err != nil {
+			return product.Product{}, fmt.Errorf("product %s: %w", m.ID, err)
+		}
+	}
+	if err := p.SetMinAge(m.MinAge); err != nil {
+		return product.Product{}, fmt.Errorf("product %s: %w", m.ID, err)
+	}
+	for _, mv := range m.Variants {
+		price, err := mv.Price.toMoney()
+		if err != nil {
+			return product.Product{}, fmt.Errorf("product %s: %w", m.ID, err)
+		}
+		err = p.AddVariant(product.Variant{SKU: mv.SKU, Size: mv.Size, Price: price, Draw: mv.Draw})
+		if err != nil {
+			return product.Product{}, fmt.Errorf("product %s: %w", m.ID, err)
+		}
+	}
+	if len(m.Recipe) > 0 {
+		recipe := make([]product.Ingredient, 0, len(m.Recipe))
+		for _, mi := range m.Recipe {
+			recipe = append(recipe, product.Ingredient{ProductID: mi.ProductID, Amount: mi.Amount})
+		}
+		if err := p.SetRecipe(recipe...); err != nil {
+			return product.Product{}, fmt.Errorf("product %s: %w", m.ID, err)
+		}
+	}
+
+	return p, nil
+}
+
+func newMongoMoney(m domain.Money) mongoMoney {
+	return mongoMoney{
+		Amount:   m.GetAmount(),
+		Currency: m.GetCurrency(),
+	}
+}
+
+func (m mongoMoney) toMoney() (domain.Money, error) {
+	return domain.NewMoney(m.Amount, m.Currency)
+}
+
+// New connects to the database and makes sure the product indexes exist
+func New(ctx context.Context, connString string) (*MongoRepository, error) {
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(connString))
+	if err != nil {
+		return nil, err
+	}
+
+	db := client.Database("tavern")
+	products := db.Collection("products")
+	_, err = products.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "variants.sku", Value: 1}},
+			// products without variants have no SKU to keep unique
+			Options: options.Index().SetName(skuIndex).SetUnique(true).
+				SetPartialFilterExpression(bson.M{"variants.sku": bson.M{"$exists": true}}),
+		},
+		{
+			Keys: bson.D{{Key: "name", Value: 1}},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating product indexes: %w", err)
+	}
+
+	return &MongoRepository{
+		db:      db,
+		product: products,
+	}, nil
+}
+
+// GetAll returns every product sorted by name
+func (mr *MongoRepository) GetAll(ctx context.Context) ([]product.Product, error) {
+	cursor, err := mr.product.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var docs []mongoProduct
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	products := make([]product.Product, 0, len(docs))
+	for _, d := range docs {
+		p, err := d.ToAggregate()
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, p)
+	}
+
+	return products, nil
+}
+
+func (mr *MongoRepository) GetByID(ctx context.Context, id uuid.UUID) (product.Product, error) {
+	return mr.findOne(ctx, bson.M{"_id": id})
+}
+
+func (mr *MongoRepository) GetBySKU(ctx context.Context, sku string) (product.Product, error) {
+	return mr.findOne(ctx, bson.M{"variants.sku": sku})
+}
+
+func (mr *MongoRepository) findOne(ctx context.Context, filter bson.M) (product.Product, error) {
+	var p mongoProduct
+	if err := mr.product.FindOne(ctx, filter).Decode(&p); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return product.Product{}, product.ErrProductNotFound
+		}
+		return product.Product{}, err
+	}
+
+	return p.ToAggregate()
+}
+
+func (mr *MongoRepository) Add(ctx context.Context, p product.Product) error {
+	if _, err := mr.product.InsertOne(ctx, NewFromProduct(p)); err != nil {
+		return duplicateError(p, err)
+	}
+
+	return nil
+}
+
+// Update replaces the stored product, stock included. Use AdjustStock to change the stock of
+// a product that is being sold.
+func (mr *MongoRepository) Update(ctx context.Context, p product.Product) error {
+	result, err := mr.product.ReplaceOne(ctx, bson.M{"_id": p.GetID()}, NewFromProduct(p))
+	if err != nil {
+		return duplicateError(p, err)
+	}
+	if result.MatchedCount == 0 {
+		return product.ErrProductNotFound
+	}
+
+	return nil
+}
+
+func (mr *MongoRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := mr.product.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return product.ErrProductNotFound
+	}
+
+	return nil
+}
+
+// AdjustStock changes the stock in a single update that only matches while there is enough
+// stock, so concurrent orders can never take the stock below zero
+func (mr *MongoRepository) AdjustStock(ctx context.Context, id uuid.UUID, delta int) (product.Product, error) {
+	filter := bson.M{"_id": id}
+	if delta < 0 {
+		filter["quantity"] = bson.M{"$gte": -delta}
+	}
+	var p mongoProduct
+	err := mr.product.FindOneAndUpdate(ctx, filter, bson.M{"$inc": bson.M{"quantity": delta}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&p)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		if _, err := mr.GetByID(ctx, id); err != nil {
+			return product.Product{}, err
+		}
+		return product.Product{}, product.ErrOutOfStock
+	}
+	if err != nil {
+		return product.Product{}, err
+	}
+
+	return p.ToAggregate()
+}
+
+// duplicateError maps a duplicate key on the SKU index to ErrVariantExists and one on the
+// product ID to ErrProductAlreadyExists
+func duplicateError(p product.Product, err error) error {
+	if !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+	if strings.Contains(err.Error(), skuIndex) {
+		return fmt.Errorf("a SKU of %s is used by another product: %w", p.GetItem().Name, product.ErrVariantExists)
+	}
+	return fmt.Errorf("error adding product %v due to error: %w", p.GetItem(), product.ErrProductAlreadyExists)
+}
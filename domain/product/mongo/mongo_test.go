@@ -0,0 +1,163 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/devsrivatsa/tavernDDD/domain"
+	"github.com/devsrivatsa/tavernDDD/domain/product"
+	"github.com/google/uuid"
+)
+
+const mongoConnectionString = "mongodb://localhost:27017"
+
+func newBeer(t *testing.T) product.Product {
+	beer, err := product.NewProduct("Beer", "A refreshing beer", domain.MustNewMoney(199, "EUR"), 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := beer.SetTaxClass(product.TaxClassAlcohol); err != nil {
+		t.Fatal(err)
+	}
+	if err := beer.SetMinAge(18); err != nil {
+		t.Fatal(err)
+	}
+	if err := beer.AddVariant(product.Variant{SKU: "BEER-PINT", Size: "pint", Price: domain.MustNewMoney(450, "EUR"), Draw: 2}); err != nil {
+		t.Fatal(err)
+	}
+	return beer
+}
+
+// setupTestRepo connects to a local server with an empty products collection, the test is
+// skipped when no server is running
+func setupTestRepo(t *testing.T) *MongoRepository {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	repo, err := New(ctx, mongoConnectionString)
+	if err != nil {
+		t.Skipf("no MongoDB server at %s: %v", mongoConnectionString, err)
+	}
+	if err := repo.product.Drop(ctx); err != nil {
+		t.Fatalf("Failed to drop collection: %v", err)
+	}
+	repo, err = New(ctx, mongoConnectionString)
+	if err != nil {
+		t.Fatalf("Failed to recreate indexes: %v", err)
+	}
+
+	return repo
+}
+
+func TestMongoProduct_ToAggregate(t *testing.T) {
+	beer := newBeer(t)
+	cocktail, err := product.NewProduct("Gin Tonic", "Gin with tonic", domain.MustNewMoney(850, "EUR"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cocktail.SetRecipe(product.Ingredient{ProductID: beer.GetID(), Amount: 4}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cocktail.SetStation(product.StationKitchen); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []product.Product{beer, cocktail} {
+		got, err := NewFromProduct(p).ToAggregate()
+		if err != nil {
+			t.Fatalf("expected %s to round trip, got %v", p.GetItem().Name, err)
+		}
+		if got.GetID() != p.GetID() || *got.GetItem() != *p.GetItem() || !got.GetPrice().Equals(p.GetPrice()) || got.GetQuantity() != p.GetQuantity() {
+			t.Errorf("expected %+v, got %+v", *p.GetItem(), *got.GetItem())
+		}
+		if got.GetTaxClass() != p.GetTaxClass() || got.GetStation() != p.GetStation() || got.GetMinAge() != p.GetMinAge() {
+			t.Errorf("expected %s, %s and %d, got %s, %s and %d", p.GetTaxClass(), p.GetStation(), p.GetMinAge(), got.GetTaxClass(), got.GetStation(), got.GetMinAge())
+		}
+		if len(got.GetVariants()) != len(p.GetVariants()) || len(got.GetRecipe()) != len(p.GetRecipe()) {
+			t.Errorf("expected %d variants and %d ingredients, got %d and %d", len(p.GetVariants()), len(p.GetRecipe()), len(got.GetVariants()), len(got.GetRecipe()))
+		}
+	}
+
+	old := NewFromProduct(beer)
+	old.TaxClass, old.Station = "", ""
+	got, err := old.ToAggregate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.GetTaxClass() != product.TaxClassStandard || got.GetStation() != product.StationBar {
+		t.Errorf("expected the defaults for an old document, got %s and %s", got.GetTaxClass(), got.GetStation())
+	}
+	old.Price.Currency = ""
+	if _, err := old.ToAggregate(); !errors.Is(err, domain.ErrInvalidCurrency) {
+		t.Errorf("expected error %v, got %v", domain.ErrInvalidCurrency, err)
+	}
+}
+
+func TestMongoRepository(t *testing.T) {
+	repo := setupTestRepo(t)
+	beer := newBeer(t)
+
+	if err := repo.Add(context.Background(), beer); err != nil {
+		t.Fatalf("Error adding product: %v", err)
+	}
+	if err := repo.Add(context.Background(), beer); !errors.Is(err, product.ErrProductAlreadyExists) {
+		t.Errorf("expected error %v, got %v", product.ErrProductAlreadyExists, err)
+	}
+	copycat, err := product.NewProduct("Ale", "Another beer", domain.MustNewMoney(299, "EUR"), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Add(context.Background(), copycat); err != nil {
+		t.Fatalf("Error adding product without variants: %v", err)
+	}
+	if err := copycat.AddVariant(beer.GetVariants()[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Update(context.Background(), copycat); !errors.Is(err, product.ErrVariantExists) {
+		t.Errorf("expected error %v, got %v", product.ErrVariantExists, err)
+	}
+
+	got, err := repo.GetBySKU(context.Background(), beer.GetVariants()[0].SKU)
+	if err != nil || got.GetID() != beer.GetID() {
+		t.Errorf("expected %s by its SKU, got %v", beer.GetItem().Name, err)
+	}
+	all, err := repo.GetAll(context.Background())
+	if err != nil || len(all) != 2 || all[0].GetItem().Name != "Ale" {
+		t.Errorf("expected Ale and Beer, got %d products (%v)", len(all), err)
+	}
+
+	if _, err := repo.AdjustStock(context.Background(), beer.GetID(), -41); !errors.Is(err, product.ErrOutOfStock) {
+		t.Errorf("expected error %v, got %v", product.ErrOutOfStock, err)
+	}
+	if _, err := repo.AdjustStock(context.Background(), uuid.New(), -1); !errors.Is(err, product.ErrProductNotFound) {
+		t.Errorf("expected error %v, got %v", product.ErrProductNotFound, err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = repo.AdjustStock(context.Background(), beer.GetID(), -1)
+		}()
+	}
+	wg.Wait()
+	if got, err := repo.GetByID(context.Background(), beer.GetID()); err != nil || got.GetQuantity() != 0 {
+		t.Errorf("expected the stock to stop at 0, got %d (%v)", got.GetQuantity(), err)
+	}
+
+	if err := repo.Delete(context.Background(), beer.GetID()); err != nil {
+		t.Fatalf("Error deleting product: %v", err)
+	}
+	if err := repo.Delete(context.Background(), beer.GetID()); !errors.Is(err, product.ErrProductNotFound) {
+		t.Errorf("expected error %v, got %v", product.ErrProductNotFound, err)
+	}
+	if _, err := repo.GetByID(context.Background(), beer.GetID()); !errors.Is(err, product.ErrProductNotFound) {
+		t.Errorf("expected error %v, got %v", product.ErrProductNotFound, err)
+	}
+	if err := repo.Update(context.Background(), beer); !errors.Is(err, product.ErrProductNotFound) {
+		t.Errorf("expected error %v, got %v", product.ErrProductNotFound, err)
+	}
+}
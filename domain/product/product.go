@@ -2,6 +2,7 @@ package product
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/devsrivatsa/tavernDDD/domain"
 	"github.com/google/uuid"
@@ -11,19 +12,77 @@ var (
 	ErrMissingValue         = errors.New("missing important values")
 	ErrProductNotFound      = errors.New("no such product found")
 	ErrProductAlreadyExists = errors.New("product already exists")
+	ErrInvalidPrice         = errors.New("price cannot be negative")
+	ErrInvalidQuantity      = errors.New("quantity must be a positive number")
+	ErrOutOfStock           = errors.New("not enough stock on hand")
+	ErrInvalidTaxClass      = errors.New("unknown tax class")
+	ErrInvalidStation       = errors.New("unknown preparation station")
+	ErrInvalidMinAge        = errors.New("minimum age cannot be negative")
+	ErrInvalidVariant       = errors.New("a variant needs a SKU, a size, a price and a stock draw greater than zero")
+	ErrVariantExists        = errors.New("the SKU is already in use")
+	ErrVariantNotFound      = errors.New("the product has no variant with the SKU")
+	ErrInvalidRecipe        = errors.New("a recipe needs other products as ingredients, each once and in an amount greater than zero")
+)
+
+// TaxClass groups products that are taxed at the same rate
+type TaxClass string
+
+const (
+	TaxClassStandard TaxClass = "standard"
+	TaxClassFood     TaxClass = "food"
+	TaxClassAlcohol  TaxClass = "alcohol"
+)
+
+// Station is where a product is prepared before it is served
+type Station string
+
+const (
+	StationBar     Station = "bar"
+	StationKitchen Station = "kitchen"
 )
 
 type Product struct {
 	item     *domain.Item
-	price    float64
+	price    domain.Money
 	quantity int
+	taxClass TaxClass
+	station  Station
+	//minAge is the age a customer needs to be sold the product, 0 for everyone
+	minAge int
+	//variants are the sizes the product is sold in, it is sold as is without them
+	variants []Variant
+	//recipe lists the ingredients one item is made of, the product is stocked as is without it
+	recipe []Ingredient
+}
+
+// Ingredient is a value object for the stock units of another product that go into one item,
+// e.g. 4 cl of gin stocked in cl
+type Ingredient struct {
+	ProductID uuid.UUID
+	Amount    int
+}
+
+// Variant is a value object for a size the product is sold in, e.g. a pint of beer.
+// Draw is the number of stock units of the product one variant takes, so with the
+// beer stocked in half-pints a pint draws 2 and a pitcher 8.
+type Variant struct {
+	SKU   string
+	Size  string
+	Price domain.Money
+	Draw  int
 }
 
 // factory function to create a new product
-func NewProduct(name, description string, price float64) (Product, error) {
-	if name == "" || description == "" {
+func NewProduct(name, description string, price domain.Money, quantity int) (Product, error) {
+	if name == "" || description == "" || price.GetCurrency() == "" {
 		return Product{}, ErrMissingValue
 	}
+	if price.IsNegative() {
+		return Product{}, ErrInvalidPrice
+	}
+	if quantity < 0 {
+		return Product{}, ErrInvalidQuantity
+	}
 	return Product{
 		item: &domain.Item{
 			ID:          uuid.New(),
@@ -31,7 +90,9 @@ func NewProduct(name, description string, price float64) (Product, error) {
 			Description: description,
 		},
 		price:    price,
-		quantity: 1,
+		quantity: quantity,
+		taxClass: TaxClassStandard,
+		station:  StationBar,
 	}, nil
 }
 
@@ -40,9 +101,156 @@ func NewProduct(name, description string, price float64) (Product, error) {
 func (p Product) GetID() uuid.UUID {
 	return p.item.ID
 }
+
+// SetID gives the product the identifier it was stored under, copies of the product keep theirs
+func (p *Product) SetID(id uuid.UUID) {
+	item := domain.Item{}
+	if p.item != nil {
+		item = *p.item
+	}
+	item.ID = id
+	p.item = &item
+}
+
 func (p Product) GetItem() *domain.Item {
 	return p.item
 }
-func (p Product) GetPrice() float64 {
+func (p Product) GetPrice() domain.Money {
 	return p.price
 }
+
+// GetQuantity returns the stock on hand
+func (p Product) GetQuantity() int {
+	return p.quantity
+}
+
+// Restock adds newly delivered stock to the product
+func (p *Product) Restock(amount int) error {
+	if amount <= 0 {
+		return ErrInvalidQuantity
+	}
+	p.quantity += amount
+
+	return nil
+}
+
+// Adjust corrects the stock on hand by delta (breakage, stock counts, sales).
+// The stock can never drop below zero.
+func (p *Product) Adjust(delta int) error {
+	if p.quantity+delta < 0 {
+		return fmt.Errorf("%s has %d on hand, cannot take %d: %w", p.item.Name, p.quantity, -delta, ErrOutOfStock)
+	}
+	p.quantity += delta
+
+	return nil
+}
+
+func (p Product) GetTaxClass() TaxClass {
+	return p.taxClass
+}
+
+func (p *Product) SetTaxClass(class TaxClass) error {
+	switch class {
+	case TaxClassStandard, TaxClassFood, TaxClassAlcohol:
+		p.taxClass = class
+		return nil
+	}
+	return fmt.Errorf("%q: %w", class, ErrInvalidTaxClass)
+}
+
+func (p Product) GetStation() Station {
+	return p.station
+}
+
+func (p *Product) SetStation(station Station) error {
+	switch station {
+	case StationBar, StationKitchen:
+		p.station = station
+		return nil
+	}
+	return fmt.Errorf("%q: %w", station, ErrInvalidStation)
+}
+
+// GetMinAge returns the age a customer needs to be sold the product, 0 when it is not restricted
+func (p Product) GetMinAge() int {
+	return p.minAge
+}
+
+func (p *Product) SetMinAge(age int) error {
+	if age < 0 {
+		return ErrInvalidMinAge
+	}
+	p.minAge = age
+
+	return nil
+}
+
+// GetVariants returns a copy of the variants of the product
+func (p Product) GetVariants() []Variant {
+	return append([]Variant(nil), p.variants...)
+}
+
+func (p Product) HasVariants() bool {
+	return len(p.variants) > 0
+}
+
+func (p Product) GetVariant(sku string) (Variant, error) {
+	for _, v := range p.variants {
+		if v.SKU == sku {
+			return v, nil
+		}
+	}
+	return Variant{}, fmt.Errorf("%s has no SKU %q: %w", p.item.Name, sku, ErrVariantNotFound)
+}
+
+// AddVariant adds a size the product is sold in, the price must be in the currency of the product
+func (p *Product) AddVariant(v Variant) error {
+	if v.SKU == "" || v.Size == "" || v.Draw <= 0 || v.Price.IsNegative() || v.Price.GetCurrency() != p.price.GetCurrency() {
+		return ErrInvalidVariant
+	}
+	if _, err := p.GetVariant(v.SKU); err == nil {
+		return fmt.Errorf("SKU %q: %w", v.SKU, ErrVariantExists)
+	}
+	p.variants = append(p.variants[:len(p.variants):len(p.variants)], v)
+
+	return nil
+}
+
+func (p *Product) RemoveVariant(sku string) error {
+	if _, err := p.GetVariant(sku); err != nil {
+		return err
+	}
+	variants := make([]Variant, 0, len(p.variants))
+	for _, v := range p.variants {
+		if v.SKU != sku {
+			variants = append(variants, v)
+		}
+	}
+	p.variants = variants
+
+	return nil
+}
+
+// GetRecipe returns a copy of the ingredients one item of the product is made of
+func (p Product) GetRecipe() []Ingredient {
+	return append([]Ingredient(nil), p.recipe...)
+}
+
+// HasRecipe reports whether the product is made from ingredients instead of being stocked as is
+func (p Product) HasRecipe() bool {
+	return len(p.recipe) > 0
+}
+
+// SetRecipe replaces the recipe of the product, no ingredients makes it a stocked product again
+func (p *Product) SetRecipe(ingredients ...Ingredient) error {
+	seen := make(map[uuid.UUID]bool, len(ingredients))
+	for _, i := range ingredients {
+		if i.ProductID == uuid.Nil || i.ProductID == p.GetID() || i.Amount <= 0 || seen[i.ProductID] {
+			return fmt.Errorf("ingredient %s of %s: %w", i.ProductID, p.item.Name, ErrInvalidRecipe)
+		}
+		seen[i.ProductID] = true
+	}
+	p.recipe = append([]Ingredient(nil), ingredients...)
+
+	return nil
+}
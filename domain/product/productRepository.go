@@ -1,12 +1,22 @@
 package product
 
-import "github.com/google/uuid"
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
 
 // manage product aggregates
 type ProductRepository interface {
-	GetAll() ([]Product, error)
-	GetByID(id uuid.UUID) (Product, error)
-	Add(product Product) error
-	Update(product Product) error
-	Delete(id uuid.UUID) error
+	GetAll(ctx context.Context) ([]Product, error)
+	GetByID(ctx context.Context, id uuid.UUID) (Product, error)
+	// GetBySKU returns the product with a variant of the given SKU
+	GetBySKU(ctx context.Context, sku string) (Product, error)
+	// Add and Update fail with ErrVariantExists when a SKU of the product is used by another product
+	Add(ctx context.Context, product Product) error
+	Update(ctx context.Context, product Product) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	// AdjustStock atomically applies delta to the stock of a product and returns the updated product.
+	// It fails with ErrOutOfStock, leaving the stock untouched, if the stock would drop below zero.
+	AdjustStock(ctx context.Context, id uuid.UUID, delta int) (Product, error)
 }
@@ -1,9 +1,200 @@
 package product
 
 import (
+	"errors"
 	"testing"
+
+	"github.com/devsrivatsa/tavernDDD/domain"
+	"github.com/google/uuid"
 )
 
 func TestProduct_NewProduct(t *testing.T) {
+	type testCase struct {
+		test          string
+		name          string
+		description   string
+		price         domain.Money
+		quantity      int
+		expectedError error
+	}
+
+	testcases := []testCase{
+		{
+			test:          "Missing name",
+			description:   "A refreshing beer",
+			price:         domain.MustNewMoney(199, "EUR"),
+			expectedError: ErrMissingValue,
+		},
+		{
+			test:          "Missing price",
+			name:          "Beer",
+			description:   "A refreshing beer",
+			expectedError: ErrMissingValue,
+		},
+		{
+			test:          "Negative price",
+			name:          "Beer",
+			description:   "A refreshing beer",
+			price:         domain.MustNewMoney(-199, "EUR"),
+			expectedError: ErrInvalidPrice,
+		},
+		{
+			test:          "Negative stock",
+			name:          "Beer",
+			description:   "A refreshing beer",
+			price:         domain.MustNewMoney(199, "EUR"),
+			quantity:      -1,
+			expectedError: ErrInvalidQuantity,
+		},
+		{
+			test:          "Valid product",
+			name:          "Beer",
+			description:   "A refreshing beer",
+			price:         domain.MustNewMoney(199, "EUR"),
+			quantity:      24,
+			expectedError: nil,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.test, func(t *testing.T) {
+			_, err := NewProduct(tc.name, tc.description, tc.price, tc.quantity)
+			if !errors.Is(err, tc.expectedError) {
+				t.Errorf("expected error %v, got %v", tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestProduct_Stock(t *testing.T) {
+	prd, err := NewProduct("Beer", "A refreshing beer", domain.MustNewMoney(199, "EUR"), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := prd.Restock(0); !errors.Is(err, ErrInvalidQuantity) {
+		t.Errorf("expected error %v, got %v", ErrInvalidQuantity, err)
+	}
+	if err := prd.Restock(3); err != nil {
+		t.Fatal(err)
+	}
+	if err := prd.Adjust(-6); !errors.Is(err, ErrOutOfStock) {
+		t.Errorf("expected error %v, got %v", ErrOutOfStock, err)
+	}
+	if err := prd.Adjust(-5); err != nil {
+		t.Fatal(err)
+	}
+	if prd.GetQuantity() != 0 {
+		t.Errorf("expected no stock left, got %d", prd.GetQuantity())
+	}
+}
+
+func TestProduct_SetMinAge(t *testing.T) {
+	prd, err := NewProduct("Wine", "A fine wine", domain.MustNewMoney(599, "EUR"), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := prd.SetMinAge(-1); !errors.Is(err, ErrInvalidMinAge) {
+		t.Errorf("expected error %v, got %v", ErrInvalidMinAge, err)
+	}
+	if err := prd.SetMinAge(18); err != nil {
+		t.Fatal(err)
+	}
+	if prd.GetMinAge() != 18 {
+		t.Errorf("expected a minimum age of 18, got %d", prd.GetMinAge())
+	}
+}
+
+func TestProduct_SetStation(t *testing.T) {
+	prd, err := NewProduct("Stew", "A hearty stew", domain.MustNewMoney(899, "EUR"), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prd.GetStation() != StationBar {
+		t.Errorf("expected station %s by default, got %s", StationBar, prd.GetStation())
+	}
+
+	if err := prd.SetStation("cellar"); !errors.Is(err, ErrInvalidStation) {
+		t.Errorf("expected error %v, got %v", ErrInvalidStation, err)
+	}
+	if err := prd.SetStation(StationKitchen); err != nil {
+		t.Fatal(err)
+	}
+	if prd.GetStation() != StationKitchen {
+		t.Errorf("expected station %s, got %s", StationKitchen, prd.GetStation())
+	}
+}
+
+func TestProduct_AddVariant(t *testing.T) {
+	type testCase struct {
+		test          string
+		variant       Variant
+		expectedError error
+	}
+
+	beer, err := NewProduct("Beer", "A refreshing beer", domain.MustNewMoney(199, "EUR"), 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testcases := []testCase{
+		{test: "Pint", variant: Variant{SKU: "BEER-PINT", Size: "Pint", Price: domain.MustNewMoney(599, "EUR"), Draw: 2}, expectedError: nil},
+		{test: "Same SKU twice", variant: Variant{SKU: "BEER-PINT", Size: "Pint", Price: domain.MustNewMoney(599, "EUR"), Draw: 2}, expectedError: ErrVariantExists},
+		{test: "Missing size", variant: Variant{SKU: "BEER-HALF", Price: domain.MustNewMoney(350, "EUR"), Draw: 1}, expectedError: ErrInvalidVariant},
+		{test: "No draw", variant: Variant{SKU: "BEER-HALF", Size: "Half pint", Price: domain.MustNewMoney(350, "EUR")}, expectedError: ErrInvalidVariant},
+		{test: "Other currency", variant: Variant{SKU: "BEER-HALF", Size: "Half pint", Price: domain.MustNewMoney(350, "USD"), Draw: 1}, expectedError: ErrInvalidVariant},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.test, func(t *testing.T) {
+			err := beer.AddVariant(tc.variant)
+			if !errors.Is(err, tc.expectedError) {
+				t.Errorf("expected error %v, got %v", tc.expectedError, err)
+			}
+		})
+	}
+
+	copied := beer
+	if err := copied.RemoveVariant("BEER-PINT"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := copied.GetVariant("BEER-PINT"); !errors.Is(err, ErrVariantNotFound) {
+		t.Errorf("expected error %v, got %v", ErrVariantNotFound, err)
+	}
+	if len(beer.GetVariants()) != 1 {
+		t.Errorf("expected the original to keep its variant, got %d", len(beer.GetVariants()))
+	}
+}
+
+func TestProduct_SetRecipe(t *testing.T) {
+	type testCase struct {
+		test          string
+		ingredients   []Ingredient
+		expectedError error
+	}
+
+	gin, tonic := uuid.New(), uuid.New()
+	cocktail, err := NewProduct("Gin and tonic", "A long drink", domain.MustNewMoney(899, "EUR"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testcases := []testCase{
+		{test: "Missing product", ingredients: []Ingredient{{Amount: 4}}, expectedError: ErrInvalidRecipe},
+		{test: "No amount", ingredients: []Ingredient{{ProductID: gin}}, expectedError: ErrInvalidRecipe},
+		{test: "Made of itself", ingredients: []Ingredient{{ProductID: cocktail.GetID(), Amount: 1}}, expectedError: ErrInvalidRecipe},
+		{test: "Same ingredient twice", ingredients: []Ingredient{{ProductID: gin, Amount: 4}, {ProductID: gin, Amount: 2}}, expectedError: ErrInvalidRecipe},
+		{test: "Gin and tonic", ingredients: []Ingredient{{ProductID: gin, Amount: 4}, {ProductID: tonic, Amount: 1}}, expectedError: nil},
+	}
 
+	for _, tc := range testcases {
+		t.Run(tc.test, func(t *testing.T) {
+			err := cocktail.SetRecipe(tc.ingredients...)
+			if !errors.Is(err, tc.expectedError) {
+				t.Errorf("expected error %v, got %v", tc.expectedError, err)
+			}
+		})
+	}
+	if !cocktail.HasRecipe() || len(cocktail.GetRecipe()) != 2 {
+		t.Errorf("expected a recipe of 2 ingredients, got %d", len(cocktail.GetRecipe()))
+	}
 }
@@ -0,0 +1,411 @@
+// Package sql keeps the product catalog in a relational database through database/sql. The
+// queries use ? placeholders and portable types, it is tested against SQLite.
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/devsrivatsa/tavernDDD/domain"
+	"github.com/devsrivatsa/tavernDDD/domain/migration"
+	"github.com/devsrivatsa/tavernDDD/domain/product"
+	"github.com/google/uuid"
+)
+
+// migrations of the product tables, append new ones, never change one that was released
+var migrations = []migration.Migration{
+	{
+		Version: 1,
+		Name:    "create products",
+		Statements: []string{
+			`CREATE TABLE products (
+				id             TEXT    PRIMARY KEY,
+				name           TEXT    NOT NULL,
+				description    TEXT    NOT NULL,
+				price_amount   INTEGER NOT NULL,
+				price_currency TEXT    NOT NULL,
+				quantity       INTEGER NOT NULL CHECK (quantity >= 0),
+				tax_class      TEXT    NOT NULL,
+				station        TEXT    NOT NULL,
+				min_age        INTEGER NOT NULL DEFAULT 0
+			)`,
+			`CREATE INDEX products_name ON products (name)`,
+			`CREATE TABLE product_variants (
+				product_id     TEXT    NOT NULL REFERENCES products (id),
+				position       INTEGER NOT NULL,
+				sku            TEXT    NOT NULL UNIQUE,
+				size           TEXT    NOT NULL,
+				price_amount   INTEGER NOT NULL,
+				price_currency TEXT    NOT NULL,
+				draw           INTEGER NOT NULL,
+				PRIMARY KEY (product_id, position)
+			)`,
+			`CREATE TABLE product_ingredients (
+				product_id    TEXT    NOT NULL REFERENCES products (id),
+				position      INTEGER NOT NULL,
+				ingredient_id TEXT    NOT NULL,
+				amount        INTEGER NOT NULL,
+				PRIMARY KEY (product_id, position)
+			)`,
+		},
+	},
+}
+
+const selectProducts = `SELECT id, name, description, price_amount, price_currency, quantity, tax_class, station, min_age FROM products`
+
+type SQLRepository struct {
+	db *sql.DB
+}
+
+// New brings the product tables up to date and returns a repository keeping the catalog in them
+func New(ctx context.Context, db *sql.DB) (*SQLRepository, error) {
+	if err := migration.Apply(ctx, db, "products", migrations); err != nil {
+		return nil, err
+	}
+	return &SQLRepository{db: db}, nil
+}
+
+// row is a product as it is stored, before its variants and recipe are loaded
+type row struct {
+	id, name, description string
+	price                 int64
+	currency              string
+	quantity              int
+	taxClass, station     string
+	minAge                int
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanRow(s scanner) (row, error) {
+	var r row
+	err := s.Scan(&r.id, &r.name, &r.description, &r.price, &r.currency, &r.quantity, &r.taxClass, &r.station, &r.minAge)
+	return r, err
+}
+
+// GetAll returns every product sorted by name
+func (sr *SQLRepository) GetAll(ctx context.Context) ([]product.Product, error) {
+	rows, err := sr.db.QueryContext(ctx, selectProducts+` ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	var stored []row
+	for rows.Next() {
+		r, err := scanRow(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		stored = append(stored, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	products := make([]product.Product, 0, len(stored))
+	for _, r := range stored {
+		p, err := toAggregate(ctx, sr.db, r)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, p)
+	}
+
+	return products, nil
+}
+
+func (sr *SQLRepository) GetByID(ctx context.Context, id uuid.UUID) (product.Product, error) {
+	return findOne(ctx, sr.db, selectProducts+` WHERE id = ?`, id.String())
+}
+
+func (sr *SQLRepository) GetBySKU(ctx context.Context, sku string) (product.Product, error) {
+	return findOne(ctx, sr.db, selectProducts+` WHERE id = (SELECT product_id FROM product_variants WHERE sku = ?)`, sku)
+}
+
+// querier is what reading a product needs, so it can be read inside a transaction as well
+type querier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func findOne(ctx context.Context, q querier, query string, args ...any) (product.Product, error) {
+	r, err := scanRow(q.QueryRowContext(ctx, query, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return product.Product{}, product.ErrProductNotFound
+	}
+	if err != nil {
+		return product.Product{}, err
+	}
+
+	return toAggregate(ctx, q, r)
+}
+
+func (sr *SQLRepository) Add(ctx context.Context, p product.Product) error {
+	tx, err := sr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM products WHERE id = ?`, p.GetID().String()).Scan(&exists); err != nil {
+		return err
+	}
+	if exists > 0 {
+		return fmt.Errorf("error adding product %v due to error: %w", p.GetItem(), product.ErrProductAlreadyExists)
+	}
+	if err := checkSKUs(ctx, tx, p); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO products (id, name, description, price_amount, price_currency, quantity, tax_class, station, min_age)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		p.GetID().String(), p.GetItem().Name, p.GetItem().Description, p.GetPrice().GetAmount(), p.GetPrice().GetCurrency(),
+		p.GetQuantity(), string(p.GetTaxClass()), string(p.GetStation()), p.GetMinAge())
+	if err != nil {
+		return err
+	}
+	if err := insertParts(ctx, tx, p); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Update replaces the stored product, stock included. Use AdjustStock to change the stock of
+// a product that is being sold.
+func (sr *SQLRepository) Update(ctx context.Context, p product.Product) error {
+	tx, err := sr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkSKUs(ctx, tx, p); err != nil {
+		return err
+	}
+	result, err := tx.ExecContext(ctx, `UPDATE products SET name = ?, description = ?, price_amount = ?, price_currency = ?, quantity = ?,
+		tax_class = ?, station = ?, min_age = ? WHERE id = ?`,
+		p.GetItem().Name, p.GetItem().Description, p.GetPrice().GetAmount(), p.GetPrice().GetCurrency(), p.GetQuantity(),
+		string(p.GetTaxClass()), string(p.GetStation()), p.GetMinAge(), p.GetID().String())
+	if err != nil {
+		return err
+	}
+	if updated, err := result.RowsAffected(); err != nil {
+		return err
+	} else if updated == 0 {
+		return product.ErrProductNotFound
+	}
+	if err := deleteParts(ctx, tx, p.GetID()); err != nil {
+		return err
+	}
+	if err := insertParts(ctx, tx, p); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (sr *SQLRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	tx, err := sr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := deleteParts(ctx, tx, id); err != nil {
+		return err
+	}
+	result, err := tx.ExecContext(ctx, `DELETE FROM products WHERE id = ?`, id.String())
+	if err != nil {
+		return err
+	}
+	if deleted, err := result.RowsAffected(); err != nil {
+		return err
+	} else if deleted == 0 {
+		return product.ErrProductNotFound
+	}
+
+	return tx.Commit()
+}
+
+// AdjustStock changes the stock in a single update that only matches while there is enough
+// stock, so concurrent orders can never take the stock below zero
+func (sr *SQLRepository) AdjustStock(ctx context.Context, id uuid.UUID, delta int) (product.Product, error) {
+	tx, err := sr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return product.Product{}, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `UPDATE products SET quantity = quantity + ? WHERE id = ? AND quantity + ? >= 0`, delta, id.String(), delta)
+	if err != nil {
+		return product.Product{}, err
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return product.Product{}, err
+	}
+	p, err := findOne(ctx, tx, selectProducts+` WHERE id = ?`, id.String())
+	if err != nil {
+		return product.Product{}, err
+	}
+	if updated == 0 {
+		return product.Product{}, fmt.Errorf("%s has %d on hand, cannot take %d: %w", p.GetItem().Name, p.GetQuantity(), -delta, product.ErrOutOfStock)
+	}
+
+	return p, tx.Commit()
+}
+
+// checkSKUs fails if another product has a variant with one of the SKUs of p. The unique
+// constraint on the SKU backs it up, but its error differs from driver to driver.
+func checkSKUs(ctx context.Context, tx *sql.Tx, p product.Product) error {
+	variants := p.GetVariants()
+	if len(variants) == 0 {
+		return nil
+	}
+	args := make([]any, 0, len(variants)+1)
+	for _, v := range variants {
+		args = append(args, v.SKU)
+	}
+	args = append(args, p.GetID().String())
+
+	var sku, other string
+	err := tx.QueryRowContext(ctx, `SELECT v.sku, p.name FROM product_variants v JOIN products p ON p.id = v.product_id
+		WHERE v.sku IN (?`+strings.Repeat(", ?", len(variants)-1)+`) AND v.product_id <> ?`, args...).Scan(&sku, &other)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("SKU %q of %s is used by %s: %w", sku, p.GetItem().Name, other, product.ErrVariantExists)
+}
+
+// insertParts stores the variants and the recipe of the product, in the order they were added
+func insertParts(ctx context.Context, tx *sql.Tx, p product.Product) error {
+	id := p.GetID().String()
+	for i, v := range p.GetVariants() {
+		_, err := tx.ExecContext(ctx, `INSERT INTO product_variants (product_id, position, sku, size, price_amount, price_currency, draw)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			id, i, v.SKU, v.Size, v.Price.GetAmount(), v.Price.GetCurrency(), v.Draw)
+		if err != nil {
+			return err
+		}
+	}
+	for i, in := range p.GetRecipe() {
+		_, err := tx.ExecContext(ctx, `INSERT INTO product_ingredients (product_id, position, ingredient_id, amount) VALUES (?, ?, ?, ?)`,
+			id, i, in.ProductID.String(), in.Amount)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func deleteParts(ctx context.Context, tx *sql.Tx, id uuid.UUID) error {
+	for _, table := range []string{"product_variants", "product_ingredients"} {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE product_id = ?`, id.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// toAggregate rebuilds the product with its variants and recipe, it fails on rows the product
+// rules do not allow
+func toAggregate(ctx context.Context, q querier, r row) (product.Product, error) {
+	id, err := uuid.Parse(r.id)
+	if err != nil {
+		return product.Product{}, err
+	}
+	price, err := domain.NewMoney(r.price, r.currency)
+	if err != nil {
+		return product.Product{}, fmt.Errorf("product %s: %w", id, err)
+	}
+	p, err := product.NewProduct(r.name, r.description, price, r.quantity)
+	if err != nil {
+		return product.Product{}, fmt.Errorf("product %s: %w", id, err)
+	}
+	p.SetID(id)
+	if err := p.SetTaxClass(product.TaxClass(r.taxClass)); err != nil {
+		return product.Product{}, fmt.Errorf("product %s: %w", id, err)
+	}
+	if err := p.SetStation(product.Station(r.station)); err != nil {
+		return product.Product{}, fmt.Errorf("product %s: %w", id, err)
+	}
+	if err := p.SetMinAge(r.minAge); err != nil {
+		return product.Product{}, fmt.Errorf("product %s: %w", id, err)
+	}
+	if err := loadVariants(ctx, q, &p); err != nil {
+		return product.Product{}, fmt.Errorf("product %s: %w", id, err)
+	}
+	if err := loadRecipe(ctx, q, &p); err != nil {
+		return product.Product{}, fmt.Errorf("product %s: %w", id, err)
+	}
+
+	return p, nil
+}
+
+func loadVariants(ctx context.Context, q querier, p *product.Product) error {
+	rows, err := q.QueryContext(ctx, `SELECT sku, size, price_amount, price_currency, draw FROM product_variants
+		WHERE product_id = ? ORDER BY position`, p.GetID().String())
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			v        product.Variant
+			amount   int64
+			currency string
+		)
+		if err := rows.Scan(&v.SKU, &v.Size, &amount, &currency, &v.Draw); err != nil {
+			return err
+		}
+		if v.Price, err = domain.NewMoney(amount, currency); err != nil {
+			return err
+		}
+		if err := p.AddVariant(v); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func loadRecipe(ctx context.Context, q querier, p *product.Product) error {
+	rows, err := q.QueryContext(ctx, `SELECT ingredient_id, amount FROM product_ingredients
+		WHERE product_id = ? ORDER BY position`, p.GetID().String())
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var recipe []product.Ingredient
+	for rows.Next() {
+		var (
+			id string
+			in product.Ingredient
+		)
+		if err := rows.Scan(&id, &in.Amount); err != nil {
+			return err
+		}
+		if in.ProductID, err = uuid.Parse(id); err != nil {
+			return err
+		}
+		recipe = append(recipe, in)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(recipe) == 0 {
+		return nil
+	}
+	return p.SetRecipe(recipe...)
+}
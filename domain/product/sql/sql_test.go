@@ -0,0 +1,189 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/devsrivatsa/tavernDDD/domain"
+	"github.com/devsrivatsa/tavernDDD/domain/product"
+	"github.com/google/uuid"
+	_ "modernc.org/sqlite"
+)
+
+// setupTestRepo returns a repository on a fresh SQLite database in a temporary file
+func setupTestRepo(t *testing.T) *SQLRepository {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "tavern.db")+"?_pragma=busy_timeout(5000)&_txlock=immediate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	repo, err := New(context.Background(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repo
+}
+
+func newBeer(t *testing.T, name, sku string) product.Product {
+	beer, err := product.NewProduct(name, "A refreshing beer", domain.MustNewMoney(199, "EUR"), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := beer.SetTaxClass(product.TaxClassAlcohol); err != nil {
+		t.Fatal(err)
+	}
+	if err := beer.SetMinAge(18); err != nil {
+		t.Fatal(err)
+	}
+	if err := beer.AddVariant(product.Variant{SKU: sku, Size: "pint", Price: domain.MustNewMoney(450, "EUR"), Draw: 2}); err != nil {
+		t.Fatal(err)
+	}
+	return beer
+}
+
+func TestSQLRepository_RoundTrip(t *testing.T) {
+	repo := setupTestRepo(t)
+	beer := newBeer(t, "Lager", "LAGER-PINT")
+	cocktail, err := product.NewProduct("Gin Tonic", "Gin with tonic", domain.MustNewMoney(850, "EUR"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cocktail.SetRecipe(product.Ingredient{ProductID: beer.GetID(), Amount: 4}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cocktail.SetStation(product.StationKitchen); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []product.Product{beer, cocktail} {
+		if err := repo.Add(context.Background(), p); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	all, err := repo.GetAll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 || all[0].GetItem().Name != "Gin Tonic" || all[1].GetItem().Name != "Lager" {
+		t.Fatalf("expected Gin Tonic and Lager, got %d products", len(all))
+	}
+	for i, p := range []product.Product{cocktail, beer} {
+		got := all[i]
+		if got.GetID() != p.GetID() || *got.GetItem() != *p.GetItem() || !got.GetPrice().Equals(p.GetPrice()) || got.GetQuantity() != p.GetQuantity() {
+			t.Errorf("expected %+v, got %+v", *p.GetItem(), *got.GetItem())
+		}
+		if got.GetTaxClass() != p.GetTaxClass() || got.GetStation() != p.GetStation() || got.GetMinAge() != p.GetMinAge() {
+			t.Errorf("expected %s, %s and %d, got %s, %s and %d", p.GetTaxClass(), p.GetStation(), p.GetMinAge(), got.GetTaxClass(), got.GetStation(), got.GetMinAge())
+		}
+		if len(got.GetVariants()) != len(p.GetVariants()) || len(got.GetRecipe()) != len(p.GetRecipe()) {
+			t.Errorf("expected %d variants and %d ingredients, got %d and %d", len(p.GetVariants()), len(p.GetRecipe()), len(got.GetVariants()), len(got.GetRecipe()))
+		}
+	}
+
+	bySKU, err := repo.GetBySKU(context.Background(), "LAGER-PINT")
+	if err != nil || bySKU.GetID() != beer.GetID() {
+		t.Errorf("expected %s by SKU, got %v", beer.GetID(), err)
+	}
+	if err := repo.Delete(context.Background(), beer.GetID()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.GetBySKU(context.Background(), "LAGER-PINT"); !errors.Is(err, product.ErrProductNotFound) {
+		t.Errorf("expected error %v, got %v", product.ErrProductNotFound, err)
+	}
+}
+
+func TestSQLRepository_Errors(t *testing.T) {
+	repo := setupTestRepo(t)
+	lager := newBeer(t, "Lager", "PINT")
+	if err := repo.Add(context.Background(), lager); err != nil {
+		t.Fatal(err)
+	}
+
+	type testCase struct {
+		name          string
+		do            func() error
+		expectedError error
+	}
+	testCases := []testCase{
+		{
+			name:          "add twice",
+			do:            func() error { return repo.Add(context.Background(), lager) },
+			expectedError: product.ErrProductAlreadyExists,
+		},
+		{
+			name:          "add a SKU in use",
+			do:            func() error { return repo.Add(context.Background(), newBeer(t, "Stout", "PINT")) },
+			expectedError: product.ErrVariantExists,
+		},
+		{
+			name:          "update unknown product",
+			do:            func() error { return repo.Update(context.Background(), newBeer(t, "Porter", "PORTER-PINT")) },
+			expectedError: product.ErrProductNotFound,
+		},
+		{
+			name:          "delete unknown product",
+			do:            func() error { return repo.Delete(context.Background(), uuid.New()) },
+			expectedError: product.ErrProductNotFound,
+		},
+		{
+			name: "adjust unknown product",
+			do: func() error {
+				_, err := repo.AdjustStock(context.Background(), uuid.New(), 1)
+				return err
+			},
+			expectedError: product.ErrProductNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.do(); !errors.Is(err, tc.expectedError) {
+				t.Errorf("expected error %v, got %v", tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestSQLRepository_AdjustStock(t *testing.T) {
+	repo := setupTestRepo(t)
+	beer := newBeer(t, "Lager", "PINT")
+	if err := repo.Add(context.Background(), beer); err != nil {
+		t.Fatal(err)
+	}
+
+	// 20 bartenders pour at once, only 10 can get a beer
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	outOfStock := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := repo.AdjustStock(context.Background(), beer.GetID(), -1)
+			if errors.Is(err, product.ErrOutOfStock) {
+				mu.Lock()
+				outOfStock++
+				mu.Unlock()
+			} else if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if outOfStock != 10 {
+		t.Errorf("expected 10 orders to run out of stock, got %d", outOfStock)
+	}
+	stored, err := repo.GetByID(context.Background(), beer.GetID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.GetQuantity() != 0 {
+		t.Errorf("expected no stock left, got %d", stored.GetQuantity())
+	}
+}
@@ -0,0 +1,72 @@
+package promotion
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// During holds between two times of day, given as the time since midnight.
+// A window ending before it starts runs past midnight, e.g. During(22*time.Hour, 2*time.Hour).
+func During(from, to time.Duration) Condition {
+	return func(cart Cart) bool {
+		// the clock time rather than the time since midnight, which is an hour off when the clocks change
+		at := cart.At
+		now := time.Duration(at.Hour())*time.Hour + time.Duration(at.Minute())*time.Minute + time.Duration(at.Second())*time.Second
+		if from <= to {
+			return now >= from && now < to
+		}
+		return now >= from || now < to
+	}
+}
+
+// OnWeekdays holds on the given days of the week
+func OnWeekdays(days ...time.Weekday) Condition {
+	return func(cart Cart) bool {
+		for _, d := range days {
+			if cart.At.Weekday() == d {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// ForCustomers holds when one of the given customers orders
+func ForCustomers(customerIDs ...uuid.UUID) Condition {
+	return func(cart Cart) bool {
+		for _, id := range customerIDs {
+			if cart.CustomerID == id {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// MinQuantity holds when at least n units of the given products are ordered together.
+// Without products every product in the cart counts.
+func MinQuantity(n int, productIDs ...uuid.UUID) Condition {
+	return func(cart Cart) bool {
+		count := 0
+		for _, l := range cart.Lines {
+			if inSet(productIDs, l.ProductID) {
+				count += l.Quantity
+			}
+		}
+		return count >= n
+	}
+}
+
+// inSet reports whether id is one of ids, an empty set holds every product
+func inSet(ids []uuid.UUID, id uuid.UUID) bool {
+	if len(ids) == 0 {
+		return true
+	}
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package promotion
+
+import (
+	"github.com/devsrivatsa/tavernDDD/domain"
+)
+
+// Engine runs a set of promotions against carts. Promotions are applied in the order they were
+// added and never take more off a line than is left to pay for it.
+type Engine struct {
+	promotions []Promotion
+}
+
+func NewEngine(promotions ...Promotion) *Engine {
+	return &Engine{
+		promotions: append([]Promotion(nil), promotions...),
+	}
+}
+
+func (e *Engine) Apply(cart Cart) ([]Discount, error) {
+	remaining := make(map[LineKey]domain.Money)
+	for _, l := range cart.Lines {
+		remaining[l.Key()] = l.total()
+	}
+
+	var discounts []Discount
+	for _, p := range e.promotions {
+		applied, err := p.Apply(cart)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range applied {
+			key := LineKey{ProductID: d.ProductID, SKU: d.SKU}
+			left := remaining[key]
+			if tooMuch, err := d.Amount.GreaterThan(left); err != nil {
+				return nil, err
+			} else if tooMuch {
+				d.Amount = left
+			}
+			if !d.Amount.IsPositive() {
+				continue
+			}
+			left, err = left.Sub(d.Amount)
+			if err != nil {
+				return nil, err
+			}
+			remaining[key] = left
+			discounts = append(discounts, d)
+		}
+	}
+
+	return discounts, nil
+}
@@ -0,0 +1,121 @@
+package promotion
+
+import (
+	"errors"
+	"time"
+
+	"github.com/devsrivatsa/tavernDDD/domain"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrMissingName   = errors.New("a promotion needs a name")
+	ErrMissingReward = errors.New("a promotion needs a reward")
+)
+
+// Cart is what promotions are worked out against: who orders what and when
+type Cart struct {
+	CustomerID uuid.UUID
+	At         time.Time
+	Lines      []CartLine
+}
+
+// CartLine is a product, or one of its variants when SKU is set, in the cart
+type CartLine struct {
+	ProductID uuid.UUID
+	SKU       string
+	Quantity  int
+	UnitPrice domain.Money
+}
+
+// LineKey identifies a cart line, a cart has at most one line per product variant
+type LineKey struct {
+	ProductID uuid.UUID
+	SKU       string
+}
+
+func (l CartLine) Key() LineKey {
+	return LineKey{ProductID: l.ProductID, SKU: l.SKU}
+}
+
+func (l CartLine) total() domain.Money {
+	return l.UnitPrice.Multiply(int64(l.Quantity))
+}
+
+// Discount is a value object itemising what a promotion took off a cart line
+type Discount struct {
+	PromotionID uuid.UUID
+	Name        string
+	ProductID   uuid.UUID
+	SKU         string
+	Amount      domain.Money
+}
+
+// Condition decides whether a promotion applies to a cart
+type Condition func(cart Cart) bool
+
+// Reward works out how much to take off each cart line
+type Reward func(cart Cart) (map[LineKey]domain.Money, error)
+
+// Promotion is an entity that combines the conditions under which it applies with its reward
+type Promotion struct {
+	id         uuid.UUID
+	name       string
+	conditions []Condition
+	reward     Reward
+}
+
+// factory function to create a new promotion, it applies when all conditions hold
+func NewPromotion(name string, reward Reward, conditions ...Condition) (Promotion, error) {
+	if name == "" {
+		return Promotion{}, ErrMissingName
+	}
+	if reward == nil {
+		return Promotion{}, ErrMissingReward
+	}
+
+	return Promotion{
+		id:         uuid.New(),
+		name:       name,
+		conditions: append([]Condition(nil), conditions...),
+		reward:     reward,
+	}, nil
+}
+
+func (p Promotion) GetID() uuid.UUID {
+	return p.id
+}
+
+func (p Promotion) GetName() string {
+	return p.name
+}
+
+// Apply returns the discounts the promotion gives on the cart, none if it does not apply
+func (p Promotion) Apply(cart Cart) ([]Discount, error) {
+	for _, holds := range p.conditions {
+		if !holds(cart) {
+			return nil, nil
+		}
+	}
+	amounts, err := p.reward(cart)
+	if err != nil {
+		return nil, err
+	}
+
+	var discounts []Discount
+	for _, l := range cart.Lines {
+		amount, ok := amounts[l.Key()]
+		if !ok || !amount.IsPositive() {
+			continue
+		}
+		discounts = append(discounts, Discount{
+			PromotionID: p.id,
+			Name:        p.name,
+			ProductID:   l.ProductID,
+			SKU:         l.SKU,
+			Amount:      amount,
+		})
+	}
+
+	return discounts, nil
+}
@@ -0,0 +1,227 @@
+package promotion_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/devsrivatsa/tavernDDD/domain"
+	"github.com/devsrivatsa/tavernDDD/domain/promotion"
+	"github.com/google/uuid"
+)
+
+var (
+	beer   = uuid.New()
+	wine   = uuid.New()
+	burger = uuid.New()
+)
+
+func cart(at time.Time, customerID uuid.UUID) promotion.Cart {
+	return promotion.Cart{
+		CustomerID: customerID,
+		At:         at,
+		Lines: []promotion.CartLine{
+			{ProductID: beer, Quantity: 3, UnitPrice: domain.MustNewMoney(400, "EUR")},
+			{ProductID: wine, Quantity: 1, UnitPrice: domain.MustNewMoney(600, "EUR")},
+			{ProductID: burger, Quantity: 2, UnitPrice: domain.MustNewMoney(1000, "EUR")},
+		},
+	}
+}
+
+func discountsByProduct(discounts []promotion.Discount) map[uuid.UUID]int64 {
+	amounts := make(map[uuid.UUID]int64)
+	for _, d := range discounts {
+		amounts[d.ProductID] += d.Amount.GetAmount()
+	}
+	return amounts
+}
+
+func TestPromotion_NewPromotion(t *testing.T) {
+	reward, err := promotion.PercentOff(1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := promotion.NewPromotion("", reward); !errors.Is(err, promotion.ErrMissingName) {
+		t.Errorf("expected error %v, got %v", promotion.ErrMissingName, err)
+	}
+	if _, err := promotion.NewPromotion("Happy hour", nil); !errors.Is(err, promotion.ErrMissingReward) {
+		t.Errorf("expected error %v, got %v", promotion.ErrMissingReward, err)
+	}
+	if _, err := promotion.PercentOff(0); !errors.Is(err, promotion.ErrInvalidReward) {
+		t.Errorf("expected error %v, got %v", promotion.ErrInvalidReward, err)
+	}
+}
+
+func TestPromotion_Rules(t *testing.T) {
+	regular := uuid.New()
+	friday := time.Date(2025, time.June, 6, 17, 30, 0, 0, time.UTC)
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	halfPrice, err := promotion.PercentOff(5000, beer, wine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	twoForOne, err := promotion.BuyXGetYFree(1, 1, beer, wine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	burgerAndPint, err := promotion.BundlePrice(domain.MustNewMoney(1200, "EUR"), burger, beer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	twoBurgersAndPint, err := promotion.BundlePrice(domain.MustNewMoney(2000, "EUR"), burger, burger, beer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	threeBurgers, err := promotion.BundlePrice(domain.MustNewMoney(2500, "EUR"), burger, burger, burger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type testCase struct {
+		test       string
+		reward     promotion.Reward
+		conditions []promotion.Condition
+		at         time.Time
+		customerID uuid.UUID
+		expected   map[uuid.UUID]int64
+	}
+
+	testcases := []testCase{
+		{
+			test:       "Happy hour",
+			reward:     halfPrice,
+			conditions: []promotion.Condition{promotion.During(17*time.Hour, 19*time.Hour)},
+			at:         friday,
+			expected:   map[uuid.UUID]int64{beer: 600, wine: 300},
+		},
+		{
+			test:       "Outside happy hour",
+			reward:     halfPrice,
+			conditions: []promotion.Condition{promotion.During(17*time.Hour, 19*time.Hour)},
+			at:         friday.Add(2 * time.Hour),
+			expected:   map[uuid.UUID]int64{},
+		},
+		{
+			test:       "Late window past midnight",
+			reward:     halfPrice,
+			conditions: []promotion.Condition{promotion.During(22*time.Hour, 2*time.Hour)},
+			at:         friday.Add(8 * time.Hour),
+			expected:   map[uuid.UUID]int64{beer: 600, wine: 300},
+		},
+		{
+			test:       "Happy hour the day the clocks go back",
+			reward:     halfPrice,
+			conditions: []promotion.Condition{promotion.During(17*time.Hour, 19*time.Hour)},
+			at:         time.Date(2025, time.October, 26, 18, 30, 0, 0, berlin),
+			expected:   map[uuid.UUID]int64{beer: 600, wine: 300},
+		},
+		{
+			test:       "2-for-1 night gives the cheapest drinks away",
+			reward:     twoForOne,
+			conditions: []promotion.Condition{promotion.OnWeekdays(time.Friday)},
+			at:         friday,
+			expected:   map[uuid.UUID]int64{beer: 800},
+		},
+		{
+			test:       "2-for-1 on the wrong night",
+			reward:     twoForOne,
+			conditions: []promotion.Condition{promotion.OnWeekdays(time.Monday)},
+			at:         friday,
+			expected:   map[uuid.UUID]int64{},
+		},
+		{
+			test:     "Burger and pint, twice",
+			reward:   burgerAndPint,
+			at:       friday,
+			expected: map[uuid.UUID]int64{burger: 286, beer: 114},
+		},
+		{
+			test:     "Two burgers and a pint, once",
+			reward:   twoBurgersAndPint,
+			at:       friday,
+			expected: map[uuid.UUID]int64{burger: 334, beer: 66},
+		},
+		{
+			test:     "Three burgers, not enough of them",
+			reward:   threeBurgers,
+			at:       friday,
+			expected: map[uuid.UUID]int64{},
+		},
+		{
+			test:       "Regulars only",
+			reward:     halfPrice,
+			conditions: []promotion.Condition{promotion.ForCustomers(regular)},
+			at:         friday,
+			customerID: regular,
+			expected:   map[uuid.UUID]int64{beer: 600, wine: 300},
+		},
+		{
+			test:       "Not a regular",
+			reward:     halfPrice,
+			conditions: []promotion.Condition{promotion.ForCustomers(regular)},
+			at:         friday,
+			customerID: uuid.New(),
+			expected:   map[uuid.UUID]int64{},
+		},
+		{
+			test:       "Quantity threshold not met",
+			reward:     halfPrice,
+			conditions: []promotion.Condition{promotion.MinQuantity(5, beer, wine)},
+			at:         friday,
+			expected:   map[uuid.UUID]int64{},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.test, func(t *testing.T) {
+			p, err := promotion.NewPromotion(tc.test, tc.reward, tc.conditions...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			discounts, err := p.Apply(cart(tc.at, tc.customerID))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := discountsByProduct(discounts)
+			if len(got) != len(tc.expected) {
+				t.Fatalf("expected discounts %v, got %v", tc.expected, got)
+			}
+			for id, amount := range tc.expected {
+				if got[id] != amount {
+					t.Errorf("expected discounts %v, got %v", tc.expected, got)
+				}
+			}
+		})
+	}
+}
+
+func TestEngine_NeverDiscountsMoreThanTheLine(t *testing.T) {
+	free, err := promotion.PercentOff(10000, wine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	halfPrice, err := promotion.PercentOff(5000, wine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	onTheHouse, err := promotion.NewPromotion("On the house", free)
+	if err != nil {
+		t.Fatal(err)
+	}
+	happyHour, err := promotion.NewPromotion("Happy hour", halfPrice)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	discounts, err := promotion.NewEngine(onTheHouse, happyHour).Apply(cart(time.Now(), uuid.Nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(discounts) != 1 || discounts[0].Name != "On the house" || discounts[0].Amount.GetAmount() != 600 {
+		t.Errorf("expected only the wine on the house, got %+v", discounts)
+	}
+}
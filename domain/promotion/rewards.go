@@ -0,0 +1,139 @@
+package promotion
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/devsrivatsa/tavernDDD/domain"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidReward = errors.New("invalid promotion reward")
+)
+
+// PercentOff takes basisPoints (2500 is 25%) off the given products, or off everything without products
+func PercentOff(basisPoints int64, productIDs ...uuid.UUID) (Reward, error) {
+	if basisPoints <= 0 || basisPoints > 10000 {
+		return nil, ErrInvalidReward
+	}
+
+	return func(cart Cart) (map[LineKey]domain.Money, error) {
+		amounts := make(map[LineKey]domain.Money)
+		for _, l := range cart.Lines {
+			if inSet(productIDs, l.ProductID) {
+				amounts[l.Key()] = l.total().MultiplyFraction(basisPoints, 10000)
+			}
+		}
+		return amounts, nil
+	}, nil
+}
+
+// BuyXGetYFree gives y units free for every x+y units of the given products ordered.
+// The units are pooled over the products and the cheapest ones are given away, so
+// BuyXGetYFree(1, 1) is a 2-for-1.
+func BuyXGetYFree(x, y int, productIDs ...uuid.UUID) (Reward, error) {
+	if x <= 0 || y <= 0 {
+		return nil, ErrInvalidReward
+	}
+
+	return func(cart Cart) (map[LineKey]domain.Money, error) {
+		var lines []CartLine
+		units := 0
+		for _, l := range cart.Lines {
+			if inSet(productIDs, l.ProductID) {
+				lines = append(lines, l)
+				units += l.Quantity
+			}
+		}
+		// most expensive first, the free units come off the cheap end
+		sort.SliceStable(lines, func(i, j int) bool {
+			return lines[i].UnitPrice.GetAmount() > lines[j].UnitPrice.GetAmount()
+		})
+
+		free := units / (x + y) * y
+		amounts := make(map[LineKey]domain.Money)
+		for i := len(lines) - 1; i >= 0 && free > 0; i-- {
+			n := min(lines[i].Quantity, free)
+			amounts[lines[i].Key()] = lines[i].UnitPrice.Multiply(int64(n))
+			free -= n
+		}
+		return amounts, nil
+	}, nil
+}
+
+// BundlePrice sells one of each of the given products together for price, e.g. a burger and
+// a pint for 12.00. A product given more than once is needed that many times in the bundle, e.g.
+// two burgers and a pint. The saving is spread over the products in proportion to their prices.
+// When a product is in the cart in several variants, the first one ordered makes the bundle.
+func BundlePrice(price domain.Money, productIDs ...uuid.UUID) (Reward, error) {
+	if len(productIDs) < 2 || price.IsNegative() || price.GetCurrency() == "" {
+		return nil, ErrInvalidReward
+	}
+	needed := make(map[uuid.UUID]int)
+	for _, id := range productIDs {
+		needed[id]++
+	}
+
+	return func(cart Cart) (map[LineKey]domain.Money, error) {
+		bundles := -1
+		prices := make([]domain.Money, len(productIDs))
+		keys := make([]LineKey, len(productIDs))
+		for i, id := range productIDs {
+			found := false
+			for _, l := range cart.Lines {
+				if l.ProductID == id {
+					found = true
+					prices[i] = l.UnitPrice
+					keys[i] = l.Key()
+					if n := l.Quantity / needed[id]; bundles < 0 || n < bundles {
+						bundles = n
+					}
+					break
+				}
+			}
+			if !found {
+				return nil, nil
+			}
+		}
+		if bundles == 0 {
+			return nil, nil
+		}
+
+		full := prices[0]
+		ratios := []int{int(prices[0].GetAmount())}
+		for _, p := range prices[1:] {
+			var err error
+			full, err = full.Add(p)
+			if err != nil {
+				return nil, err
+			}
+			ratios = append(ratios, int(p.GetAmount()))
+		}
+		saving, err := full.Sub(price)
+		if err != nil {
+			return nil, err
+		}
+		if !saving.IsPositive() {
+			return nil, nil
+		}
+		shares, err := saving.Multiply(int64(bundles)).Allocate(ratios...)
+		if err != nil {
+			return nil, err
+		}
+
+		// a product needed several times gets the shares of all its places in the bundle
+		amounts := make(map[LineKey]domain.Money)
+		for i, key := range keys {
+			amount, ok := amounts[key]
+			if !ok {
+				amounts[key] = shares[i]
+				continue
+			}
+			if amounts[key], err = amount.Add(shares[i]); err != nil {
+				return nil, err
+			}
+		}
+		return amounts, nil
+	}, nil
+}
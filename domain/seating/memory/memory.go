@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/devsrivatsa/tavernDDD/domain/seating"
+	"github.com/google/uuid"
+)
+
+type MemoryTableRepository struct {
+	tables map[uuid.UUID]seating.Table
+	sync.Mutex
+}
+
+func New() *MemoryTableRepository {
+	return &MemoryTableRepository{
+		tables: make(map[uuid.UUID]seating.Table),
+	}
+}
+
+func (m *MemoryTableRepository) Get(ctx context.Context, id uuid.UUID) (seating.Table, error) {
+	if err := ctx.Err(); err != nil {
+		return seating.Table{}, err
+	}
+	m.Lock()
+	defer m.Unlock()
+
+	if t, ok := m.tables[id]; ok {
+		return t, nil
+	}
+
+	return seating.Table{}, seating.ErrTableNotFound
+}
+
+// GetAll returns every table sorted by name
+func (m *MemoryTableRepository) GetAll(ctx context.Context) ([]seating.Table, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.Lock()
+	defer m.Unlock()
+
+	tables := make([]seating.Table, 0, len(m.tables))
+	for _, t := range m.tables {
+		tables = append(tables, t)
+	}
+	sort.Slice(tables, func(i, j int) bool {
+		return tables[i].GetName() < tables[j].GetName()
+	})
+
+	return tables, nil
+}
+
+func (m *MemoryTableRepository) Add(ctx context.Context, t seating.Table) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.Lock()
+	defer m.Unlock()
+
+	if _, ok := m.tables[t.GetID()]; ok {
+		return fmt.Errorf("error adding table %s: %w", t.GetName(), seating.ErrTableAlreadyExists)
+	}
+	m.tables[t.GetID()] = t
+
+	return nil
+}
+
+func (m *MemoryTableRepository) Update(ctx context.Context, t seating.Table) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.Lock()
+	defer m.Unlock()
+
+	if _, ok := m.tables[t.GetID()]; !ok {
+		return fmt.Errorf("error updating table %s: %w", t.GetName(), seating.ErrTableNotFound)
+	}
+	m.tables[t.GetID()] = t
+
+	return nil
+}
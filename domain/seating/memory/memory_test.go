@@ -0,0 +1,58 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/devsrivatsa/tavernDDD/domain/seating"
+	"github.com/google/uuid"
+)
+
+func TestMemoryTableRepository(t *testing.T) {
+	repo := New()
+	for _, name := range []string{"T2", "T1"} {
+		table, err := seating.NewTable(name, 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.Add(context.Background(), table); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tables, err := repo.GetAll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 2 || tables[0].GetName() != "T1" {
+		t.Fatalf("expected T1 and T2, got %v", tables)
+	}
+	if err := repo.Add(context.Background(), tables[0]); !errors.Is(err, seating.ErrTableAlreadyExists) {
+		t.Errorf("expected error %v, got %v", seating.ErrTableAlreadyExists, err)
+	}
+
+	table := tables[0]
+	if err := table.SeatWalkIn(uuid.New(), 2, time.Now(), time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Update(context.Background(), table); err != nil {
+		t.Fatal(err)
+	}
+	got, err := repo.Get(context.Background(), table.GetID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.GetStatus() != seating.StatusOccupied {
+		t.Errorf("expected status %s, got %s", seating.StatusOccupied, got.GetStatus())
+	}
+
+	if _, err := repo.Get(context.Background(), uuid.New()); !errors.Is(err, seating.ErrTableNotFound) {
+		t.Errorf("expected error %v, got %v", seating.ErrTableNotFound, err)
+	}
+	unknown, _ := seating.NewTable("T3", 2)
+	if err := repo.Update(context.Background(), unknown); !errors.Is(err, seating.ErrTableNotFound) {
+		t.Errorf("expected error %v, got %v", seating.ErrTableNotFound, err)
+	}
+}
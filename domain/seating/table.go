@@ -0,0 +1,279 @@
+package seating
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrMissingName           = errors.New("a table needs a name")
+	ErrInvalidCapacity       = errors.New("a table seats at least one guest")
+	ErrInvalidReservation    = errors.New("a reservation needs a guest, a party size and a time slot that ends after it starts")
+	ErrPartyTooLarge         = errors.New("the party does not fit at the table")
+	ErrReservationConflict   = errors.New("the table is reserved at that time")
+	ErrReservationNotFound   = errors.New("the table has no such reservation")
+	ErrTableNotFree          = errors.New("the table is not free")
+	ErrTableNotOccupied      = errors.New("nobody is seated at the table")
+	ErrTableNotBeingCleaned  = errors.New("the table is not being cleaned")
+	ErrReservationNotStarted = errors.New("the reservation has not started yet")
+)
+
+type Status string
+
+const (
+	StatusFree     Status = "free"
+	StatusOccupied Status = "occupied"
+	// StatusCleaning is a table that was cleared and cannot be seated yet
+	StatusCleaning Status = "cleaning"
+)
+
+// Reservation is a value object holding a table for a party during a time slot
+type Reservation struct {
+	ID uuid.UUID
+	// CustomerID is empty when the guest is not a customer yet
+	CustomerID uuid.UUID
+	Name       string
+	PartySize  int
+	From       time.Time
+	To         time.Time
+}
+
+// factory function to create a new reservation from one time to another
+func NewReservation(name string, customerID uuid.UUID, partySize int, from, to time.Time) (Reservation, error) {
+	if (name == "" && customerID == uuid.Nil) || partySize <= 0 || !to.After(from) {
+		return Reservation{}, ErrInvalidReservation
+	}
+
+	return Reservation{
+		ID:         uuid.New(),
+		CustomerID: customerID,
+		Name:       name,
+		PartySize:  partySize,
+		From:       from,
+		To:         to,
+	}, nil
+}
+
+// Overlaps reports whether the reservation takes up part of the slot from one time to another
+func (r Reservation) Overlaps(from, to time.Time) bool {
+	return r.From.Before(to) && from.Before(r.To)
+}
+
+// Table is an entity for a table of the tavern, its reservations and the party seated at it
+type Table struct {
+	//id is the root entity identifier of the table aggregate
+	id       uuid.UUID
+	name     string
+	capacity int
+	status   Status
+	//reservations that are still to come, sorted by start
+	reservations []Reservation
+	//the current party, set while the table is occupied
+	partySize int
+	hostID    uuid.UUID
+	seatedAt  time.Time
+	orderIDs  []uuid.UUID
+}
+
+// factory function to create a new free table
+func NewTable(name string, capacity int) (Table, error) {
+	if name == "" {
+		return Table{}, ErrMissingName
+	}
+	if capacity <= 0 {
+		return Table{}, ErrInvalidCapacity
+	}
+
+	return Table{
+		id:           uuid.New(),
+		name:         name,
+		capacity:     capacity,
+		status:       StatusFree,
+		reservations: make([]Reservation, 0),
+	}, nil
+}
+
+func (t Table) GetID() uuid.UUID {
+	return t.id
+}
+
+func (t Table) GetName() string {
+	return t.name
+}
+
+func (t Table) GetCapacity() int {
+	return t.capacity
+}
+
+func (t Table) GetStatus() Status {
+	return t.status
+}
+
+// GetReservations returns a copy of the reservations still to come, earliest first
+func (t Table) GetReservations() []Reservation {
+	return append([]Reservation(nil), t.reservations...)
+}
+
+func (t Table) GetPartySize() int {
+	return t.partySize
+}
+
+// GetHostID returns the customer who pays for the party seated at the table
+func (t Table) GetHostID() uuid.UUID {
+	return t.hostID
+}
+
+func (t Table) GetSeatedAt() time.Time {
+	return t.seatedAt
+}
+
+// GetOrderIDs returns a copy of the IDs of the orders placed by the party seated at the table
+func (t Table) GetOrderIDs() []uuid.UUID {
+	return append([]uuid.UUID(nil), t.orderIDs...)
+}
+
+// IsFreeBetween reports whether no reservation takes up part of the slot
+func (t Table) IsFreeBetween(from, to time.Time) bool {
+	for _, r := range t.reservations {
+		if r.Overlaps(from, to) {
+			return false
+		}
+	}
+	return true
+}
+
+// Reserve holds the table for the reservation, it fails if the slot overlaps another reservation
+func (t *Table) Reserve(r Reservation) error {
+	if r.ID == uuid.Nil || r.PartySize <= 0 || !r.To.After(r.From) {
+		return ErrInvalidReservation
+	}
+	if r.PartySize > t.capacity {
+		return fmt.Errorf("party of %d at table %s for %d: %w", r.PartySize, t.name, t.capacity, ErrPartyTooLarge)
+	}
+	if !t.IsFreeBetween(r.From, r.To) {
+		return fmt.Errorf("table %s from %s to %s: %w", t.name, r.From.Format(time.Kitchen), r.To.Format(time.Kitchen), ErrReservationConflict)
+	}
+
+	reservations := append(t.GetReservations(), r)
+	sort.Slice(reservations, func(i, j int) bool {
+		return reservations[i].From.Before(reservations[j].From)
+	})
+	t.reservations = reservations
+
+	return nil
+}
+
+func (t *Table) CancelReservation(id uuid.UUID) error {
+	if _, err := t.reservation(id); err != nil {
+		return err
+	}
+	t.reservations = t.without(id)
+
+	return nil
+}
+
+// SeatWalkIn seats a party without a reservation, who expect to stay until the given time
+func (t *Table) SeatWalkIn(hostID uuid.UUID, partySize int, at, until time.Time) error {
+	if err := t.checkSeat(partySize); err != nil {
+		return err
+	}
+	if !t.IsFreeBetween(at, until) {
+		return fmt.Errorf("table %s before %s: %w", t.name, until.Format(time.Kitchen), ErrReservationConflict)
+	}
+	t.seat(hostID, partySize, at)
+
+	return nil
+}
+
+// SeatReservation seats the party of a reservation, at most an hour early, and takes the reservation off the table
+func (t *Table) SeatReservation(id, hostID uuid.UUID, at time.Time) error {
+	r, err := t.reservation(id)
+	if err != nil {
+		return err
+	}
+	if err := t.checkSeat(r.PartySize); err != nil {
+		return err
+	}
+	if at.Before(r.From.Add(-time.Hour)) {
+		return fmt.Errorf("reservation of %s at %s: %w", r.Name, r.From.Format(time.Kitchen), ErrReservationNotStarted)
+	}
+	t.reservations = t.without(id)
+	t.seat(hostID, r.PartySize, at)
+
+	return nil
+}
+
+// AddOrder attaches an order of the seated party to the table
+func (t *Table) AddOrder(orderID uuid.UUID) error {
+	if t.status != StatusOccupied {
+		return fmt.Errorf("table %s is %s: %w", t.name, t.status, ErrTableNotOccupied)
+	}
+	t.orderIDs = append(t.orderIDs[:len(t.orderIDs):len(t.orderIDs)], orderID)
+
+	return nil
+}
+
+// Clear sees the party off, the table needs cleaning before it can be seated again
+func (t *Table) Clear() error {
+	if t.status != StatusOccupied {
+		return fmt.Errorf("table %s is %s: %w", t.name, t.status, ErrTableNotOccupied)
+	}
+	t.status = StatusCleaning
+	t.partySize = 0
+	t.hostID = uuid.Nil
+	t.seatedAt = time.Time{}
+	t.orderIDs = nil
+
+	return nil
+}
+
+// MarkClean makes a cleared table free again
+func (t *Table) MarkClean() error {
+	if t.status != StatusCleaning {
+		return fmt.Errorf("table %s is %s: %w", t.name, t.status, ErrTableNotBeingCleaned)
+	}
+	t.status = StatusFree
+
+	return nil
+}
+
+func (t Table) checkSeat(partySize int) error {
+	if t.status != StatusFree {
+		return fmt.Errorf("table %s is %s: %w", t.name, t.status, ErrTableNotFree)
+	}
+	if partySize <= 0 || partySize > t.capacity {
+		return fmt.Errorf("party of %d at table %s for %d: %w", partySize, t.name, t.capacity, ErrPartyTooLarge)
+	}
+	return nil
+}
+
+func (t *Table) seat(hostID uuid.UUID, partySize int, at time.Time) {
+	t.status = StatusOccupied
+	t.hostID = hostID
+	t.partySize = partySize
+	t.seatedAt = at
+	t.orderIDs = nil
+}
+
+func (t Table) reservation(id uuid.UUID) (Reservation, error) {
+	for _, r := range t.reservations {
+		if r.ID == id {
+			return r, nil
+		}
+	}
+	return Reservation{}, fmt.Errorf("reservation %s at table %s: %w", id, t.name, ErrReservationNotFound)
+}
+
+func (t Table) without(id uuid.UUID) []Reservation {
+	reservations := make([]Reservation, 0, len(t.reservations))
+	for _, r := range t.reservations {
+		if r.ID != id {
+			reservations = append(reservations, r)
+		}
+	}
+	return reservations
+}
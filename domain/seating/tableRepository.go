@@ -0,0 +1,21 @@
+package seating
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrTableNotFound      = errors.New("table not found")
+	ErrTableAlreadyExists = errors.New("table already exists")
+)
+
+// manage table aggregates
+type TableRepository interface {
+	Get(ctx context.Context, id uuid.UUID) (Table, error)
+	GetAll(ctx context.Context) ([]Table, error)
+	Add(ctx context.Context, table Table) error
+	Update(ctx context.Context, table Table) error
+}
@@ -0,0 +1,156 @@
+package seating_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/devsrivatsa/tavernDDD/domain/seating"
+	"github.com/google/uuid"
+)
+
+func TestTable_Reserve(t *testing.T) {
+	evening := time.Date(2024, 3, 1, 19, 0, 0, 0, time.UTC)
+	reservation := func(partySize int, from, to time.Time) seating.Reservation {
+		r, err := seating.NewReservation("Percy", uuid.Nil, partySize, from, to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+
+	table, err := seating.NewTable("T1", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type testCase struct {
+		test          string
+		reservation   seating.Reservation
+		expectedError error
+	}
+
+	testcases := []testCase{
+		{test: "First reservation", reservation: reservation(4, evening, evening.Add(2*time.Hour)), expectedError: nil},
+		{test: "Overlapping the end", reservation: reservation(2, evening.Add(time.Hour), evening.Add(3*time.Hour)), expectedError: seating.ErrReservationConflict},
+		{test: "Inside another", reservation: reservation(2, evening.Add(30*time.Minute), evening.Add(time.Hour)), expectedError: seating.ErrReservationConflict},
+		{test: "Right after another", reservation: reservation(2, evening.Add(2*time.Hour), evening.Add(4*time.Hour)), expectedError: nil},
+		{test: "Right before another", reservation: reservation(2, evening.Add(-2*time.Hour), evening), expectedError: nil},
+		{test: "Party too large", reservation: reservation(5, evening.Add(5*time.Hour), evening.Add(6*time.Hour)), expectedError: seating.ErrPartyTooLarge},
+		{test: "Empty reservation", reservation: seating.Reservation{}, expectedError: seating.ErrInvalidReservation},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.test, func(t *testing.T) {
+			err := table.Reserve(tc.reservation)
+			if !errors.Is(err, tc.expectedError) {
+				t.Errorf("expected error %v, got %v", tc.expectedError, err)
+			}
+		})
+	}
+
+	reservations := table.GetReservations()
+	if len(reservations) != 3 || !reservations[0].To.Equal(evening) {
+		t.Errorf("expected 3 reservations, earliest first, got %v", reservations)
+	}
+	if err := table.CancelReservation(reservations[1].ID); err != nil {
+		t.Fatal(err)
+	}
+	if !table.IsFreeBetween(evening, evening.Add(2*time.Hour)) {
+		t.Error("expected the slot of the cancelled reservation to be free")
+	}
+	if err := table.CancelReservation(reservations[1].ID); !errors.Is(err, seating.ErrReservationNotFound) {
+		t.Errorf("expected error %v, got %v", seating.ErrReservationNotFound, err)
+	}
+}
+
+func TestNewReservation(t *testing.T) {
+	evening := time.Date(2024, 3, 1, 19, 0, 0, 0, time.UTC)
+
+	type testCase struct {
+		test          string
+		name          string
+		customerID    uuid.UUID
+		partySize     int
+		to            time.Time
+		expectedError error
+	}
+
+	testcases := []testCase{
+		{test: "Named guest", name: "Percy", partySize: 2, to: evening.Add(time.Hour), expectedError: nil},
+		{test: "Customer without a name", customerID: uuid.New(), partySize: 2, to: evening.Add(time.Hour), expectedError: nil},
+		{test: "Nobody", partySize: 2, to: evening.Add(time.Hour), expectedError: seating.ErrInvalidReservation},
+		{test: "Empty party", name: "Percy", partySize: 0, to: evening.Add(time.Hour), expectedError: seating.ErrInvalidReservation},
+		{test: "Ends when it starts", name: "Percy", partySize: 2, to: evening, expectedError: seating.ErrInvalidReservation},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.test, func(t *testing.T) {
+			_, err := seating.NewReservation(tc.name, tc.customerID, tc.partySize, evening, tc.to)
+			if !errors.Is(err, tc.expectedError) {
+				t.Errorf("expected error %v, got %v", tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestTable_Seating(t *testing.T) {
+	evening := time.Date(2024, 3, 1, 19, 0, 0, 0, time.UTC)
+	hostID := uuid.New()
+
+	table, err := seating.NewTable("T1", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := seating.NewReservation("Percy", uuid.Nil, 3, evening.Add(2*time.Hour), evening.Add(4*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Reserve(r); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := table.AddOrder(uuid.New()); !errors.Is(err, seating.ErrTableNotOccupied) {
+		t.Errorf("expected error %v, got %v", seating.ErrTableNotOccupied, err)
+	}
+	if err := table.SeatWalkIn(hostID, 2, evening, evening.Add(3*time.Hour)); !errors.Is(err, seating.ErrReservationConflict) {
+		t.Errorf("expected error %v, got %v", seating.ErrReservationConflict, err)
+	}
+	if err := table.SeatWalkIn(hostID, 5, evening, evening.Add(time.Hour)); !errors.Is(err, seating.ErrPartyTooLarge) {
+		t.Errorf("expected error %v, got %v", seating.ErrPartyTooLarge, err)
+	}
+	if err := table.SeatWalkIn(hostID, 2, evening, evening.Add(2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.SeatWalkIn(hostID, 2, evening, evening.Add(time.Hour)); !errors.Is(err, seating.ErrTableNotFree) {
+		t.Errorf("expected error %v, got %v", seating.ErrTableNotFree, err)
+	}
+	if err := table.AddOrder(uuid.New()); err != nil {
+		t.Fatal(err)
+	}
+	if table.GetStatus() != seating.StatusOccupied || table.GetHostID() != hostID || table.GetPartySize() != 2 || len(table.GetOrderIDs()) != 1 {
+		t.Errorf("expected a party of 2 with one order, got %s with %d and %d orders", table.GetStatus(), table.GetPartySize(), len(table.GetOrderIDs()))
+	}
+
+	if err := table.MarkClean(); !errors.Is(err, seating.ErrTableNotBeingCleaned) {
+		t.Errorf("expected error %v, got %v", seating.ErrTableNotBeingCleaned, err)
+	}
+	if err := table.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.SeatReservation(r.ID, hostID, evening.Add(2*time.Hour)); !errors.Is(err, seating.ErrTableNotFree) {
+		t.Errorf("expected error %v, got %v", seating.ErrTableNotFree, err)
+	}
+	if err := table.MarkClean(); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.SeatReservation(r.ID, hostID, evening.Add(30*time.Minute)); !errors.Is(err, seating.ErrReservationNotStarted) {
+		t.Errorf("expected error %v, got %v", seating.ErrReservationNotStarted, err)
+	}
+	if err := table.SeatReservation(r.ID, hostID, evening.Add(90*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if table.GetPartySize() != 3 || len(table.GetReservations()) != 0 || len(table.GetOrderIDs()) != 0 {
+		t.Errorf("expected the reserved party of 3 without orders, got %d with %d orders", table.GetPartySize(), len(table.GetOrderIDs()))
+	}
+}
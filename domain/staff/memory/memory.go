@@ -0,0 +1,65 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/devsrivatsa/tavernDDD/domain/staff"
+	"github.com/google/uuid"
+)
+
+type MemoryStaffRepository struct {
+	members map[uuid.UUID]staff.Member
+	sync.Mutex
+}
+
+func New() *MemoryStaffRepository {
+	return &MemoryStaffRepository{
+		members: make(map[uuid.UUID]staff.Member),
+	}
+}
+
+func (m *MemoryStaffRepository) Get(ctx context.Context, id uuid.UUID) (staff.Member, error) {
+	if err := ctx.Err(); err != nil {
+		return staff.Member{}, err
+	}
+	m.Lock()
+	defer m.Unlock()
+
+	if member, ok := m.members[id]; ok {
+		return member, nil
+	}
+
+	return staff.Member{}, staff.ErrMemberNotFound
+}
+
+func (m *MemoryStaffRepository) Add(ctx context.Context, member staff.Member) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.Lock()
+	defer m.Unlock()
+
+	if _, ok := m.members[member.GetID()]; ok {
+		return fmt.Errorf("error adding staff member %s: %w", member.GetName(), staff.ErrMemberAlreadyExists)
+	}
+	m.members[member.GetID()] = member
+
+	return nil
+}
+
+func (m *MemoryStaffRepository) Update(ctx context.Context, member staff.Member) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.Lock()
+	defer m.Unlock()
+
+	if _, ok := m.members[member.GetID()]; !ok {
+		return fmt.Errorf("error updating staff member %s: %w", member.GetName(), staff.ErrMemberNotFound)
+	}
+	m.members[member.GetID()] = member
+
+	return nil
+}
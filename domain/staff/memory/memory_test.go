@@ -0,0 +1,46 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/devsrivatsa/tavernDDD/domain/staff"
+	"github.com/google/uuid"
+)
+
+func TestMemoryStaffRepository(t *testing.T) {
+	repo := New()
+	member, err := staff.NewMember("Sam", staff.RoleBartender)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Add(context.Background(), member); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Add(context.Background(), member); !errors.Is(err, staff.ErrMemberAlreadyExists) {
+		t.Errorf("expected error %v, got %v", staff.ErrMemberAlreadyExists, err)
+	}
+
+	if err := member.SetRole(staff.RoleManager); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Update(context.Background(), member); err != nil {
+		t.Fatal(err)
+	}
+	got, err := repo.Get(context.Background(), member.GetID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.GetRole() != staff.RoleManager {
+		t.Errorf("expected role %s, got %s", staff.RoleManager, got.GetRole())
+	}
+
+	if _, err := repo.Get(context.Background(), uuid.New()); !errors.Is(err, staff.ErrMemberNotFound) {
+		t.Errorf("expected error %v, got %v", staff.ErrMemberNotFound, err)
+	}
+	other, _ := staff.NewMember("Alex", staff.RoleServer)
+	if err := repo.Update(context.Background(), other); !errors.Is(err, staff.ErrMemberNotFound) {
+		t.Errorf("expected error %v, got %v", staff.ErrMemberNotFound, err)
+	}
+}
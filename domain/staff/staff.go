@@ -0,0 +1,114 @@
+package staff
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrMissingName  = errors.New("a staff member needs a name")
+	ErrInvalidRole  = errors.New("unknown staff role")
+	ErrInactive     = errors.New("the staff member no longer works at the tavern")
+	ErrNotPermitted = errors.New("the role of the staff member does not allow it")
+)
+
+type Role string
+
+const (
+	RoleBartender Role = "bartender"
+	RoleServer    Role = "server"
+	RoleManager   Role = "manager"
+	RoleKitchen   Role = "kitchen"
+)
+
+// Action is something staff do that depends on their role
+type Action string
+
+const (
+	ActionTakeOrder Action = "take orders"
+	// ActionVoid calls off an order that has not been paid yet
+	ActionVoid Action = "void orders"
+	// ActionComp gives an order line away for free
+	ActionComp          Action = "comp order lines"
+	ActionOverridePrice Action = "override prices"
+)
+
+// permissions lists what every role may do, managers may do everything
+var permissions = map[Role][]Action{
+	RoleBartender: {ActionTakeOrder},
+	RoleServer:    {ActionTakeOrder},
+	RoleManager:   {ActionTakeOrder, ActionVoid, ActionComp, ActionOverridePrice},
+	RoleKitchen:   {},
+}
+
+// Member is an entity for someone working at the tavern
+type Member struct {
+	//id is the root entity identifier of the staff aggregate
+	id   uuid.UUID
+	name string
+	role Role
+	//active is false once the member left, their past orders still refer to them
+	active bool
+}
+
+// factory function to create a new active staff member
+func NewMember(name string, role Role) (Member, error) {
+	if name == "" {
+		return Member{}, ErrMissingName
+	}
+	if _, ok := permissions[role]; !ok {
+		return Member{}, fmt.Errorf("%q: %w", role, ErrInvalidRole)
+	}
+
+	return Member{
+		id:     uuid.New(),
+		name:   name,
+		role:   role,
+		active: true,
+	}, nil
+}
+
+func (m Member) GetID() uuid.UUID {
+	return m.id
+}
+
+func (m Member) GetName() string {
+	return m.name
+}
+
+func (m Member) GetRole() Role {
+	return m.role
+}
+
+func (m Member) IsActive() bool {
+	return m.active
+}
+
+func (m *Member) SetRole(role Role) error {
+	if _, ok := permissions[role]; !ok {
+		return fmt.Errorf("%q: %w", role, ErrInvalidRole)
+	}
+	m.role = role
+
+	return nil
+}
+
+// Deactivate marks a member who left, they cannot do anything any more
+func (m *Member) Deactivate() {
+	m.active = false
+}
+
+// Authorize reports why the member may not take the action, it returns nil when they may
+func (m Member) Authorize(action Action) error {
+	if !m.active {
+		return fmt.Errorf("%s: %w", m.name, ErrInactive)
+	}
+	for _, a := range permissions[m.role] {
+		if a == action {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is a %s and cannot %s: %w", m.name, m.role, action, ErrNotPermitted)
+}
@@ -0,0 +1,20 @@
+package staff
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrMemberNotFound      = errors.New("staff member not found")
+	ErrMemberAlreadyExists = errors.New("staff member already exists")
+)
+
+// manage staff aggregates
+type StaffRepository interface {
+	Get(ctx context.Context, id uuid.UUID) (Member, error)
+	Add(ctx context.Context, member Member) error
+	Update(ctx context.Context, member Member) error
+}
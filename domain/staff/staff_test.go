@@ -0,0 +1,64 @@
+package staff_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/devsrivatsa/tavernDDD/domain/staff"
+)
+
+func TestMember_Authorize(t *testing.T) {
+	newMember := func(role staff.Role) staff.Member {
+		m, err := staff.NewMember("Sam", role)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+	left := newMember(staff.RoleManager)
+	left.Deactivate()
+
+	type testCase struct {
+		test          string
+		member        staff.Member
+		action        staff.Action
+		expectedError error
+	}
+
+	testcases := []testCase{
+		{test: "Bartender takes an order", member: newMember(staff.RoleBartender), action: staff.ActionTakeOrder, expectedError: nil},
+		{test: "Server takes an order", member: newMember(staff.RoleServer), action: staff.ActionTakeOrder, expectedError: nil},
+		{test: "Kitchen takes an order", member: newMember(staff.RoleKitchen), action: staff.ActionTakeOrder, expectedError: staff.ErrNotPermitted},
+		{test: "Bartender voids", member: newMember(staff.RoleBartender), action: staff.ActionVoid, expectedError: staff.ErrNotPermitted},
+		{test: "Bartender comps", member: newMember(staff.RoleBartender), action: staff.ActionComp, expectedError: staff.ErrNotPermitted},
+		{test: "Manager voids", member: newMember(staff.RoleManager), action: staff.ActionVoid, expectedError: nil},
+		{test: "Manager overrides a price", member: newMember(staff.RoleManager), action: staff.ActionOverridePrice, expectedError: nil},
+		{test: "Manager who left", member: left, action: staff.ActionTakeOrder, expectedError: staff.ErrInactive},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.test, func(t *testing.T) {
+			err := tc.member.Authorize(tc.action)
+			if !errors.Is(err, tc.expectedError) {
+				t.Errorf("expected error %v, got %v", tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestNewMember(t *testing.T) {
+	if _, err := staff.NewMember("", staff.RoleServer); !errors.Is(err, staff.ErrMissingName) {
+		t.Errorf("expected error %v, got %v", staff.ErrMissingName, err)
+	}
+	m, err := staff.NewMember("Sam", "dishwasher")
+	if !errors.Is(err, staff.ErrInvalidRole) {
+		t.Errorf("expected error %v, got %v", staff.ErrInvalidRole, err)
+	}
+	m, _ = staff.NewMember("Sam", staff.RoleBartender)
+	if err := m.SetRole("dishwasher"); !errors.Is(err, staff.ErrInvalidRole) {
+		t.Errorf("expected error %v, got %v", staff.ErrInvalidRole, err)
+	}
+	if err := m.SetRole(staff.RoleManager); err != nil || m.Authorize(staff.ActionComp) != nil {
+		t.Errorf("expected a promoted manager to comp, got %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/devsrivatsa/tavernDDD/domain/tab"
+	"github.com/google/uuid"
+)
+
+type MemoryTabRepository struct {
+	tabs map[uuid.UUID]tab.Tab
+	sync.Mutex
+}
+
+func New() *MemoryTabRepository {
+	return &MemoryTabRepository{
+		tabs: make(map[uuid.UUID]tab.Tab),
+	}
+}
+
+func (m *MemoryTabRepository) Get(ctx context.Context, id uuid.UUID) (tab.Tab, error) {
+	if err := ctx.Err(); err != nil {
+		return tab.Tab{}, err
+	}
+	m.Lock()
+	defer m.Unlock()
+
+	if t, ok := m.tabs[id]; ok {
+		return t, nil
+	}
+
+	return tab.Tab{}, tab.ErrTabNotFound
+}
+
+func (m *MemoryTabRepository) GetOpenByCustomer(ctx context.Context, customerID uuid.UUID) (tab.Tab, error) {
+	if err := ctx.Err(); err != nil {
+		return tab.Tab{}, err
+	}
+	m.Lock()
+	defer m.Unlock()
+
+	if t, ok := m.openTab(customerID); ok {
+		return t, nil
+	}
+
+	return tab.Tab{}, tab.ErrTabNotFound
+}
+
+// GetOpen returns the open tabs, oldest first
+func (m *MemoryTabRepository) GetOpen(ctx context.Context) ([]tab.Tab, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.Lock()
+	defer m.Unlock()
+
+	var tabs []tab.Tab
+	for _, t := range m.tabs {
+		if t.IsOpen() {
+			tabs = append(tabs, t)
+		}
+	}
+	sort.Slice(tabs, func(i, j int) bool {
+		return tabs[i].GetOpenedAt().Before(tabs[j].GetOpenedAt())
+	})
+
+	return tabs, nil
+}
+
+func (m *MemoryTabRepository) Add(ctx context.Context, t tab.Tab) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.Lock()
+	defer m.Unlock()
+
+	if _, ok := m.tabs[t.GetID()]; ok {
+		return fmt.Errorf("error adding tab %s: %w", t.GetID(), tab.ErrTabAlreadyExists)
+	}
+	if _, ok := m.openTab(t.GetCustomerID()); ok && t.IsOpen() {
+		return fmt.Errorf("error adding tab %s: %w", t.GetID(), tab.ErrTabAlreadyOpen)
+	}
+	m.tabs[t.GetID()] = t
+
+	return nil
+}
+
+func (m *MemoryTabRepository) Update(ctx context.Context, t tab.Tab) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.Lock()
+	defer m.Unlock()
+
+	if _, ok := m.tabs[t.GetID()]; !ok {
+		return fmt.Errorf("error updating tab %s: %w", t.GetID(), tab.ErrTabNotFound)
+	}
+	m.tabs[t.GetID()] = t
+
+	return nil
+}
+
+func (m *MemoryTabRepository) openTab(customerID uuid.UUID) (tab.Tab, bool) {
+	for _, t := range m.tabs {
+		if t.IsOpen() && t.GetCustomerID() == customerID {
+			return t, true
+		}
+	}
+	return tab.Tab{}, false
+}
@@ -0,0 +1,44 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/devsrivatsa/tavernDDD/domain/tab"
+	"github.com/google/uuid"
+)
+
+func TestMemoryTabRepository_Add(t *testing.T) {
+	customerID := uuid.New()
+	newTab := func() tab.Tab {
+		tb, err := tab.NewTab(customerID, time.Time{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tb
+	}
+
+	repo := New()
+	first := newTab()
+	if err := repo.Add(context.Background(), first); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Add(context.Background(), newTab()); !errors.Is(err, tab.ErrTabAlreadyOpen) {
+		t.Errorf("expected error %v, got %v", tab.ErrTabAlreadyOpen, err)
+	}
+
+	if err := first.Close(uuid.New(), time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Update(context.Background(), first); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.GetOpenByCustomer(context.Background(), customerID); !errors.Is(err, tab.ErrTabNotFound) {
+		t.Errorf("expected error %v, got %v", tab.ErrTabNotFound, err)
+	}
+	if err := repo.Add(context.Background(), newTab()); err != nil {
+		t.Errorf("expected error %v, got %v", nil, err)
+	}
+}
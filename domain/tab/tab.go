@@ -0,0 +1,218 @@
+package tab
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/devsrivatsa/tavernDDD/domain"
+	"github.com/devsrivatsa/tavernDDD/domain/order"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrMissingCustomer = errors.New("a tab must belong to a customer")
+	ErrTabClosed       = errors.New("the tab is closed")
+	ErrWrongCustomer   = errors.New("the order belongs to another customer")
+	ErrOrderOnTab      = errors.New("the order is already on the tab")
+	ErrOrderNotOnTab   = errors.New("the order is not on the tab")
+)
+
+type Status string
+
+const (
+	StatusOpen   Status = "open"
+	StatusClosed Status = "closed"
+)
+
+// Tab is the running bill of a customer. Orders are added to it while it is open
+// and the customer pays for all of them at once when it is closed.
+type Tab struct {
+	//id is the root entity identifier of the tab aggregate
+	id         uuid.UUID
+	customerID uuid.UUID
+	orderIDs   []uuid.UUID
+	lines      []order.Line
+	//lineCounts holds how many lines each order put on the tab, in the order of orderIDs
+	lineCounts []int
+	total      domain.Money
+	status     Status
+	//invoiceID references the final bill, it is set when the tab is closed
+	invoiceID uuid.UUID
+	//flagged marks a tab that was left open past closing time
+	flagged  bool
+	openedAt time.Time
+	closedAt time.Time
+}
+
+// factory function to open a new tab for a customer
+func NewTab(customerID uuid.UUID, openedAt time.Time) (Tab, error) {
+	if customerID == uuid.Nil {
+		return Tab{}, ErrMissingCustomer
+	}
+	if openedAt.IsZero() {
+		openedAt = time.Now()
+	}
+
+	return Tab{
+		id:         uuid.New(),
+		customerID: customerID,
+		status:     StatusOpen,
+		openedAt:   openedAt,
+	}, nil
+}
+
+func (t Tab) GetID() uuid.UUID {
+	return t.id
+}
+
+func (t Tab) GetCustomerID() uuid.UUID {
+	return t.customerID
+}
+
+// GetOrderIDs returns a copy of the IDs of the orders on the tab
+func (t Tab) GetOrderIDs() []uuid.UUID {
+	return append([]uuid.UUID(nil), t.orderIDs...)
+}
+
+// GetLines returns a copy of the lines of all orders on the tab, in the order they were added
+func (t Tab) GetLines() []order.Line {
+	return append([]order.Line(nil), t.lines...)
+}
+
+// GetTotal returns what the customer owes for the tab so far, it is zero for an empty tab
+func (t Tab) GetTotal() domain.Money {
+	return t.total
+}
+
+func (t Tab) GetStatus() Status {
+	return t.status
+}
+
+func (t Tab) IsOpen() bool {
+	return t.status == StatusOpen
+}
+
+func (t Tab) GetInvoiceID() uuid.UUID {
+	return t.invoiceID
+}
+
+func (t Tab) IsFlagged() bool {
+	return t.flagged
+}
+
+func (t Tab) GetOpenedAt() time.Time {
+	return t.openedAt
+}
+
+func (t Tab) GetClosedAt() time.Time {
+	return t.closedAt
+}
+
+// AddOrder puts the lines of an order of the tab's customer on the tab
+func (t *Tab) AddOrder(o order.Order) error {
+	if !t.IsOpen() {
+		return fmt.Errorf("tab %s: %w", t.id, ErrTabClosed)
+	}
+	if o.GetCustomerID() != t.customerID {
+		return fmt.Errorf("order %s: %w", o.GetID(), ErrWrongCustomer)
+	}
+	for _, id := range t.orderIDs {
+		if id == o.GetID() {
+			return fmt.Errorf("order %s: %w", o.GetID(), ErrOrderOnTab)
+		}
+	}
+
+	total := o.GetTotal()
+	if len(t.orderIDs) > 0 {
+		var err error
+		total, err = t.total.Add(total)
+		if err != nil {
+			return fmt.Errorf("order %s: %w", o.GetID(), err)
+		}
+	}
+	t.total = total
+	t.orderIDs = append(t.orderIDs[:len(t.orderIDs):len(t.orderIDs)], o.GetID())
+	t.lines = append(t.lines[:len(t.lines):len(t.lines)], o.GetLines()...)
+	t.lineCounts = append(t.lineCounts[:len(t.lineCounts):len(t.lineCounts)], len(o.GetLines()))
+
+	return nil
+}
+
+// RemoveOrder takes an order that was called off, e.g. voided, off the open tab with its lines
+func (t *Tab) RemoveOrder(o order.Order) error {
+	if !t.IsOpen() {
+		return fmt.Errorf("tab %s: %w", t.id, ErrTabClosed)
+	}
+	index := -1
+	for i, id := range t.orderIDs {
+		if id == o.GetID() {
+			index = i
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("order %s: %w", o.GetID(), ErrOrderNotOnTab)
+	}
+
+	total := domain.Money{}
+	if len(t.orderIDs) > 1 {
+		var err error
+		total, err = t.total.Sub(o.GetTotal())
+		if err != nil {
+			return fmt.Errorf("order %s: %w", o.GetID(), err)
+		}
+	}
+	from := 0
+	for _, n := range t.lineCounts[:index] {
+		from += n
+	}
+	to := from + t.lineCounts[index]
+
+	t.total = total
+	t.orderIDs = append(t.GetOrderIDs()[:index], t.orderIDs[index+1:]...)
+	t.lines = append(t.GetLines()[:from], t.lines[to:]...)
+	t.lineCounts = append(append([]int(nil), t.lineCounts[:index]...), t.lineCounts[index+1:]...)
+
+	return nil
+}
+
+// Close settles the tab, invoiceID is empty when nothing was ordered on it
+func (t *Tab) Close(invoiceID uuid.UUID, closedAt time.Time) error {
+	if !t.IsOpen() {
+		return fmt.Errorf("tab %s: %w", t.id, ErrTabClosed)
+	}
+	if closedAt.IsZero() {
+		closedAt = time.Now()
+	}
+	t.status = StatusClosed
+	t.invoiceID = invoiceID
+	t.closedAt = closedAt
+
+	return nil
+}
+
+// Flag marks the tab if it is still open after the first closing time since it was opened.
+// closing is the time of day the tavern closes, as the duration since midnight, so
+// 26 * time.Hour and 2 * time.Hour both mean 2 in the morning. It reports whether the tab is flagged.
+func (t *Tab) Flag(now time.Time, closing time.Duration) bool {
+	if t.IsOpen() && now.After(closingAfter(t.openedAt, closing)) {
+		t.flagged = true
+	}
+	return t.flagged
+}
+
+// closingAfter returns the first closing time after the given moment, in the same location
+func closingAfter(at time.Time, closing time.Duration) time.Time {
+	closing %= 24 * time.Hour
+	c := closingOn(at, 0, closing)
+	if !c.After(at) {
+		c = closingOn(at, 1, closing)
+	}
+	return c
+}
+
+// closingOn returns the closing time on the clock the given number of days after at, rather than
+// the time since midnight, which is an hour off when the clocks change
+func closingOn(at time.Time, days int, closing time.Duration) time.Time {
+	return time.Date(at.Year(), at.Month(), at.Day()+days, 0, 0, 0, int(closing), at.Location())
+}
@@ -0,0 +1,25 @@
+package tab
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrTabNotFound      = errors.New("tab not found")
+	ErrTabAlreadyExists = errors.New("tab already exists")
+	ErrTabAlreadyOpen   = errors.New("the customer already has an open tab")
+)
+
+// manage tab aggregates, a customer has at most one open tab
+type TabRepository interface {
+	Get(ctx context.Context, id uuid.UUID) (Tab, error)
+	// GetOpenByCustomer fails with ErrTabNotFound when the customer has no open tab
+	GetOpenByCustomer(ctx context.Context, customerID uuid.UUID) (Tab, error)
+	GetOpen(ctx context.Context) ([]Tab, error)
+	// Add fails with ErrTabAlreadyOpen when the customer already has an open tab
+	Add(ctx context.Context, tab Tab) error
+	Update(ctx context.Context, tab Tab) error
+}
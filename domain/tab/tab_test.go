@@ -0,0 +1,158 @@
+package tab_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/devsrivatsa/tavernDDD/domain"
+	"github.com/devsrivatsa/tavernDDD/domain/order"
+	"github.com/devsrivatsa/tavernDDD/domain/tab"
+	"github.com/google/uuid"
+)
+
+func TestTab_AddOrder(t *testing.T) {
+	customerID := uuid.New()
+	line := order.Line{ProductID: uuid.New(), Name: "Beer", UnitPrice: domain.MustNewMoney(199, "EUR"), Quantity: 2}
+	newOrder := func(customerID uuid.UUID) order.Order {
+		o, err := order.NewOrder(customerID, []order.Line{line})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return o
+	}
+
+	tb, err := tab.NewTab(customerID, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := newOrder(customerID)
+
+	type testCase struct {
+		test          string
+		order         order.Order
+		expectedError error
+	}
+
+	testcases := []testCase{
+		{test: "First order", order: first, expectedError: nil},
+		{test: "Second order", order: newOrder(customerID), expectedError: nil},
+		{test: "Same order twice", order: first, expectedError: tab.ErrOrderOnTab},
+		{test: "Order of another customer", order: newOrder(uuid.New()), expectedError: tab.ErrWrongCustomer},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.test, func(t *testing.T) {
+			err := tb.AddOrder(tc.order)
+			if !errors.Is(err, tc.expectedError) {
+				t.Errorf("expected error %v, got %v", tc.expectedError, err)
+			}
+		})
+	}
+
+	if !tb.GetTotal().Equals(domain.MustNewMoney(796, "EUR")) || len(tb.GetLines()) != 2 {
+		t.Errorf("expected 2 lines over 7.96 EUR, got %d over %s", len(tb.GetLines()), tb.GetTotal())
+	}
+	if err := tb.Close(uuid.New(), time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tb.AddOrder(newOrder(customerID)); !errors.Is(err, tab.ErrTabClosed) {
+		t.Errorf("expected error %v, got %v", tab.ErrTabClosed, err)
+	}
+}
+
+func TestTab_RemoveOrder(t *testing.T) {
+	customerID := uuid.New()
+	beer := order.Line{ProductID: uuid.New(), Name: "Beer", UnitPrice: domain.MustNewMoney(199, "EUR"), Quantity: 2}
+	wine := order.Line{ProductID: uuid.New(), Name: "Wine", UnitPrice: domain.MustNewMoney(599, "EUR"), Quantity: 1}
+	newOrder := func(lines ...order.Line) order.Order {
+		o, err := order.NewOrder(customerID, lines)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return o
+	}
+
+	tb, err := tab.NewTab(customerID, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, second, third := newOrder(beer), newOrder(beer, wine), newOrder(wine)
+	for _, o := range []order.Order{first, second, third} {
+		if err := tb.AddOrder(o); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	type testCase struct {
+		test          string
+		order         order.Order
+		expectedError error
+		expectedTotal domain.Money
+		expectedLines []string
+	}
+
+	testcases := []testCase{
+		{test: "Order in the middle", order: second, expectedTotal: domain.MustNewMoney(997, "EUR"), expectedLines: []string{"Beer", "Wine"}},
+		{test: "Same order twice", order: second, expectedError: tab.ErrOrderNotOnTab, expectedTotal: domain.MustNewMoney(997, "EUR"), expectedLines: []string{"Beer", "Wine"}},
+		{test: "First order", order: first, expectedTotal: domain.MustNewMoney(599, "EUR"), expectedLines: []string{"Wine"}},
+		{test: "Last order", order: third, expectedTotal: domain.Money{}, expectedLines: nil},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.test, func(t *testing.T) {
+			err := tb.RemoveOrder(tc.order)
+			if !errors.Is(err, tc.expectedError) {
+				t.Errorf("expected error %v, got %v", tc.expectedError, err)
+			}
+			var names []string
+			for _, l := range tb.GetLines() {
+				names = append(names, l.Name)
+			}
+			if !tb.GetTotal().Equals(tc.expectedTotal) || fmt.Sprint(names) != fmt.Sprint(tc.expectedLines) {
+				t.Errorf("expected %v over %s, got %v over %s", tc.expectedLines, tc.expectedTotal, names, tb.GetTotal())
+			}
+		})
+	}
+	if len(tb.GetOrderIDs()) != 0 {
+		t.Errorf("expected no orders on the tab, got %d", len(tb.GetOrderIDs()))
+	}
+}
+
+func TestTab_Flag(t *testing.T) {
+	type testCase struct {
+		test     string
+		openedAt time.Time
+		now      time.Time
+		closing  time.Duration
+		expected bool
+	}
+
+	evening := time.Date(2024, 3, 1, 20, 0, 0, 0, time.UTC)
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the clocks go forward early on 30 March 2025, so that day has 23 hours
+	spring := time.Date(2025, 3, 30, 20, 0, 0, 0, berlin)
+	testcases := []testCase{
+		{test: "Before closing past midnight", openedAt: evening, now: evening.Add(5 * time.Hour), closing: 2 * time.Hour, expected: false},
+		{test: "After closing past midnight", openedAt: evening, now: evening.Add(7 * time.Hour), closing: 2 * time.Hour, expected: true},
+		{test: "After closing the same day", openedAt: evening, now: evening.Add(4 * time.Hour), closing: 23 * time.Hour, expected: true},
+		{test: "Closing given past 24 hours", openedAt: evening, now: evening.Add(7 * time.Hour), closing: 26 * time.Hour, expected: true},
+		{test: "After closing the day the clocks go forward", openedAt: spring, now: time.Date(2025, 3, 30, 23, 30, 0, 0, berlin), closing: 23 * time.Hour, expected: true},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.test, func(t *testing.T) {
+			tb, err := tab.NewTab(uuid.New(), tc.openedAt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := tb.Flag(tc.now, tc.closing); got != tc.expected {
+				t.Errorf("expected flagged %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
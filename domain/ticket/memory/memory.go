@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/devsrivatsa/tavernDDD/domain/product"
+	"github.com/devsrivatsa/tavernDDD/domain/ticket"
+	"github.com/google/uuid"
+)
+
+type MemoryTicketRepository struct {
+	tickets map[uuid.UUID]ticket.Ticket
+	sync.Mutex
+}
+
+func New() *MemoryTicketRepository {
+	return &MemoryTicketRepository{
+		tickets: make(map[uuid.UUID]ticket.Ticket),
+	}
+}
+
+func (m *MemoryTicketRepository) Get(ctx context.Context, id uuid.UUID) (ticket.Ticket, error) {
+	if err := ctx.Err(); err != nil {
+		return ticket.Ticket{}, err
+	}
+	m.Lock()
+	defer m.Unlock()
+
+	if t, ok := m.tickets[id]; ok {
+		return t, nil
+	}
+
+	return ticket.Ticket{}, ticket.ErrTicketNotFound
+}
+
+func (m *MemoryTicketRepository) GetByOrder(ctx context.Context, orderID uuid.UUID) ([]ticket.Ticket, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return m.find(func(t ticket.Ticket) bool {
+		return t.GetOrderID() == orderID
+	}), nil
+}
+
+func (m *MemoryTicketRepository) GetPending(ctx context.Context, station product.Station) ([]ticket.Ticket, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return m.find(func(t ticket.Ticket) bool {
+		return t.GetStation() == station && t.GetStatus() != ticket.StatusServed && t.GetStatus() != ticket.StatusCancelled
+	}), nil
+}
+
+func (m *MemoryTicketRepository) Add(ctx context.Context, t ticket.Ticket) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.Lock()
+	defer m.Unlock()
+
+	if _, ok := m.tickets[t.GetID()]; ok {
+		return fmt.Errorf("error adding ticket %s: %w", t.GetID(), ticket.ErrTicketAlreadyExists)
+	}
+	m.tickets[t.GetID()] = t
+
+	return nil
+}
+
+func (m *MemoryTicketRepository) Update(ctx context.Context, t ticket.Ticket) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.Lock()
+	defer m.Unlock()
+
+	if _, ok := m.tickets[t.GetID()]; !ok {
+		return fmt.Errorf("error updating ticket %s: %w", t.GetID(), ticket.ErrTicketNotFound)
+	}
+	m.tickets[t.GetID()] = t
+
+	return nil
+}
+
+// find returns the matching tickets, oldest first
+func (m *MemoryTicketRepository) find(match func(t ticket.Ticket) bool) []ticket.Ticket {
+	m.Lock()
+	defer m.Unlock()
+
+	var tickets []ticket.Ticket
+	for _, t := range m.tickets {
+		if match(t) {
+			tickets = append(tickets, t)
+		}
+	}
+	sort.Slice(tickets, func(i, j int) bool {
+		return tickets[i].GetCreatedAt().Before(tickets[j].GetCreatedAt())
+	})
+
+	return tickets
+}
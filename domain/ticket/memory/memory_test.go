@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/devsrivatsa/tavernDDD/domain"
+	"github.com/devsrivatsa/tavernDDD/domain/order"
+	"github.com/devsrivatsa/tavernDDD/domain/product"
+	"github.com/devsrivatsa/tavernDDD/domain/ticket"
+	"github.com/google/uuid"
+)
+
+func TestMemoryTicketRepository_GetPending(t *testing.T) {
+	o, err := order.NewOrder(uuid.New(), []order.Line{
+		{ProductID: uuid.New(), Name: "Beer", UnitPrice: domain.MustNewMoney(199, "EUR"), Quantity: 2},
+		{ProductID: uuid.New(), Name: "Stew", UnitPrice: domain.MustNewMoney(899, "EUR"), Quantity: 1, Station: "kitchen"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 3, 1, 20, 0, 0, 0, time.UTC)
+	first, err := ticket.Split(o, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := ticket.Split(o, now.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := New()
+	for _, tk := range append(second, first...) {
+		if err := repo.Add(context.Background(), tk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repo.Add(context.Background(), first[0]); !errors.Is(err, ticket.ErrTicketAlreadyExists) {
+		t.Errorf("expected error %v, got %v", ticket.ErrTicketAlreadyExists, err)
+	}
+
+	served := first[0]
+	for _, step := range []func(time.Time) error{served.Start, served.MarkReady, served.Serve} {
+		if err := step(now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repo.Update(context.Background(), served); err != nil {
+		t.Fatal(err)
+	}
+
+	pending, err := repo.GetPending(context.Background(), product.StationBar)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].GetID() != second[0].GetID() {
+		t.Errorf("expected only the second bar ticket pending, got %d tickets", len(pending))
+	}
+	pending, err = repo.GetPending(context.Background(), product.StationKitchen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 2 || pending[0].GetID() != first[1].GetID() {
+		t.Errorf("expected both kitchen tickets pending, oldest first, got %d tickets", len(pending))
+	}
+	byOrder, err := repo.GetByOrder(context.Background(), o.GetID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byOrder) != 4 {
+		t.Errorf("expected 4 tickets for the order, got %d", len(byOrder))
+	}
+}
@@ -0,0 +1,175 @@
+package ticket
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/devsrivatsa/tavernDDD/domain/order"
+	"github.com/devsrivatsa/tavernDDD/domain/product"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNoItems       = errors.New("a ticket needs at least one item to prepare")
+	ErrInvalidStatus = errors.New("the ticket cannot move to the requested status")
+)
+
+type Status string
+
+const (
+	StatusQueued     Status = "queued"
+	StatusInProgress Status = "in_progress"
+	StatusReady      Status = "ready"
+	StatusServed     Status = "served"
+	StatusCancelled  Status = "cancelled"
+)
+
+// Item is a value object for something the station has to make
+type Item struct {
+	ProductID uuid.UUID
+	Name      string
+	// Size is set when a variant of the product was ordered
+	Size     string
+	Quantity int
+	Notes    string
+}
+
+// Ticket is what one station has to prepare for an order. It carries the order, table,
+// customer and staff member, so whoever makes it knows where it goes.
+type Ticket struct {
+	//id is the root entity identifier of the ticket aggregate
+	id         uuid.UUID
+	orderID    uuid.UUID
+	customerID uuid.UUID
+	//tableID is empty for orders at the bar
+	tableID   uuid.UUID
+	staffID   uuid.UUID
+	station   product.Station
+	items     []Item
+	status    Status
+	createdAt time.Time
+	updatedAt time.Time
+}
+
+// Split makes a queued ticket for every station the lines of the order are prepared at,
+// in the order the stations first appear on it. Lines without a station go to the bar.
+func Split(o order.Order, at time.Time) ([]Ticket, error) {
+	if len(o.GetLines()) == 0 {
+		return nil, ErrNoItems
+	}
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	var tickets []Ticket
+	index := make(map[product.Station]int)
+	for _, l := range o.GetLines() {
+		station := product.Station(l.Station)
+		if station == "" {
+			station = product.StationBar
+		}
+		i, ok := index[station]
+		if !ok {
+			i = len(tickets)
+			index[station] = i
+			tickets = append(tickets, Ticket{
+				id:         uuid.New(),
+				orderID:    o.GetID(),
+				customerID: o.GetCustomerID(),
+				tableID:    o.GetTableID(),
+				staffID:    o.GetTakenBy(),
+				station:    station,
+				status:     StatusQueued,
+				createdAt:  at,
+				updatedAt:  at,
+			})
+		}
+		tickets[i].items = append(tickets[i].items, Item{
+			ProductID: l.ProductID,
+			Name:      l.Name,
+			Size:      l.Size,
+			Quantity:  l.Quantity,
+			Notes:     l.Notes,
+		})
+	}
+
+	return tickets, nil
+}
+
+func (t Ticket) GetID() uuid.UUID {
+	return t.id
+}
+
+func (t Ticket) GetOrderID() uuid.UUID {
+	return t.orderID
+}
+
+func (t Ticket) GetCustomerID() uuid.UUID {
+	return t.customerID
+}
+
+func (t Ticket) GetTableID() uuid.UUID {
+	return t.tableID
+}
+
+// GetStaffID returns the staff member who took the order
+func (t Ticket) GetStaffID() uuid.UUID {
+	return t.staffID
+}
+
+func (t Ticket) GetStation() product.Station {
+	return t.station
+}
+
+// GetItems returns a copy of the items to prepare
+func (t Ticket) GetItems() []Item {
+	return append([]Item(nil), t.items...)
+}
+
+func (t Ticket) GetStatus() Status {
+	return t.status
+}
+
+func (t Ticket) GetCreatedAt() time.Time {
+	return t.createdAt
+}
+
+func (t Ticket) GetUpdatedAt() time.Time {
+	return t.updatedAt
+}
+
+// Start marks a queued ticket as being prepared
+func (t *Ticket) Start(at time.Time) error {
+	return t.transition(StatusQueued, StatusInProgress, at)
+}
+
+// MarkReady marks a ticket as prepared and waiting to be served
+func (t *Ticket) MarkReady(at time.Time) error {
+	return t.transition(StatusInProgress, StatusReady, at)
+}
+
+func (t *Ticket) Serve(at time.Time) error {
+	return t.transition(StatusReady, StatusServed, at)
+}
+
+// Cancel takes a ticket that has not been served off its station, e.g. when the order is voided
+func (t *Ticket) Cancel(at time.Time) error {
+	if t.status == StatusServed || t.status == StatusCancelled {
+		return fmt.Errorf("ticket %s is %s, cannot become %s: %w", t.id, t.status, StatusCancelled, ErrInvalidStatus)
+	}
+	return t.transition(t.status, StatusCancelled, at)
+}
+
+func (t *Ticket) transition(from, to Status, at time.Time) error {
+	if t.status != from {
+		return fmt.Errorf("ticket %s is %s, cannot become %s: %w", t.id, t.status, to, ErrInvalidStatus)
+	}
+	if at.IsZero() {
+		at = time.Now()
+	}
+	t.status = to
+	t.updatedAt = at
+
+	return nil
+}
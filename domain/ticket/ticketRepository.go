@@ -0,0 +1,25 @@
+package ticket
+
+import (
+	"context"
+	"errors"
+
+	"github.com/devsrivatsa/tavernDDD/domain/product"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrTicketNotFound      = errors.New("ticket not found")
+	ErrTicketAlreadyExists = errors.New("ticket already exists")
+)
+
+// manage ticket aggregates
+type TicketRepository interface {
+	Get(ctx context.Context, id uuid.UUID) (Ticket, error)
+	GetByOrder(ctx context.Context, orderID uuid.UUID) ([]Ticket, error)
+	// GetPending returns the tickets of the station that have not been served or cancelled yet,
+	// oldest first
+	GetPending(ctx context.Context, station product.Station) ([]Ticket, error)
+	Add(ctx context.Context, ticket Ticket) error
+	Update(ctx context.Context, ticket Ticket) error
+}
@@ -0,0 +1,130 @@
+package ticket_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/devsrivatsa/tavernDDD/domain"
+	"github.com/devsrivatsa/tavernDDD/domain/order"
+	"github.com/devsrivatsa/tavernDDD/domain/product"
+	"github.com/devsrivatsa/tavernDDD/domain/ticket"
+	"github.com/google/uuid"
+)
+
+func TestSplit(t *testing.T) {
+	o, err := order.NewOrder(uuid.New(), []order.Line{
+		{ProductID: uuid.New(), Name: "Beer", UnitPrice: domain.MustNewMoney(199, "EUR"), Quantity: 2},
+		{ProductID: uuid.New(), Name: "Stew", UnitPrice: domain.MustNewMoney(899, "EUR"), Quantity: 1, Station: "kitchen", Notes: "no onions"},
+		{ProductID: uuid.New(), Name: "Wine", UnitPrice: domain.MustNewMoney(599, "EUR"), Quantity: 1, Station: "bar"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tableID, staffID := uuid.New(), uuid.New()
+	o.SetTableID(tableID)
+	o.SetTakenBy(staffID)
+
+	tickets, err := ticket.Split(o, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tickets) != 2 {
+		t.Fatalf("expected 2 tickets, got %d", len(tickets))
+	}
+	bar, kitchen := tickets[0], tickets[1]
+	if bar.GetStation() != product.StationBar || len(bar.GetItems()) != 2 {
+		t.Errorf("expected 2 items for the bar, got %d for %s", len(bar.GetItems()), bar.GetStation())
+	}
+	if kitchen.GetStation() != product.StationKitchen || len(kitchen.GetItems()) != 1 || kitchen.GetItems()[0].Notes != "no onions" {
+		t.Errorf("expected the stew with its notes for the kitchen, got %v for %s", kitchen.GetItems(), kitchen.GetStation())
+	}
+	for _, tk := range tickets {
+		if tk.GetOrderID() != o.GetID() || tk.GetTableID() != tableID || tk.GetStaffID() != staffID || tk.GetStatus() != ticket.StatusQueued {
+			t.Errorf("expected a queued ticket for the order at the table, got %s", tk.GetStatus())
+		}
+	}
+}
+
+func TestTicket_Lifecycle(t *testing.T) {
+	o, err := order.NewOrder(uuid.New(), []order.Line{
+		{ProductID: uuid.New(), Name: "Beer", UnitPrice: domain.MustNewMoney(199, "EUR"), Quantity: 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tickets, err := ticket.Split(o, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tk := tickets[0]
+
+	type testCase struct {
+		test          string
+		step          func(at time.Time) error
+		expectedError error
+	}
+
+	testcases := []testCase{
+		{test: "Served before it is made", step: tk.Serve, expectedError: ticket.ErrInvalidStatus},
+		{test: "Ready before it is started", step: tk.MarkReady, expectedError: ticket.ErrInvalidStatus},
+		{test: "Started", step: tk.Start, expectedError: nil},
+		{test: "Started twice", step: tk.Start, expectedError: ticket.ErrInvalidStatus},
+		{test: "Ready", step: tk.MarkReady, expectedError: nil},
+		{test: "Served", step: tk.Serve, expectedError: nil},
+		{test: "Cancelled once served", step: tk.Cancel, expectedError: ticket.ErrInvalidStatus},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.test, func(t *testing.T) {
+			err := tc.step(time.Time{})
+			if !errors.Is(err, tc.expectedError) {
+				t.Errorf("expected error %v, got %v", tc.expectedError, err)
+			}
+		})
+	}
+
+	if tk.GetStatus() != ticket.StatusServed {
+		t.Errorf("expected status %s, got %s", ticket.StatusServed, tk.GetStatus())
+	}
+}
+
+func TestTicket_Cancel(t *testing.T) {
+	o, err := order.NewOrder(uuid.New(), []order.Line{
+		{ProductID: uuid.New(), Name: "Beer", UnitPrice: domain.MustNewMoney(199, "EUR"), Quantity: 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tickets, err := ticket.Split(o, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tk := tickets[0]
+
+	type testCase struct {
+		test          string
+		step          func(at time.Time) error
+		expectedError error
+	}
+
+	testcases := []testCase{
+		{test: "Started", step: tk.Start, expectedError: nil},
+		{test: "Cancelled while in progress", step: tk.Cancel, expectedError: nil},
+		{test: "Cancelled twice", step: tk.Cancel, expectedError: ticket.ErrInvalidStatus},
+		{test: "Ready once cancelled", step: tk.MarkReady, expectedError: ticket.ErrInvalidStatus},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.test, func(t *testing.T) {
+			err := tc.step(time.Time{})
+			if !errors.Is(err, tc.expectedError) {
+				t.Errorf("expected error %v, got %v", tc.expectedError, err)
+			}
+		})
+	}
+
+	if tk.GetStatus() != ticket.StatusCancelled {
+		t.Errorf("expected status %s, got %s", ticket.StatusCancelled, tk.GetStatus())
+	}
+}
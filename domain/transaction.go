@@ -1,15 +1,58 @@
 package domain
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+var (
+	ErrInvalidAmount  = errors.New("a transaction amount must be greater than zero")
+	ErrInvalidParties = errors.New("a transaction needs two different parties")
+)
+
 // Transaction is a value object that represents a transaction. It has no identifier
 type Transaction struct {
 	from      uuid.UUID
-	amount    float64
+	amount    Money
 	to        uuid.UUID
 	createdAt time.Time
 }
+
+// factory function to create a new transaction moving amount from one party to another.
+// A zero createdAt is stamped with the current time.
+func NewTransaction(from, to uuid.UUID, amount Money, createdAt time.Time) (Transaction, error) {
+	if from == uuid.Nil || to == uuid.Nil || from == to {
+		return Transaction{}, ErrInvalidParties
+	}
+	if !amount.IsPositive() || amount.GetCurrency() == "" {
+		return Transaction{}, ErrInvalidAmount
+	}
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
+	return Transaction{
+		from:      from,
+		amount:    amount,
+		to:        to,
+		createdAt: createdAt,
+	}, nil
+}
+
+func (t Transaction) GetFrom() uuid.UUID {
+	return t.from
+}
+
+func (t Transaction) GetTo() uuid.UUID {
+	return t.to
+}
+
+func (t Transaction) GetAmount() Money {
+	return t.amount
+}
+
+func (t Transaction) GetCreatedAt() time.Time {
+	return t.createdAt
+}
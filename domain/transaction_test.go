@@ -0,0 +1,68 @@
+package domain_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/devsrivatsa/tavernDDD/domain"
+	"github.com/google/uuid"
+)
+
+func TestTransaction_NewTransaction(t *testing.T) {
+	type testCase struct {
+		test          string
+		from          uuid.UUID
+		to            uuid.UUID
+		amount        domain.Money
+		expectedError error
+	}
+
+	customer := uuid.New()
+	tavern := uuid.New()
+	testcases := []testCase{
+		{
+			test:          "Missing party",
+			from:          customer,
+			to:            uuid.Nil,
+			amount:        domain.MustNewMoney(199, "EUR"),
+			expectedError: domain.ErrInvalidParties,
+		},
+		{
+			test:          "Same party",
+			from:          customer,
+			to:            customer,
+			amount:        domain.MustNewMoney(199, "EUR"),
+			expectedError: domain.ErrInvalidParties,
+		},
+		{
+			test:          "Zero amount",
+			from:          customer,
+			to:            tavern,
+			amount:        domain.MustNewMoney(0, "EUR"),
+			expectedError: domain.ErrInvalidAmount,
+		},
+		{
+			test:          "Missing amount",
+			from:          customer,
+			to:            tavern,
+			expectedError: domain.ErrInvalidAmount,
+		},
+		{
+			test:          "Valid transaction",
+			from:          customer,
+			to:            tavern,
+			amount:        domain.MustNewMoney(199, "EUR"),
+			expectedError: nil,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.test, func(t *testing.T) {
+			_, err := domain.NewTransaction(tc.from, tc.to, tc.amount, time.Now())
+			if !errors.Is(err, tc.expectedError) {
+				t.Errorf("expected error %v, got %v", tc.expectedError, err)
+			}
+		})
+	}
+}
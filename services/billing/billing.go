@@ -0,0 +1,70 @@
+package billing
+
+import (
+	"errors"
+	"time"
+
+	"github.com/devsrivatsa/tavernDDD/domain"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidInvoice     = errors.New("an invoice needs a customer and an amount greater than zero")
+	ErrInvoiceNotFound    = errors.New("invoice not found")
+	ErrInvoiceAlreadyPaid = errors.New("invoice is already paid")
+	ErrInvalidSettlement  = errors.New("a settlement must be greater than zero and cannot exceed the amount due")
+	ErrInvoiceVoid        = errors.New("invoice has been voided")
+)
+
+type Status string
+
+const (
+	StatusUnpaid Status = "unpaid"
+	StatusPaid   Status = "paid"
+	StatusVoid   Status = "void"
+)
+
+// Settlement is a value object recording a payment made against an invoice
+type Settlement struct {
+	Amount    domain.Money
+	SettledAt time.Time
+}
+
+// Invoice is what the customer is billed for one or more orders
+type Invoice struct {
+	ID          uuid.UUID
+	CustomerID  uuid.UUID
+	OrderIDs    []uuid.UUID
+	Amount      domain.Money
+	Status      Status
+	Settlements []Settlement
+	IssuedAt    time.Time
+	PaidAt      time.Time
+}
+
+// AmountDue is the invoice amount minus everything settled so far
+func (i Invoice) AmountDue() (domain.Money, error) {
+	due := i.Amount
+	for _, s := range i.Settlements {
+		var err error
+		due, err = due.Sub(s.Amount)
+		if err != nil {
+			return domain.Money{}, err
+		}
+	}
+
+	return due, nil
+}
+
+// Service issues invoices to customers and keeps track of what has been paid
+type Service interface {
+	// Issue bills the customer for the given orders
+	Issue(customerID uuid.UUID, amount domain.Money, orderIDs ...uuid.UUID) (Invoice, error)
+	Get(id uuid.UUID) (Invoice, error)
+	// Settle records a payment against the invoice, the invoice is paid once nothing is due anymore
+	Settle(id uuid.UUID, amount domain.Money) (Invoice, error)
+	// Void cancels an invoice nothing has been settled on yet
+	Void(id uuid.UUID) (Invoice, error)
+	// Unpaid lists the open invoices of a customer, oldest first
+	Unpaid(customerID uuid.UUID) ([]Invoice, error)
+}
@@ -0,0 +1,141 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/devsrivatsa/tavernDDD/domain"
+	"github.com/devsrivatsa/tavernDDD/services/billing"
+	"github.com/google/uuid"
+)
+
+type MemoryBillingService struct {
+	invoices map[uuid.UUID]billing.Invoice
+	sync.Mutex
+}
+
+func New() *MemoryBillingService {
+	return &MemoryBillingService{
+		invoices: make(map[uuid.UUID]billing.Invoice),
+	}
+}
+
+func (m *MemoryBillingService) Issue(customerID uuid.UUID, amount domain.Money, orderIDs ...uuid.UUID) (billing.Invoice, error) {
+	if customerID == uuid.Nil || !amount.IsPositive() {
+		return billing.Invoice{}, billing.ErrInvalidInvoice
+	}
+	m.Lock()
+	defer m.Unlock()
+
+	invoice := billing.Invoice{
+		ID:          uuid.New(),
+		CustomerID:  customerID,
+		OrderIDs:    append([]uuid.UUID(nil), orderIDs...),
+		Amount:      amount,
+		Status:      billing.StatusUnpaid,
+		Settlements: make([]billing.Settlement, 0),
+		IssuedAt:    time.Now(),
+	}
+	m.invoices[invoice.ID] = invoice
+
+	return copyInvoice(invoice), nil
+}
+
+func (m *MemoryBillingService) Get(id uuid.UUID) (billing.Invoice, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	if invoice, ok := m.invoices[id]; ok {
+		return copyInvoice(invoice), nil
+	}
+
+	return billing.Invoice{}, billing.ErrInvoiceNotFound
+}
+
+func (m *MemoryBillingService) Settle(id uuid.UUID, amount domain.Money) (billing.Invoice, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	invoice, ok := m.invoices[id]
+	if !ok {
+		return billing.Invoice{}, billing.ErrInvoiceNotFound
+	}
+	switch invoice.Status {
+	case billing.StatusPaid:
+		return billing.Invoice{}, billing.ErrInvoiceAlreadyPaid
+	case billing.StatusVoid:
+		return billing.Invoice{}, billing.ErrInvoiceVoid
+	}
+	due, err := invoice.AmountDue()
+	if err != nil {
+		return billing.Invoice{}, err
+	}
+	tooMuch, err := amount.GreaterThan(due)
+	if err != nil {
+		return billing.Invoice{}, fmt.Errorf("%w: %w", billing.ErrInvalidSettlement, err)
+	}
+	if !amount.IsPositive() || tooMuch {
+		return billing.Invoice{}, fmt.Errorf("settling %s with %s due: %w", amount, due, billing.ErrInvalidSettlement)
+	}
+
+	now := time.Now()
+	invoice.Settlements = append(invoice.Settlements[:len(invoice.Settlements):len(invoice.Settlements)], billing.Settlement{
+		Amount:    amount,
+		SettledAt: now,
+	})
+	if amount.Equals(due) {
+		invoice.Status = billing.StatusPaid
+		invoice.PaidAt = now
+	}
+	m.invoices[id] = invoice
+
+	return copyInvoice(invoice), nil
+}
+
+func (m *MemoryBillingService) Void(id uuid.UUID) (billing.Invoice, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	invoice, ok := m.invoices[id]
+	if !ok {
+		return billing.Invoice{}, billing.ErrInvoiceNotFound
+	}
+	switch {
+	case invoice.Status == billing.StatusPaid:
+		return billing.Invoice{}, billing.ErrInvoiceAlreadyPaid
+	case invoice.Status == billing.StatusVoid:
+		return billing.Invoice{}, billing.ErrInvoiceVoid
+	case len(invoice.Settlements) > 0:
+		return billing.Invoice{}, fmt.Errorf("invoice %s is partly settled: %w", id, billing.ErrInvoiceAlreadyPaid)
+	}
+	invoice.Status = billing.StatusVoid
+	m.invoices[id] = invoice
+
+	return copyInvoice(invoice), nil
+}
+
+func (m *MemoryBillingService) Unpaid(customerID uuid.UUID) ([]billing.Invoice, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	var invoices []billing.Invoice
+	for _, invoice := range m.invoices {
+		if invoice.CustomerID == customerID && invoice.Status == billing.StatusUnpaid {
+			invoices = append(invoices, copyInvoice(invoice))
+		}
+	}
+	sort.Slice(invoices, func(i, j int) bool {
+		return invoices[i].IssuedAt.Before(invoices[j].IssuedAt)
+	})
+
+	return invoices, nil
+}
+
+// copyInvoice makes sure callers never share slices with the stored invoice
+func copyInvoice(i billing.Invoice) billing.Invoice {
+	i.OrderIDs = append([]uuid.UUID(nil), i.OrderIDs...)
+	i.Settlements = append([]billing.Settlement(nil), i.Settlements...)
+	return i
+}
@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/devsrivatsa/tavernDDD/domain"
+	"github.com/devsrivatsa/tavernDDD/services/billing"
+	"github.com/google/uuid"
+)
+
+func TestMemoryBillingService_Issue(t *testing.T) {
+	type testCase struct {
+		test          string
+		customerID    uuid.UUID
+		amount        domain.Money
+		expectedError error
+	}
+
+	testcases := []testCase{
+		{
+			test:          "Missing customer",
+			customerID:    uuid.Nil,
+			amount:        domain.MustNewMoney(897, "EUR"),
+			expectedError: billing.ErrInvalidInvoice,
+		},
+		{
+			test:          "Nothing to bill",
+			customerID:    uuid.New(),
+			amount:        domain.MustNewMoney(0, "EUR"),
+			expectedError: billing.ErrInvalidInvoice,
+		},
+		{
+			test:          "Valid invoice",
+			customerID:    uuid.New(),
+			amount:        domain.MustNewMoney(897, "EUR"),
+			expectedError: nil,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.test, func(t *testing.T) {
+			_, err := New().Issue(tc.customerID, tc.amount, uuid.New())
+			if !errors.Is(err, tc.expectedError) {
+				t.Errorf("expected error %v, got %v", tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestMemoryBillingService_Settle(t *testing.T) {
+	bs := New()
+	customerID := uuid.New()
+	invoice, err := bs.Issue(customerID, domain.MustNewMoney(897, "EUR"), uuid.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := bs.Settle(invoice.ID, domain.MustNewMoney(1000, "EUR")); !errors.Is(err, billing.ErrInvalidSettlement) {
+		t.Errorf("expected error %v, got %v", billing.ErrInvalidSettlement, err)
+	}
+	invoice, err = bs.Settle(invoice.ID, domain.MustNewMoney(500, "EUR"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if invoice.Status != billing.StatusUnpaid {
+		t.Errorf("expected a partly settled invoice to stay unpaid, got %s", invoice.Status)
+	}
+	unpaid, err := bs.Unpaid(customerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unpaid) != 1 {
+		t.Errorf("expected 1 unpaid invoice, got %d", len(unpaid))
+	}
+
+	invoice, err = bs.Settle(invoice.ID, domain.MustNewMoney(397, "EUR"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if invoice.Status != billing.StatusPaid || invoice.PaidAt.IsZero() || len(invoice.Settlements) != 2 {
+		t.Errorf("expected a paid invoice with 2 settlements, got %s with %d", invoice.Status, len(invoice.Settlements))
+	}
+	if _, err := bs.Settle(invoice.ID, domain.MustNewMoney(1, "EUR")); !errors.Is(err, billing.ErrInvoiceAlreadyPaid) {
+		t.Errorf("expected error %v, got %v", billing.ErrInvoiceAlreadyPaid, err)
+	}
+}
@@ -0,0 +1,138 @@
+package order
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/devsrivatsa/tavernDDD/domain"
+	"github.com/devsrivatsa/tavernDDD/domain/customer"
+	ord "github.com/devsrivatsa/tavernDDD/domain/order"
+)
+
+var (
+	ErrInvalidLoyaltyProgram = errors.New("a loyalty program needs positive earn and burn amounts in the same currency")
+	ErrInvalidTier           = errors.New("a tier needs a name, a non-negative threshold and a non-negative earn percentage")
+	ErrNoLoyaltyProgram      = errors.New("the tavern has no loyalty program")
+	ErrTooManyPoints         = errors.New("the points redeemed are worth more than the order")
+)
+
+// Tier is a loyalty level, customers reach it once their lifetime points reach MinPoints
+type Tier struct {
+	Name      string
+	MinPoints int64
+	// EarnPercent scales the points earned, 100 earns the base rate and 150 half as much again
+	EarnPercent int64
+}
+
+// TierRegular is the tier every customer starts in, unless the program defines another tier from 0 points
+var TierRegular = Tier{Name: "Regular", MinPoints: 0, EarnPercent: 100}
+
+// LoyaltyProgram decides how many points customers earn for what they spend and what
+// redeeming points is worth
+type LoyaltyProgram struct {
+	//earnPer is the amount to spend to earn one point
+	earnPer domain.Money
+	//pointValue is the discount a redeemed point is worth
+	pointValue domain.Money
+	//tiers sorted by MinPoints, the first one starts at 0
+	tiers []Tier
+}
+
+// NewLoyaltyProgram creates a program where customers earn a point for every earnPer they spend
+// and every point redeemed takes pointValue off an order, e.g. a point per 1.00 EUR worth 0.05 EUR.
+func NewLoyaltyProgram(earnPer, pointValue domain.Money, tiers ...Tier) (*LoyaltyProgram, error) {
+	if !earnPer.IsPositive() || !pointValue.IsPositive() || earnPer.GetCurrency() != pointValue.GetCurrency() {
+		return nil, ErrInvalidLoyaltyProgram
+	}
+	sorted := make([]Tier, 0, len(tiers)+1)
+	for _, t := range tiers {
+		if t.Name == "" || t.MinPoints < 0 || t.EarnPercent < 0 {
+			return nil, fmt.Errorf("tier %q: %w", t.Name, ErrInvalidTier)
+		}
+		sorted = append(sorted, t)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].MinPoints < sorted[j].MinPoints
+	})
+	if len(sorted) == 0 || sorted[0].MinPoints > 0 {
+		sorted = append([]Tier{TierRegular}, sorted...)
+	}
+
+	return &LoyaltyProgram{earnPer: earnPer, pointValue: pointValue, tiers: sorted}, nil
+}
+
+// TierOf returns the highest tier the customer reached
+func (lp *LoyaltyProgram) TierOf(c customer.Customer) Tier {
+	lifetime := c.GetLifetimePoints()
+	tier := lp.tiers[0]
+	for _, t := range lp.tiers[1:] {
+		if lifetime >= t.MinPoints {
+			tier = t
+		}
+	}
+	return tier
+}
+
+// Earned returns the points the customer earns for spending the amount, partial points are dropped
+func (lp *LoyaltyProgram) Earned(c customer.Customer, spent domain.Money) (int64, error) {
+	if _, err := spent.Compare(lp.earnPer); err != nil {
+		return 0, err
+	}
+	if !spent.IsPositive() {
+		return 0, nil
+	}
+	return spent.GetAmount() * lp.TierOf(c).EarnPercent / (lp.earnPer.GetAmount() * 100), nil
+}
+
+// Value returns the discount the points are worth
+func (lp *LoyaltyProgram) Value(points int64) domain.Money {
+	return lp.pointValue.Multiply(points)
+}
+
+// redeemPoints spreads the value of the points over the lines as a discount, in proportion
+// to what is left of each line after the other discounts
+func (o *OrderService) redeemPoints(lines []ord.Line, c customer.Customer, points int64) error {
+	if o.loyalty == nil {
+		return ErrNoLoyaltyProgram
+	}
+	if balance := c.GetPoints(); balance < points {
+		return fmt.Errorf("redeeming %d of %d points: %w", points, balance, customer.ErrInsufficientPoints)
+	}
+
+	value := o.loyalty.Value(points)
+	left := value.Multiply(0)
+	ratios := make([]int, len(lines))
+	for i, l := range lines {
+		discount, err := l.GetDiscount()
+		if err != nil {
+			return err
+		}
+		rest, err := l.GetSubtotal().Sub(discount)
+		if err != nil {
+			return err
+		}
+		if left, err = left.Add(rest); err != nil {
+			return err
+		}
+		ratios[i] = int(rest.GetAmount())
+	}
+	if tooMuch, err := value.GreaterThan(left); err != nil || tooMuch {
+		return fmt.Errorf("%d points worth %s on %s: %w", points, value, left, ErrTooManyPoints)
+	}
+
+	shares, err := value.Allocate(ratios...)
+	if err != nil {
+		return err
+	}
+	for i, share := range shares {
+		if share.IsPositive() {
+			lines[i].Discounts = append(lines[i].Discounts, ord.Discount{
+				Name:   fmt.Sprintf("%d loyalty points", points),
+				Amount: share,
+			})
+		}
+	}
+
+	return nil
+}
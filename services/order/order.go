@@ -1,15 +1,38 @@
 package order
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"log"
+	"time"
 
+	"github.com/devsrivatsa/tavernDDD/domain"
 	"github.com/devsrivatsa/tavernDDD/domain/customer"
 	custMem "github.com/devsrivatsa/tavernDDD/domain/customer/memory"
+	custSQL "github.com/devsrivatsa/tavernDDD/domain/customer/sql"
+	"github.com/devsrivatsa/tavernDDD/domain/menu"
+	menuMem "github.com/devsrivatsa/tavernDDD/domain/menu/memory"
+	ord "github.com/devsrivatsa/tavernDDD/domain/order"
+	ordMem "github.com/devsrivatsa/tavernDDD/domain/order/memory"
 	"github.com/devsrivatsa/tavernDDD/domain/product"
 	prdMem "github.com/devsrivatsa/tavernDDD/domain/product/memory"
+	prdMongo "github.com/devsrivatsa/tavernDDD/domain/product/mongo"
+	prdSQL "github.com/devsrivatsa/tavernDDD/domain/product/sql"
+	"github.com/devsrivatsa/tavernDDD/domain/promotion"
+	"github.com/devsrivatsa/tavernDDD/domain/staff"
+	staffMem "github.com/devsrivatsa/tavernDDD/domain/staff/memory"
 	"github.com/google/uuid"
 )
 
+var (
+	ErrNoProducts      = errors.New("an order needs at least one product")
+	ErrInvalidAccount  = errors.New("the tavern account cannot be empty")
+	ErrAgeRestricted   = errors.New("the customer is not verified to be old enough for the product")
+	ErrVariantRequired = errors.New("the product is sold in several sizes, order one of its variants")
+)
+
 // OrderConfiguration is a function that configures the order service
 // it takes a pointer to the order service and returns an error
 // (service configuration generator pattern)
@@ -19,11 +42,36 @@ type OrderConfiguration func(os *OrderService) error
 type OrderService struct {
 	customers customer.CustomerRepository
 	products  product.ProductRepository
+	orders    ord.OrderRepository
+	//menus decide what can be ordered, every product in stock can be ordered without them
+	menus menu.MenuRepository
+	//staff take the orders, orders are not attributed without it
+	staff staff.StaffRepository
+	//account is the party customers pay into
+	account    uuid.UUID
+	tax        TaxPolicy
+	promotions *promotion.Engine
+	loyalty    *LoyaltyProgram
+	now        func() time.Time
 }
 
-// factory function to create a new order service
+// maxCustomerUpdates is how many times a customer change is tried when the customer
+// keeps being changed concurrently, e.g. by two orders at once
+const maxCustomerUpdates = 5
+
+// maxOrderUpdates is how many times an order change is tried when the order keeps being
+// changed concurrently, e.g. by two refunds at once
+const maxOrderUpdates = 5
+
+// factory function to create a new order service, orders are kept in memory unless
+// another repository is configured
 func NewOrderService(cfgs ...OrderConfiguration) (*OrderService, error) {
-	os := &OrderService{}
+	os := &OrderService{
+		orders:  ordMem.New(),
+		account: uuid.New(),
+		tax:     noTax{},
+		now:     time.Now,
+	}
 	// apply all configurations to the order service
 	for _, cfg := range cfgs {
 		err := cfg(os)
@@ -66,12 +114,19 @@ func WithMemoryCustomerRepository() OrderConfiguration {
 
 */
 
+func WithProductRepository(pr product.ProductRepository) OrderConfiguration {
+	return func(os *OrderService) error {
+		os.products = pr
+		return nil
+	}
+}
+
 func WithMemoryProductRepository(products []product.Product) OrderConfiguration {
 
 	return func(os *OrderService) error {
 		pr := prdMem.New()
 		for _, newPrd := range products {
-			err := pr.Add(newPrd)
+			err := pr.Add(context.Background(), newPrd)
 			if err != nil {
 				return err
 			}
@@ -82,37 +137,516 @@ func WithMemoryProductRepository(products []product.Product) OrderConfiguration
 	}
 }
 
-func (o *OrderService) CreateOrder(curstomerID uuid.UUID, productsID []uuid.UUID) (float64, error) {
+// WithMongoProductRepository keeps the catalog in the Mongo database at connString
+func WithMongoProductRepository(ctx context.Context, connString string) OrderConfiguration {
+	return func(os *OrderService) error {
+		pr, err := prdMongo.New(ctx, connString)
+		if err != nil {
+			return err
+		}
+		os.products = pr
+		return nil
+	}
+}
+
+// WithSQLRepositories keeps customers and the catalog in the database, bringing their tables
+// up to date first. The caller opens the database with the driver of its choice.
+func WithSQLRepositories(ctx context.Context, db *sql.DB) OrderConfiguration {
+	return func(os *OrderService) error {
+		cr, err := custSQL.New(ctx, db)
+		if err != nil {
+			return err
+		}
+		pr, err := prdSQL.New(ctx, db)
+		if err != nil {
+			return err
+		}
+		os.customers = cr
+		os.products = pr
+
+		return nil
+	}
+}
+
+func WithOrderRepository(or ord.OrderRepository) OrderConfiguration {
+	return func(os *OrderService) error {
+		os.orders = or
+		return nil
+	}
+}
+
+func WithMemoryOrderRepository() OrderConfiguration {
+	return WithOrderRepository(ordMem.New())
+}
+
+func WithMenuRepository(mr menu.MenuRepository) OrderConfiguration {
+	return func(os *OrderService) error {
+		os.menus = mr
+		return nil
+	}
+}
+
+func WithMemoryMenuRepository(menus ...menu.Menu) OrderConfiguration {
+	return func(os *OrderService) error {
+		mr := menuMem.New()
+		for _, m := range menus {
+			if err := mr.Add(context.Background(), m); err != nil {
+				return err
+			}
+		}
+		os.menus = mr
+
+		return nil
+	}
+}
+
+func WithStaffRepository(sr staff.StaffRepository) OrderConfiguration {
+	return func(os *OrderService) error {
+		os.staff = sr
+		return nil
+	}
+}
+
+func WithMemoryStaffRepository(members ...staff.Member) OrderConfiguration {
+	return func(os *OrderService) error {
+		sr := staffMem.New()
+		for _, m := range members {
+			if err := sr.Add(context.Background(), m); err != nil {
+				return err
+			}
+		}
+		os.staff = sr
+
+		return nil
+	}
+}
+
+// WithAccount sets the account customer payments are booked to, a random one is used otherwise
+func WithAccount(id uuid.UUID) OrderConfiguration {
+	return func(os *OrderService) error {
+		if id == uuid.Nil {
+			return ErrInvalidAccount
+		}
+		os.account = id
+		return nil
+	}
+}
+
+// WithTaxPolicy sets how tax is worked out on order lines, no tax is charged otherwise
+func WithTaxPolicy(tp TaxPolicy) OrderConfiguration {
+	return func(os *OrderService) error {
+		if tp == nil {
+			return ErrInvalidTaxRate
+		}
+		os.tax = tp
+		return nil
+	}
+}
+
+// WithPromotions runs the promotions of the engine on every order
+func WithPromotions(engine *promotion.Engine) OrderConfiguration {
+	return func(os *OrderService) error {
+		os.promotions = engine
+		return nil
+	}
+}
+
+// WithClock replaces the clock used for time based rules such as happy hours
+func WithClock(now func() time.Time) OrderConfiguration {
+	return func(os *OrderService) error {
+		os.now = now
+		return nil
+	}
+}
+
+// WithLoyaltyProgram lets customers earn points on their orders and redeem them on later ones
+func WithLoyaltyProgram(lp *LoyaltyProgram) OrderConfiguration {
+	return func(os *OrderService) error {
+		if lp == nil {
+			return ErrInvalidLoyaltyProgram
+		}
+		os.loyalty = lp
+		return nil
+	}
+}
+
+// CreateOrder places an order for the customer. Every distinct product in the request
+// is looked up once and snapshotted into an order line.
+func (o *OrderService) CreateOrder(ctx context.Context, curstomerID uuid.UUID, req Request) (ord.Order, error) {
+	req, err := req.Normalize()
+	if err != nil {
+		return ord.Order{}, err
+	}
+	if err := o.checkStaff(ctx, req); err != nil {
+		log.Printf("error checking staff: %v", err)
+		return ord.Order{}, err
+	}
 	//fetch the customer
 
-	customer, err := o.customers.Get(curstomerID)
+	cust, err := o.customers.Get(ctx, curstomerID)
 	if err != nil {
 		log.Printf("error fetching customer: %v", err)
-		return 0, err
+		return ord.Order{}, err
 	}
-	//fetch the products
-	var products []product.Product
-	var totalPrice float64
-	for _, id := range productsID {
-		prd, err := o.products.GetByID(id)
-		if err != nil {
-			log.Printf("error fetching product: %v", err)
-			return 0, err
+	//fetch the products, variants of the same product share one lookup
+	products := make([]product.Product, 0, len(req.Lines))
+	fetched := make(map[uuid.UUID]product.Product)
+	for _, rl := range req.Lines {
+		prd, ok := fetched[rl.ProductID]
+		if !ok {
+			prd, err = o.products.GetByID(ctx, rl.ProductID)
+			if err != nil {
+				log.Printf("error fetching product: %v", err)
+				return ord.Order{}, err
+			}
+			fetched[rl.ProductID] = prd
 		}
 		products = append(products, prd)
-		totalPrice += prd.GetPrice()
 	}
-	log.Printf("Customer %s is ordering %d products for a total of %.2f", customer.GetName(), len(products), totalPrice)
+	if err := o.checkMenu(ctx, products); err != nil {
+		log.Printf("error checking menu: %v", err)
+		return ord.Order{}, err
+	}
+	if err := o.checkAge(cust, products); err != nil {
+		log.Printf("error checking age: %v", err)
+		return ord.Order{}, err
+	}
+	lines, err := o.priceLines(cust, req, products)
+	if err != nil {
+		log.Printf("error pricing order: %v", err)
+		return ord.Order{}, err
+	}
+
+	order, err := ord.NewOrder(cust.GetID(), lines)
+	if err != nil {
+		log.Printf("error creating order: %v", err)
+		return ord.Order{}, err
+	}
+	order.SetTableID(req.TableID)
+	order.SetTakenBy(req.StaffID)
+	if err := o.takeStock(ctx, lines); err != nil {
+		log.Printf("error taking stock: %v", err)
+		return ord.Order{}, err
+	}
+	if err := o.recordOnCustomer(ctx, order, req.RedeemPoints); err != nil {
+		log.Printf("error recording order on customer: %v", err)
+		o.returnStock(ctx, lines)
+		return ord.Order{}, err
+	}
+	if err := o.orders.Add(ctx, order); err != nil {
+		log.Printf("error saving order: %v", err)
+		o.returnStock(ctx, lines)
+		if err := o.undoOnCustomer(context.WithoutCancel(ctx), order); err != nil {
+			log.Printf("error reimbursing customer for unsaved order %s: %v", order.GetID(), err)
+		}
+		return ord.Order{}, err
+	}
+	log.Printf("Customer %s is ordering %d products for a total of %s", cust.GetName(), len(lines), order.GetTotal())
+
+	return order, nil
+}
+
+// checkMenu refuses products that are not available on the active menu right now
+func (o *OrderService) checkMenu(ctx context.Context, products []product.Product) error {
+	if o.menus == nil {
+		return nil
+	}
+	active, err := o.menus.GetActive(ctx)
+	if err != nil {
+		return err
+	}
+	now := o.now()
+	for _, prd := range products {
+		if err := active.AvailableAt(prd.GetID(), now); err != nil {
+			return fmt.Errorf("%s on menu %s: %w", prd.GetItem().Name, active.GetName(), err)
+		}
+	}
+
+	return nil
+}
+
+// checkAge refuses age restricted products to customers without a verified date of birth
+// showing they are old enough
+func (o *OrderService) checkAge(cust customer.Customer, products []product.Product) error {
+	age, verified := cust.GetVerifiedAgeAt(o.now())
+	for _, prd := range products {
+		if prd.GetMinAge() == 0 {
+			continue
+		}
+		if !verified {
+			return fmt.Errorf("%s is for %d and over and the customer's age is not verified: %w", prd.GetItem().Name, prd.GetMinAge(), ErrAgeRestricted)
+		}
+		if age < prd.GetMinAge() {
+			return fmt.Errorf("%s is for %d and over and the customer is %d: %w", prd.GetItem().Name, prd.GetMinAge(), age, ErrAgeRestricted)
+		}
+	}
+
+	return nil
+}
+
+// recordOnCustomer adds the ordered items to the purchase history of the customer,
+// books the order total as a payment from the customer to the tavern account
+// and spends and earns the loyalty points of the order
+func (o *OrderService) recordOnCustomer(ctx context.Context, order ord.Order, redeemed int64) error {
+	return o.updateCustomer(ctx, order.GetCustomerID(), func(c *customer.Customer) error {
+		if redeemed > 0 {
+			if err := c.RedeemPoints(order.GetID(), redeemed, order.GetCreatedAt()); err != nil {
+				return err
+			}
+		}
+		if o.loyalty != nil {
+			earned, err := o.loyalty.Earned(*c, order.GetTotal())
+			if err != nil {
+				return err
+			}
+			if earned > 0 {
+				if err := c.EarnPoints(order.GetID(), earned, order.GetCreatedAt()); err != nil {
+					return err
+				}
+			}
+		}
+		for _, l := range order.GetLines() {
+			err := c.AddPurchase(customer.Purchase{
+				OrderID:     order.GetID(),
+				Item:        domain.Item{ID: l.ProductID, Name: l.Name},
+				Quantity:    l.Quantity,
+				PurchasedAt: order.GetCreatedAt(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if !order.GetTotal().IsPositive() {
+			return nil
+		}
+		payment, err := domain.NewTransaction(order.GetCustomerID(), o.account, order.GetTotal(), order.GetCreatedAt())
+		if err != nil {
+			return err
+		}
+		return c.AddTransaction(payment)
+	})
+}
+
+// undoOnCustomer reverses recordOnCustomer with a compensating transaction from the tavern account
+func (o *OrderService) undoOnCustomer(ctx context.Context, order ord.Order) error {
+	return o.updateCustomer(ctx, order.GetCustomerID(), func(c *customer.Customer) error {
+		c.RemovePurchases(order.GetID())
+		c.ReversePoints(order.GetID(), o.now())
+		if !order.GetTotal().IsPositive() {
+			return nil
+		}
+		refund, err := domain.NewTransaction(o.account, order.GetCustomerID(), order.GetTotal(), o.now())
+		if err != nil {
+			return err
+		}
+		return c.AddTransaction(refund)
+	})
+}
+
+// updateCustomer loads the customer, applies change and stores the result. If the customer
+// was changed in the meantime, change is applied again to the fresh customer.
+func (o *OrderService) updateCustomer(ctx context.Context, id uuid.UUID, change func(c *customer.Customer) error) error {
+	var err error
+	for i := 0; i < maxCustomerUpdates; i++ {
+		var c customer.Customer
+		c, err = o.customers.Get(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := change(&c); err != nil {
+			return err
+		}
+		err = o.customers.Update(ctx, c)
+		if !errors.Is(err, customer.ErrConcurrentUpdate) {
+			return err
+		}
+	}
+
+	return err
+}
+
+// ConfirmOrder accepts a placed order, authorizationID references the card payment backing it
+func (o *OrderService) ConfirmOrder(ctx context.Context, orderID uuid.UUID, authorizationID string) (ord.Order, error) {
+	return o.updateOrder(ctx, orderID, func(order *ord.Order) error {
+		return order.Confirm(authorizationID)
+	})
+}
+
+// RejectOrder rolls a placed order back: the stock is returned and the customer is reimbursed
+func (o *OrderService) RejectOrder(ctx context.Context, orderID uuid.UUID) (ord.Order, error) {
+	order, err := o.updateOrder(ctx, orderID, func(order *ord.Order) error {
+		return order.Reject()
+	})
+	if err != nil {
+		return ord.Order{}, err
+	}
+
+	return o.rollBack(ctx, order)
+}
+
+// CancelOrder calls off an order that has not been paid yet. The stock is returned
+// and the customer is reimbursed. Paid orders fail with ord.ErrAlreadyPaid and need a refund instead.
+// Staff void orders with VoidOrder instead, which checks they are allowed to and records who did.
+func (o *OrderService) CancelOrder(ctx context.Context, orderID uuid.UUID) (ord.Order, error) {
+	order, err := o.updateOrder(ctx, orderID, func(order *ord.Order) error {
+		return order.Cancel()
+	})
+	if err != nil {
+		return ord.Order{}, err
+	}
+
+	return o.rollBack(ctx, order)
+}
+
+// RefundLines refunds part of a paid order. The refunded items go back into stock and the
+// customer is reimbursed for them, the points earned with them are taken back. It returns the
+// updated order and the amount refunded.
+func (o *OrderService) RefundLines(ctx context.Context, orderID uuid.UUID, refunds []ord.LineRefund) (ord.Order, domain.Money, error) {
+	return o.RefundLinesWith(ctx, orderID, refunds, nil)
+}
+
+// RefundLinesWith refunds part of a paid order as RefundLines does, giving the money back with
+// pay once the refund is recorded on the order. If pay fails the refund is undone on the order,
+// and neither the stock nor the customer are touched.
+func (o *OrderService) RefundLinesWith(ctx context.Context, orderID uuid.UUID, refunds []ord.LineRefund, pay func(order ord.Order, amount domain.Money) error) (ord.Order, domain.Money, error) {
+	var refunded []ord.Line
+	order, err := o.updateOrder(ctx, orderID, func(order *ord.Order) error {
+		var err error
+		refunded, err = order.RefundLines(refunds)
+		return err
+	})
+	if err != nil {
+		return ord.Order{}, domain.Money{}, err
+	}
+	// the lines are refunded, the rest follows even when the request was called off
+	ctx = context.WithoutCancel(ctx)
+	amount, err := ord.SumLines(refunded)
+	if err != nil {
+		return ord.Order{}, domain.Money{}, err
+	}
+	if pay != nil && amount.IsPositive() {
+		if err := pay(order, amount); err != nil {
+			_, uerr := o.updateOrder(ctx, orderID, func(order *ord.Order) error {
+				return order.UndoRefund(refunds)
+			})
+			if uerr != nil {
+				log.Printf("error undoing the refund of order %s: %v", orderID, uerr)
+			}
+			return ord.Order{}, domain.Money{}, err
+		}
+	}
+	o.returnStock(ctx, refunded)
+
+	if !amount.IsPositive() {
+		return order, amount, nil
+	}
+	total, err := order.GetRefunded()
+	if err != nil {
+		return ord.Order{}, domain.Money{}, err
+	}
+	err = o.updateCustomer(ctx, order.GetCustomerID(), func(c *customer.Customer) error {
+		if err := c.RefundPoints(order.GetID(), total, order.GetTotal(), o.now()); err != nil {
+			return err
+		}
+		refund, err := domain.NewTransaction(o.account, order.GetCustomerID(), amount, o.now())
+		if err != nil {
+			return err
+		}
+		return c.AddTransaction(refund)
+	})
+	if err != nil {
+		return ord.Order{}, domain.Money{}, fmt.Errorf("order %s refunded but the customer was not reimbursed: %w", orderID, err)
+	}
+
+	return order, amount, nil
+}
+
+// rollBack undoes the side effects of creating the order. The order has changed already, so it
+// goes on even when the request was called off.
+func (o *OrderService) rollBack(ctx context.Context, order ord.Order) (ord.Order, error) {
+	ctx = context.WithoutCancel(ctx)
+	o.returnStock(ctx, order.GetLines())
+	if err := o.undoOnCustomer(ctx, order); err != nil {
+		return ord.Order{}, fmt.Errorf("order %s is %s but the customer was not reimbursed: %w", order.GetID(), order.GetStatus(), err)
+	}
+
+	return order, nil
+}
+
+// MarkOrderPaid records that the confirmed order has been paid for
+func (o *OrderService) MarkOrderPaid(ctx context.Context, orderID uuid.UUID) (ord.Order, error) {
+	return o.updateOrder(ctx, orderID, func(order *ord.Order) error {
+		return order.MarkPaid()
+	})
+}
+
+// MarkOrderPaidBy records that the confirmed order has been paid for with the card payment,
+// so it can be refunded on the card later
+func (o *OrderService) MarkOrderPaidBy(ctx context.Context, orderID uuid.UUID, authorizationID string) (ord.Order, error) {
+	return o.updateOrder(ctx, orderID, func(order *ord.Order) error {
+		return order.MarkPaidBy(authorizationID)
+	})
+}
+
+// updateOrder loads the order, applies change and stores the result. If the order was changed
+// in the meantime, change is applied again to the fresh order, so it is checked against it.
+func (o *OrderService) updateOrder(ctx context.Context, id uuid.UUID, change func(order *ord.Order) error) (ord.Order, error) {
+	var err error
+	for i := 0; i < maxOrderUpdates; i++ {
+		var order ord.Order
+		order, err = o.orders.Get(ctx, id)
+		if err != nil {
+			return ord.Order{}, err
+		}
+		if err := change(&order); err != nil {
+			return ord.Order{}, err
+		}
+		err = o.orders.Update(ctx, order)
+		if err == nil {
+			return order, nil
+		}
+		if !errors.Is(err, ord.ErrConcurrentUpdate) {
+			return ord.Order{}, err
+		}
+	}
+
+	return ord.Order{}, err
+}
+
+func (o *OrderService) GetOrder(ctx context.Context, id uuid.UUID) (ord.Order, error) {
+	return o.orders.Get(ctx, id)
+}
+
+func (o *OrderService) GetCustomerOrders(ctx context.Context, customerID uuid.UUID) ([]ord.Order, error) {
+	return o.orders.GetByCustomer(ctx, customerID)
+}
+
+// SetDateOfBirth records the date of birth of a customer, verified tells whether staff checked it against an ID
+func (o *OrderService) SetDateOfBirth(ctx context.Context, customerID uuid.UUID, dob time.Time, verified bool) error {
+	return o.updateCustomer(ctx, customerID, func(c *customer.Customer) error {
+		return c.SetDateOfBirth(dob, verified)
+	})
+}
+
+// GetLoyalty returns the points the customer can redeem and the tier they reached
+func (o *OrderService) GetLoyalty(ctx context.Context, customerID uuid.UUID) (int64, Tier, error) {
+	if o.loyalty == nil {
+		return 0, Tier{}, ErrNoLoyaltyProgram
+	}
+	c, err := o.customers.Get(ctx, customerID)
+	if err != nil {
+		return 0, Tier{}, err
+	}
 
-	return totalPrice, nil
+	return c.GetPoints(), o.loyalty.TierOf(c), nil
 }
 
-func (o *OrderService) AddCustomer(name string) (uuid.UUID, error) {
+func (o *OrderService) AddCustomer(ctx context.Context, name string) (uuid.UUID, error) {
 	c, err := customer.NewCustomer(name)
 	if err != nil {
 		return uuid.Nil, err
 	}
-	err = o.customers.Add(c)
+	err = o.customers.Add(ctx, c)
 	if err != nil {
 		return uuid.Nil, err
 	}
@@ -1,22 +1,33 @@
 package order
 
 import (
+	"context"
+	"errors"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/devsrivatsa/tavernDDD/domain"
+	"github.com/devsrivatsa/tavernDDD/domain/customer"
+	"github.com/devsrivatsa/tavernDDD/domain/menu"
+	ord "github.com/devsrivatsa/tavernDDD/domain/order"
+	ordMem "github.com/devsrivatsa/tavernDDD/domain/order/memory"
 	"github.com/devsrivatsa/tavernDDD/domain/product"
+	"github.com/devsrivatsa/tavernDDD/domain/promotion"
+	"github.com/devsrivatsa/tavernDDD/domain/staff"
 	"github.com/google/uuid"
 )
 
 func init_products(t *testing.T) []product.Product {
-	beer, err := product.NewProduct("Beer", "A refreshing beer", 1.99)
+	beer, err := product.NewProduct("Beer", "A refreshing beer", domain.MustNewMoney(199, "EUR"), 10)
 	if err != nil {
 		t.Fatalf("Error initializing product: %v \nCannot proceed with tests", err)
 	}
-	peanuts, err := product.NewProduct("Peanuts", "A delicious snack", 0.99)
+	peanuts, err := product.NewProduct("Peanuts", "A delicious snack", domain.MustNewMoney(99, "EUR"), 10)
 	if err != nil {
 		t.Fatalf("Error initializing product: %v \nCannot proceed with tests", err)
 	}
-	wine, err := product.NewProduct("Wine", "A fine wine", 5.99)
+	wine, err := product.NewProduct("Wine", "A fine wine", domain.MustNewMoney(599, "EUR"), 10)
 	if err != nil {
 		t.Fatalf("Error initializing product: %v \nCannot proceed with tests", err)
 	}
@@ -35,17 +46,1184 @@ func TestOrder_NewOrderService(t *testing.T) {
 	}
 	t.Log("Order service created")
 
-	customerID, err := or.AddCustomer("John Doe")
+	customerID, err := or.AddCustomer(context.Background(), "John Doe")
 	if err != nil {
 		t.Errorf("Error creating customer: %v", err)
 	}
 
 	t.Log("Customer created and added to the order service")
-	order := []uuid.UUID{products[0].GetID()}
+	order := Request{Lines: []RequestLine{{ProductID: products[0].GetID(), Quantity: 1}}}
 
-	_, err = or.CreateOrder(customerID, order)
+	_, err = or.CreateOrder(context.Background(), customerID, order)
 	if err != nil {
 		t.Fatalf("Error creating order: %v", err)
 	}
 	t.Log("Order created")
 }
+
+func TestOrder_CreateOrderTotal(t *testing.T) {
+	products := init_products(t)
+	or, err := NewOrderService(
+		WithMemoryCustomerRepository(),
+		WithMemoryProductRepository(products),
+	)
+	if err != nil {
+		t.Fatalf("Error creating order service: %v", err)
+	}
+	customerID, err := or.AddCustomer(context.Background(), "John Doe")
+	if err != nil {
+		t.Fatalf("Error creating customer: %v", err)
+	}
+
+	placed, err := or.CreateOrder(context.Background(), customerID, Request{Lines: []RequestLine{
+		{ProductID: products[0].GetID(), Quantity: 1},
+		{ProductID: products[1].GetID(), Quantity: 1},
+		{ProductID: products[2].GetID(), Quantity: 1},
+		{ProductID: products[0].GetID(), Quantity: 1},
+	}})
+	if err != nil {
+		t.Fatalf("Error creating order: %v", err)
+	}
+	if !placed.GetTotal().Equals(domain.MustNewMoney(1096, "EUR")) {
+		t.Errorf("expected total of 10.96 EUR, got %s", placed.GetTotal())
+	}
+	if len(placed.GetLines()) != 3 {
+		t.Errorf("expected 3 order lines, got %d", len(placed.GetLines()))
+	}
+
+	stored, err := or.GetOrder(context.Background(), placed.GetID())
+	if err != nil {
+		t.Fatalf("Error fetching order: %v", err)
+	}
+	if !stored.GetTotal().Equals(placed.GetTotal()) {
+		t.Errorf("expected stored total %s, got %s", placed.GetTotal(), stored.GetTotal())
+	}
+	orders, err := or.GetCustomerOrders(context.Background(), customerID)
+	if err != nil {
+		t.Fatalf("Error listing orders: %v", err)
+	}
+	if len(orders) != 1 {
+		t.Errorf("expected 1 order for the customer, got %d", len(orders))
+	}
+
+	cust, err := or.customers.Get(context.Background(), customerID)
+	if err != nil {
+		t.Fatalf("Error fetching customer: %v", err)
+	}
+	if len(cust.GetTransactions()) != 1 {
+		t.Fatalf("expected 1 transaction on the customer, got %d", len(cust.GetTransactions()))
+	}
+	if tx := cust.GetTransactions()[0]; tx.GetTo() != or.account || !tx.GetAmount().Equals(placed.GetTotal()) {
+		t.Errorf("expected a payment of %s to the tavern, got %s to %s", placed.GetTotal(), tx.GetAmount(), tx.GetTo())
+	}
+	history := cust.PurchaseHistory()
+	if len(history) != 3 {
+		t.Fatalf("expected 3 purchases, got %d", len(history))
+	}
+	if history[0].Item.ID != products[0].GetID() || history[0].Quantity != 2 {
+		t.Errorf("expected 2 beers in the purchase history, got %d of %s", history[0].Quantity, history[0].Item.Name)
+	}
+}
+
+func TestOrder_CreateOrderOutOfStock(t *testing.T) {
+	products := init_products(t)
+	or, err := NewOrderService(
+		WithMemoryCustomerRepository(),
+		WithMemoryOrderRepository(),
+		WithMemoryProductRepository(products),
+	)
+	if err != nil {
+		t.Fatalf("Error creating order service: %v", err)
+	}
+	customerID, err := or.AddCustomer(context.Background(), "John Doe")
+	if err != nil {
+		t.Fatalf("Error creating customer: %v", err)
+	}
+
+	// one beer and eleven glasses of wine, but there are only ten in stock
+	_, err = or.CreateOrder(context.Background(), customerID, Request{Lines: []RequestLine{
+		{ProductID: products[0].GetID(), Quantity: 1},
+		{ProductID: products[2].GetID(), Quantity: 11},
+	}})
+	if !errors.Is(err, product.ErrOutOfStock) {
+		t.Fatalf("expected error %v, got %v", product.ErrOutOfStock, err)
+	}
+
+	// the beer taken for the failed order must be back in stock
+	beer, err := or.products.GetByID(context.Background(), products[0].GetID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if beer.GetQuantity() != 10 {
+		t.Errorf("expected 10 beers in stock, got %d", beer.GetQuantity())
+	}
+}
+
+func TestOrder_RejectOrder(t *testing.T) {
+	products := init_products(t)
+	or, err := NewOrderService(
+		WithMemoryCustomerRepository(),
+		WithMemoryOrderRepository(),
+		WithMemoryProductRepository(products),
+	)
+	if err != nil {
+		t.Fatalf("Error creating order service: %v", err)
+	}
+	customerID, err := or.AddCustomer(context.Background(), "John Doe")
+	if err != nil {
+		t.Fatalf("Error creating customer: %v", err)
+	}
+	placed, err := or.CreateOrder(context.Background(), customerID, Request{Lines: []RequestLine{
+		{ProductID: products[0].GetID(), Quantity: 1},
+		{ProductID: products[2].GetID(), Quantity: 1},
+	}})
+	if err != nil {
+		t.Fatalf("Error creating order: %v", err)
+	}
+
+	if _, err := or.RejectOrder(context.Background(), placed.GetID()); err != nil {
+		t.Fatalf("Error rejecting order: %v", err)
+	}
+	if _, err := or.ConfirmOrder(context.Background(), placed.GetID(), ""); !errors.Is(err, ord.ErrInvalidStatus) {
+		t.Errorf("expected error %v, got %v", ord.ErrInvalidStatus, err)
+	}
+
+	wine, err := or.products.GetByID(context.Background(), products[2].GetID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wine.GetQuantity() != 10 {
+		t.Errorf("expected 10 glasses of wine in stock, got %d", wine.GetQuantity())
+	}
+	cust, err := or.customers.Get(context.Background(), customerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	balance, err := cust.GetBalance("EUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !balance.IsZero() || len(cust.PurchaseHistory()) != 0 {
+		t.Errorf("expected the customer to be reimbursed, got a balance of %s and %d purchases", balance, len(cust.PurchaseHistory()))
+	}
+}
+
+func TestOrder_CancelAndRefund(t *testing.T) {
+	products := init_products(t)
+	or, err := NewOrderService(
+		WithMemoryCustomerRepository(),
+		WithMemoryOrderRepository(),
+		WithMemoryProductRepository(products),
+	)
+	if err != nil {
+		t.Fatalf("Error creating order service: %v", err)
+	}
+	customerID, err := or.AddCustomer(context.Background(), "John Doe")
+	if err != nil {
+		t.Fatalf("Error creating customer: %v", err)
+	}
+	order := Request{Lines: []RequestLine{
+		{ProductID: products[0].GetID(), Quantity: 2},
+		{ProductID: products[2].GetID(), Quantity: 1},
+	}}
+
+	cancelled, err := or.CreateOrder(context.Background(), customerID, order)
+	if err != nil {
+		t.Fatalf("Error creating order: %v", err)
+	}
+	if _, err := or.CancelOrder(context.Background(), cancelled.GetID()); err != nil {
+		t.Fatalf("Error cancelling order: %v", err)
+	}
+
+	paid, err := or.CreateOrder(context.Background(), customerID, order)
+	if err != nil {
+		t.Fatalf("Error creating order: %v", err)
+	}
+	if _, err := or.ConfirmOrder(context.Background(), paid.GetID(), ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := or.MarkOrderPaid(context.Background(), paid.GetID()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := or.CancelOrder(context.Background(), paid.GetID()); !errors.Is(err, ord.ErrAlreadyPaid) {
+		t.Errorf("expected error %v, got %v", ord.ErrAlreadyPaid, err)
+	}
+	_, amount, err := or.RefundLines(context.Background(), paid.GetID(), []ord.LineRefund{{ProductID: products[0].GetID(), Quantity: 1}})
+	if err != nil {
+		t.Fatalf("Error refunding order: %v", err)
+	}
+	if !amount.Equals(products[0].GetPrice()) {
+		t.Errorf("expected a refund of %s, got %s", products[0].GetPrice(), amount)
+	}
+
+	beer, err := or.products.GetByID(context.Background(), products[0].GetID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if beer.GetQuantity() != 9 {
+		t.Errorf("expected 9 beers in stock, got %d", beer.GetQuantity())
+	}
+	cust, err := or.customers.Get(context.Background(), customerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	balance, err := cust.GetBalance("EUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// only the beer and the wine that were kept are paid for
+	if !balance.Equals(domain.MustNewMoney(-798, "EUR")) {
+		t.Errorf("expected a balance of -7.98 EUR, got %s", balance)
+	}
+}
+
+// cancellingProductRepository calls off the request once stock has been taken for it
+type cancellingProductRepository struct {
+	product.ProductRepository
+	cancel context.CancelFunc
+}
+
+func (c *cancellingProductRepository) AdjustStock(ctx context.Context, id uuid.UUID, delta int) (product.Product, error) {
+	p, err := c.ProductRepository.AdjustStock(ctx, id, delta)
+	if delta < 0 {
+		c.cancel()
+	}
+	return p, err
+}
+
+func TestOrder_CreateOrderCancelled(t *testing.T) {
+	products := init_products(t)
+	or, err := NewOrderService(
+		WithMemoryCustomerRepository(),
+		WithMemoryOrderRepository(),
+		WithMemoryProductRepository(products),
+	)
+	if err != nil {
+		t.Fatalf("Error creating order service: %v", err)
+	}
+	customerID, err := or.AddCustomer(context.Background(), "John Doe")
+	if err != nil {
+		t.Fatalf("Error creating customer: %v", err)
+	}
+	req := Request{Lines: []RequestLine{{ProductID: products[0].GetID(), Quantity: 2}}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := or.CreateOrder(ctx, customerID, req); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected error %v, got %v", context.Canceled, err)
+	}
+
+	// called off halfway, the stock taken for the order is still put back
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	or.products = &cancellingProductRepository{ProductRepository: or.products, cancel: cancel}
+	if _, err := or.CreateOrder(ctx, customerID, req); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected error %v, got %v", context.Canceled, err)
+	}
+	beer, err := or.products.GetByID(context.Background(), products[0].GetID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if beer.GetQuantity() != 10 {
+		t.Errorf("expected 10 beers in stock, got %d", beer.GetQuantity())
+	}
+	orders, err := or.GetCustomerOrders(context.Background(), customerID)
+	if err != nil || len(orders) != 0 {
+		t.Errorf("expected no orders, got %d (%v)", len(orders), err)
+	}
+}
+
+// countingProductRepository counts how often products are looked up
+type countingProductRepository struct {
+	product.ProductRepository
+	lookups int
+}
+
+func (c *countingProductRepository) GetByID(ctx context.Context, id uuid.UUID) (product.Product, error) {
+	c.lookups++
+	return c.ProductRepository.GetByID(ctx, id)
+}
+
+func TestOrder_CreateOrderLooksUpProductsOnce(t *testing.T) {
+	products := init_products(t)
+	or, err := NewOrderService(
+		WithMemoryCustomerRepository(),
+		WithMemoryOrderRepository(),
+		WithMemoryProductRepository(products),
+	)
+	if err != nil {
+		t.Fatalf("Error creating order service: %v", err)
+	}
+	repo := &countingProductRepository{ProductRepository: or.products}
+	or.products = repo
+	customerID, err := or.AddCustomer(context.Background(), "John Doe")
+	if err != nil {
+		t.Fatalf("Error creating customer: %v", err)
+	}
+
+	placed, err := or.CreateOrder(context.Background(), customerID, Request{Lines: []RequestLine{
+		{ProductID: products[0].GetID(), Quantity: 5},
+		{ProductID: products[1].GetID(), Quantity: 1},
+		{ProductID: products[0].GetID(), Quantity: 5, Notes: "a round for the table"},
+	}})
+	if err != nil {
+		t.Fatalf("Error creating order: %v", err)
+	}
+	if repo.lookups != 2 {
+		t.Errorf("expected 2 product lookups, got %d", repo.lookups)
+	}
+	lines := placed.GetLines()
+	if len(lines) != 2 || lines[0].Quantity != 10 || lines[0].Notes != "a round for the table" {
+		t.Errorf("expected 10 beers for the table, got %+v", lines[0])
+	}
+}
+
+func TestOrder_CreateOrderWithTax(t *testing.T) {
+	products := init_products(t)
+	if err := products[1].SetTaxClass(product.TaxClassFood); err != nil {
+		t.Fatal(err)
+	}
+	tax, err := NewClassRateTax(map[product.TaxClass]int64{product.TaxClassFood: 700}, 2000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	or, err := NewOrderService(
+		WithMemoryCustomerRepository(),
+		WithMemoryOrderRepository(),
+		WithMemoryProductRepository(products),
+		WithTaxPolicy(tax),
+	)
+	if err != nil {
+		t.Fatalf("Error creating order service: %v", err)
+	}
+	customerID, err := or.AddCustomer(context.Background(), "John Doe")
+	if err != nil {
+		t.Fatalf("Error creating customer: %v", err)
+	}
+
+	placed, err := or.CreateOrder(context.Background(), customerID, Request{Lines: []RequestLine{
+		{ProductID: products[0].GetID(), Quantity: 2},
+		{ProductID: products[1].GetID(), Quantity: 1},
+	}})
+	if err != nil {
+		t.Fatalf("Error creating order: %v", err)
+	}
+
+	// 2 beers at 3.98 + 20% and peanuts at 0.99 + 7%
+	lines := placed.GetLines()
+	if !lines[0].Tax.Equals(domain.MustNewMoney(80, "EUR")) || !lines[1].Tax.Equals(domain.MustNewMoney(7, "EUR")) {
+		t.Errorf("expected tax of 0.80 EUR and 0.07 EUR, got %s and %s", lines[0].Tax, lines[1].Tax)
+	}
+	if !placed.GetNet().Equals(domain.MustNewMoney(497, "EUR")) {
+		t.Errorf("expected a net total of 4.97 EUR, got %s", placed.GetNet())
+	}
+	if !placed.GetTax().Equals(domain.MustNewMoney(87, "EUR")) {
+		t.Errorf("expected 0.87 EUR of tax, got %s", placed.GetTax())
+	}
+	if !placed.GetTotal().Equals(domain.MustNewMoney(584, "EUR")) {
+		t.Errorf("expected a total of 5.84 EUR, got %s", placed.GetTotal())
+	}
+}
+
+func TestOrder_CreateOrderWithPromotions(t *testing.T) {
+	products := init_products(t)
+	beer, peanuts := products[0], products[1]
+
+	twoForOne, err := promotion.BuyXGetYFree(1, 1, beer.GetID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	halfPrice, err := promotion.PercentOff(5000, peanuts.GetID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	beerNight, err := promotion.NewPromotion("2-for-1 beer night", twoForOne, promotion.OnWeekdays(time.Friday))
+	if err != nil {
+		t.Fatal(err)
+	}
+	happyHour, err := promotion.NewPromotion("Happy hour", halfPrice, promotion.During(17*time.Hour, 19*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tax, err := NewFlatRateTax(1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	or, err := NewOrderService(
+		WithMemoryCustomerRepository(),
+		WithMemoryOrderRepository(),
+		WithMemoryProductRepository(products),
+		WithPromotions(promotion.NewEngine(beerNight, happyHour)),
+		WithTaxPolicy(tax),
+		WithClock(func() time.Time { return time.Date(2025, time.June, 6, 18, 0, 0, 0, time.UTC) }),
+	)
+	if err != nil {
+		t.Fatalf("Error creating order service: %v", err)
+	}
+	customerID, err := or.AddCustomer(context.Background(), "John Doe")
+	if err != nil {
+		t.Fatalf("Error creating customer: %v", err)
+	}
+
+	placed, err := or.CreateOrder(context.Background(), customerID, Request{Lines: []RequestLine{
+		{ProductID: beer.GetID(), Quantity: 4},
+		{ProductID: peanuts.GetID(), Quantity: 2},
+	}})
+	if err != nil {
+		t.Fatalf("Error creating order: %v", err)
+	}
+
+	lines := placed.GetLines()
+	if len(lines[0].Discounts) != 1 || lines[0].Discounts[0].Name != "2-for-1 beer night" {
+		t.Fatalf("expected the beers to be discounted by the beer night, got %+v", lines[0].Discounts)
+	}
+	if len(lines[1].Discounts) != 1 || lines[1].Discounts[0].Name != "Happy hour" {
+		t.Fatalf("expected the peanuts to be discounted by the happy hour, got %+v", lines[1].Discounts)
+	}
+	discount, err := placed.GetDiscount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2 free beers and half off 1.98 of peanuts
+	if !discount.Equals(domain.MustNewMoney(497, "EUR")) {
+		t.Errorf("expected 4.97 EUR of discounts, got %s", discount)
+	}
+	// 3.98 of beer and 0.99 of peanuts plus 10% tax
+	if !placed.GetNet().Equals(domain.MustNewMoney(497, "EUR")) || !placed.GetTotal().Equals(domain.MustNewMoney(547, "EUR")) {
+		t.Errorf("expected 4.97 EUR net and 5.47 EUR in total, got %s and %s", placed.GetNet(), placed.GetTotal())
+	}
+}
+
+func TestOrder_CreateOrderCompWithPromotion(t *testing.T) {
+	products := init_products(t)
+	beer := products[0]
+	manager, err := staff.NewMember("Alex", staff.RoleManager)
+	if err != nil {
+		t.Fatal(err)
+	}
+	halfPrice, err := promotion.PercentOff(5000, beer.GetID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	happyHour, err := promotion.NewPromotion("Happy hour", halfPrice)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	or, err := NewOrderService(
+		WithMemoryCustomerRepository(),
+		WithMemoryOrderRepository(),
+		WithMemoryProductRepository(products),
+		WithMemoryStaffRepository(manager),
+		WithPromotions(promotion.NewEngine(happyHour)),
+	)
+	if err != nil {
+		t.Fatalf("Error creating order service: %v", err)
+	}
+	customerID, err := or.AddCustomer(context.Background(), "John Doe")
+	if err != nil {
+		t.Fatalf("Error creating customer: %v", err)
+	}
+
+	type testCase struct {
+		test          string
+		paid, comped  int
+		expectedTotal domain.Money
+	}
+	testcases := []testCase{
+		{test: "One paid, two comped", paid: 1, comped: 2, expectedTotal: domain.MustNewMoney(99, "EUR")},
+		{test: "Two paid, one comped", paid: 2, comped: 1, expectedTotal: domain.MustNewMoney(199, "EUR")},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.test, func(t *testing.T) {
+			placed, err := or.CreateOrder(context.Background(), customerID, Request{Lines: []RequestLine{
+				{ProductID: beer.GetID(), Quantity: tc.paid},
+				{ProductID: beer.GetID(), Quantity: tc.comped, Comp: true},
+			}, StaffID: manager.GetID()})
+			if err != nil {
+				t.Fatalf("Error creating order: %v", err)
+			}
+
+			lines := placed.GetLines()
+			if len(lines[0].Discounts) != 1 || lines[0].Discounts[0].Name != "Happy hour" {
+				t.Errorf("expected the paid beers discounted by the happy hour, got %+v", lines[0].Discounts)
+			}
+			if len(lines[1].Discounts) != 1 || lines[1].Discounts[0].Name != "Comp" {
+				t.Errorf("expected the comped beers given away, got %+v", lines[1].Discounts)
+			}
+			if !placed.GetTotal().Equals(tc.expectedTotal) {
+				t.Errorf("expected a total of %s, got %s", tc.expectedTotal, placed.GetTotal())
+			}
+		})
+	}
+}
+
+func TestOrder_LoyaltyPoints(t *testing.T) {
+	products := init_products(t)
+	beer, peanuts, wine := products[0], products[1], products[2]
+
+	gold := Tier{Name: "Gold", MinPoints: 10, EarnPercent: 200}
+	program, err := NewLoyaltyProgram(domain.MustNewMoney(100, "EUR"), domain.MustNewMoney(10, "EUR"), gold)
+	if err != nil {
+		t.Fatal(err)
+	}
+	or, err := NewOrderService(
+		WithMemoryCustomerRepository(),
+		WithMemoryOrderRepository(),
+		WithMemoryProductRepository(products),
+		WithLoyaltyProgram(program),
+	)
+	if err != nil {
+		t.Fatalf("Error creating order service: %v", err)
+	}
+	customerID, err := or.AddCustomer(context.Background(), "John Doe")
+	if err != nil {
+		t.Fatalf("Error creating customer: %v", err)
+	}
+
+	// 5.99 earns 5 points twice, which reaches gold
+	for i := 0; i < 2; i++ {
+		if _, err := or.CreateOrder(context.Background(), customerID, Request{Lines: []RequestLine{{ProductID: wine.GetID(), Quantity: 1}}}); err != nil {
+			t.Fatalf("Error creating order: %v", err)
+		}
+	}
+	points, tier, err := or.GetLoyalty(context.Background(), customerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if points != 10 || tier.Name != gold.Name {
+		t.Fatalf("expected 10 points and the gold tier, got %d and %s", points, tier.Name)
+	}
+
+	_, err = or.CreateOrder(context.Background(), customerID, Request{Lines: []RequestLine{{ProductID: beer.GetID(), Quantity: 1}}, RedeemPoints: 50})
+	if !errors.Is(err, customer.ErrInsufficientPoints) {
+		t.Errorf("expected error %v, got %v", customer.ErrInsufficientPoints, err)
+	}
+
+	placed, err := or.CreateOrder(context.Background(), customerID, Request{Lines: []RequestLine{
+		{ProductID: beer.GetID(), Quantity: 1},
+		{ProductID: peanuts.GetID(), Quantity: 1},
+	}, RedeemPoints: 10})
+	if err != nil {
+		t.Fatalf("Error creating order: %v", err)
+	}
+	// 1.00 EUR off 2.98 EUR, split 0.67 and 0.33 over the lines
+	lines := placed.GetLines()
+	if !lines[0].Discounts[0].Amount.Equals(domain.MustNewMoney(67, "EUR")) || !lines[1].Discounts[0].Amount.Equals(domain.MustNewMoney(33, "EUR")) {
+		t.Errorf("expected discounts of 0.67 EUR and 0.33 EUR, got %s and %s", lines[0].Discounts[0].Amount, lines[1].Discounts[0].Amount)
+	}
+	if !placed.GetTotal().Equals(domain.MustNewMoney(198, "EUR")) {
+		t.Errorf("expected a total of 1.98 EUR, got %s", placed.GetTotal())
+	}
+	// gold earns 2 points per euro on the 1.98 EUR paid
+	if points, _, _ := or.GetLoyalty(context.Background(), customerID); points != 3 {
+		t.Errorf("expected 3 points after redeeming, got %d", points)
+	}
+
+	if _, err := or.CancelOrder(context.Background(), placed.GetID()); err != nil {
+		t.Fatalf("Error cancelling order: %v", err)
+	}
+	if points, _, _ := or.GetLoyalty(context.Background(), customerID); points != 10 {
+		t.Errorf("expected the 10 redeemed points back after cancelling, got %d", points)
+	}
+}
+
+func TestOrder_RefundTakesBackPoints(t *testing.T) {
+	products := init_products(t)
+	wine := products[2]
+	now := time.Date(2025, time.June, 6, 22, 0, 0, 0, time.UTC)
+
+	program, err := NewLoyaltyProgram(domain.MustNewMoney(100, "EUR"), domain.MustNewMoney(10, "EUR"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	or, err := NewOrderService(
+		WithMemoryCustomerRepository(),
+		WithMemoryOrderRepository(),
+		WithMemoryProductRepository(products),
+		WithLoyaltyProgram(program),
+		WithClock(func() time.Time { return now }),
+	)
+	if err != nil {
+		t.Fatalf("Error creating order service: %v", err)
+	}
+	customerID, err := or.AddCustomer(context.Background(), "John Doe")
+	if err != nil {
+		t.Fatalf("Error creating customer: %v", err)
+	}
+	// 4 glasses of 5.99 earn 23 points
+	placed, err := or.CreateOrder(context.Background(), customerID, Request{Lines: []RequestLine{{ProductID: wine.GetID(), Quantity: 4}}})
+	if err != nil {
+		t.Fatalf("Error creating order: %v", err)
+	}
+	if _, err := or.ConfirmOrder(context.Background(), placed.GetID(), ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := or.MarkOrderPaid(context.Background(), placed.GetID()); err != nil {
+		t.Fatal(err)
+	}
+
+	type testCase struct {
+		test           string
+		quantity       int
+		expectedPoints int64
+	}
+	// a quarter of the order takes back 5 of the 23 points, the rest takes back all of them
+	testcases := []testCase{
+		{test: "One glass", quantity: 1, expectedPoints: 18},
+		{test: "The other glasses", quantity: 3, expectedPoints: 0},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.test, func(t *testing.T) {
+			now = now.Add(time.Hour)
+			_, _, err := or.RefundLines(context.Background(), placed.GetID(), []ord.LineRefund{{ProductID: wine.GetID(), Quantity: tc.quantity}})
+			if err != nil {
+				t.Fatalf("Error refunding: %v", err)
+			}
+			if points, _, _ := or.GetLoyalty(context.Background(), customerID); points != tc.expectedPoints {
+				t.Errorf("expected %d points, got %d", tc.expectedPoints, points)
+			}
+			c, err := or.customers.Get(context.Background(), customerID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			history := c.PointsHistory()
+			if last := history[len(history)-1]; last.Reason != customer.PointsRefunded || !last.At.Equal(now) {
+				t.Errorf("expected points refunded at %s, got %s at %s", now, last.Reason, last.At)
+			}
+			transactions := c.GetTransactions()
+			if last := transactions[len(transactions)-1]; !last.GetCreatedAt().Equal(now) {
+				t.Errorf("expected the refund at %s, got %s", now, last.GetCreatedAt())
+			}
+		})
+	}
+}
+
+func TestOrder_LoyaltyPointsConcurrentRedemptions(t *testing.T) {
+	products := init_products(t)
+	peanuts, wine := products[1], products[2]
+
+	program, err := NewLoyaltyProgram(domain.MustNewMoney(100, "EUR"), domain.MustNewMoney(10, "EUR"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	or, err := NewOrderService(
+		WithMemoryCustomerRepository(),
+		WithMemoryOrderRepository(),
+		WithMemoryProductRepository(products),
+		WithLoyaltyProgram(program),
+	)
+	if err != nil {
+		t.Fatalf("Error creating order service: %v", err)
+	}
+	customerID, err := or.AddCustomer(context.Background(), "John Doe")
+	if err != nil {
+		t.Fatalf("Error creating customer: %v", err)
+	}
+	// 11.98 earns 11 points
+	if _, err := or.CreateOrder(context.Background(), customerID, Request{Lines: []RequestLine{{ProductID: wine.GetID(), Quantity: 2}}}); err != nil {
+		t.Fatalf("Error creating order: %v", err)
+	}
+
+	// 4 orders of peanuts redeem 5 points each at the same time, only 2 of them can have the points
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := or.CreateOrder(context.Background(), customerID, Request{Lines: []RequestLine{{ProductID: peanuts.GetID(), Quantity: 1}}, RedeemPoints: 5})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	placed := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			placed++
+		case !errors.Is(err, customer.ErrInsufficientPoints):
+			t.Errorf("expected error %v, got %v", customer.ErrInsufficientPoints, err)
+		}
+	}
+	points, _, err := or.GetLoyalty(context.Background(), customerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if placed != 2 || points != 1 {
+		t.Errorf("expected 2 orders placed and 1 point left, got %d and %d", placed, points)
+	}
+}
+
+// gatedOrderRepository holds the first readers of an order until all of them have read it,
+// so their changes are made on the same version
+type gatedOrderRepository struct {
+	ord.OrderRepository
+	readers int
+	gate    chan struct{}
+	sync.Mutex
+}
+
+func (r *gatedOrderRepository) hold(readers int) {
+	r.Lock()
+	defer r.Unlock()
+	r.readers = readers
+	r.gate = make(chan struct{})
+}
+
+func (r *gatedOrderRepository) Get(ctx context.Context, id uuid.UUID) (ord.Order, error) {
+	o, err := r.OrderRepository.Get(ctx, id)
+	r.Lock()
+	gate := r.gate
+	if r.readers > 0 {
+		r.readers--
+		if r.readers == 0 {
+			close(r.gate)
+		}
+	} else {
+		gate = nil
+	}
+	r.Unlock()
+	if gate != nil {
+		<-gate
+	}
+	return o, err
+}
+
+func TestOrder_ConcurrentRefunds(t *testing.T) {
+	products := init_products(t)
+	wine := products[2]
+	orders := &gatedOrderRepository{OrderRepository: ordMem.New()}
+	or, err := NewOrderService(
+		WithMemoryCustomerRepository(),
+		WithOrderRepository(orders),
+		WithMemoryProductRepository(products),
+	)
+	if err != nil {
+		t.Fatalf("Error creating order service: %v", err)
+	}
+	customerID, err := or.AddCustomer(context.Background(), "John Doe")
+	if err != nil {
+		t.Fatalf("Error creating customer: %v", err)
+	}
+	placed, err := or.CreateOrder(context.Background(), customerID, Request{Lines: []RequestLine{{ProductID: wine.GetID(), Quantity: 2}}})
+	if err != nil {
+		t.Fatalf("Error creating order: %v", err)
+	}
+	if _, err := or.ConfirmOrder(context.Background(), placed.GetID(), ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := or.MarkOrderPaid(context.Background(), placed.GetID()); err != nil {
+		t.Fatal(err)
+	}
+
+	// 2 refunds of both glasses read the paid order at the same time, only one of them can go through
+	orders.hold(2)
+	var wg sync.WaitGroup
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, err := or.RefundLines(context.Background(), placed.GetID(), []ord.LineRefund{{ProductID: wine.GetID(), Quantity: 2}})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	refunded := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			refunded++
+		case !errors.Is(err, ord.ErrNotPaid):
+			t.Errorf("expected error %v, got %v", ord.ErrNotPaid, err)
+		}
+	}
+	if refunded != 1 {
+		t.Errorf("expected 1 refund, got %d", refunded)
+	}
+	if p, _ := or.products.GetByID(context.Background(), wine.GetID()); p.GetQuantity() != 10 {
+		t.Errorf("expected 10 glasses of wine in stock, got %d", p.GetQuantity())
+	}
+}
+
+func TestOrder_CreateOrderAgeRestricted(t *testing.T) {
+	products := init_products(t)
+	peanuts, wine := products[1], products[2]
+	if err := wine.SetMinAge(18); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2024, time.June, 15, 20, 0, 0, 0, time.UTC)
+	or, err := NewOrderService(
+		WithMemoryCustomerRepository(),
+		WithMemoryOrderRepository(),
+		WithMemoryProductRepository([]product.Product{peanuts, wine}),
+		WithClock(func() time.Time { return now }),
+	)
+	if err != nil {
+		t.Fatalf("Error creating order service: %v", err)
+	}
+
+	type testCase struct {
+		test          string
+		dob           time.Time
+		verified      bool
+		product       product.Product
+		expectedError error
+	}
+
+	testcases := []testCase{
+		{test: "Unrestricted product without a date of birth", product: peanuts, expectedError: nil},
+		{test: "Restricted product without a date of birth", product: wine, expectedError: ErrAgeRestricted},
+		{test: "Unverified adult", dob: time.Date(1990, time.January, 1, 0, 0, 0, 0, time.UTC), verified: false, product: wine, expectedError: ErrAgeRestricted},
+		{test: "Verified minor", dob: time.Date(2006, time.June, 16, 0, 0, 0, 0, time.UTC), verified: true, product: wine, expectedError: ErrAgeRestricted},
+		{test: "Verified adult", dob: time.Date(2006, time.June, 15, 0, 0, 0, 0, time.UTC), verified: true, product: wine, expectedError: nil},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.test, func(t *testing.T) {
+			customerID, err := or.AddCustomer(context.Background(), "John Doe")
+			if err != nil {
+				t.Fatalf("Error creating customer: %v", err)
+			}
+			if !tc.dob.IsZero() {
+				if err := or.SetDateOfBirth(context.Background(), customerID, tc.dob, tc.verified); err != nil {
+					t.Fatal(err)
+				}
+			}
+			_, err = or.CreateOrder(context.Background(), customerID, Request{Lines: []RequestLine{{ProductID: tc.product.GetID(), Quantity: 1}}})
+			if !errors.Is(err, tc.expectedError) {
+				t.Errorf("expected error %v, got %v", tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestOrder_CreateOrderFromMenu(t *testing.T) {
+	products := init_products(t)
+	beer, peanuts, wine := products[0], products[1], products[2]
+
+	evening, err := menu.NewMenu("Evening")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kitchen, err := menu.NewWindow(12*time.Hour, 22*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []string{"Draught", "Snacks"} {
+		if err := evening.AddCategory(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := evening.AddItem("Draught", menu.Item{ProductID: beer.GetID()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := evening.AddItem("Snacks", menu.Item{ProductID: peanuts.GetID(), Windows: []menu.Window{kitchen}}); err != nil {
+		t.Fatal(err)
+	}
+	evening.Activate()
+
+	now := time.Date(2024, time.March, 1, 23, 0, 0, 0, time.UTC)
+	or, err := NewOrderService(
+		WithMemoryCustomerRepository(),
+		WithMemoryOrderRepository(),
+		WithMemoryProductRepository(products),
+		WithMemoryMenuRepository(evening),
+		WithClock(func() time.Time { return now }),
+	)
+	if err != nil {
+		t.Fatalf("Error creating order service: %v", err)
+	}
+	customerID, err := or.AddCustomer(context.Background(), "John Doe")
+	if err != nil {
+		t.Fatalf("Error creating customer: %v", err)
+	}
+
+	type testCase struct {
+		test          string
+		product       product.Product
+		expectedError error
+	}
+
+	testcases := []testCase{
+		{test: "On the menu", product: beer, expectedError: nil},
+		{test: "Kitchen closed", product: peanuts, expectedError: menu.ErrOutsideHours},
+		{test: "Not on the menu", product: wine, expectedError: menu.ErrNotOnMenu},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.test, func(t *testing.T) {
+			_, err := or.CreateOrder(context.Background(), customerID, Request{Lines: []RequestLine{{ProductID: tc.product.GetID(), Quantity: 1}}})
+			if !errors.Is(err, tc.expectedError) {
+				t.Errorf("expected error %v, got %v", tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestOrder_CreateOrderWithVariants(t *testing.T) {
+	products := init_products(t)
+	beer := products[0]
+	// the beer is stocked in half-pints
+	variants := []product.Variant{
+		{SKU: "BEER-HALF", Size: "Half pint", Price: domain.MustNewMoney(350, "EUR"), Draw: 1},
+		{SKU: "BEER-PINT", Size: "Pint", Price: domain.MustNewMoney(599, "EUR"), Draw: 2},
+		{SKU: "BEER-PITCHER", Size: "Pitcher", Price: domain.MustNewMoney(1999, "EUR"), Draw: 8},
+	}
+	for _, v := range variants {
+		if err := beer.AddVariant(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	or, err := NewOrderService(
+		WithMemoryCustomerRepository(),
+		WithMemoryOrderRepository(),
+		WithMemoryProductRepository([]product.Product{beer}),
+	)
+	if err != nil {
+		t.Fatalf("Error creating order service: %v", err)
+	}
+	customerID, err := or.AddCustomer(context.Background(), "John Doe")
+	if err != nil {
+		t.Fatalf("Error creating customer: %v", err)
+	}
+
+	_, err = or.CreateOrder(context.Background(), customerID, Request{Lines: []RequestLine{{ProductID: beer.GetID(), Quantity: 1}}})
+	if !errors.Is(err, ErrVariantRequired) {
+		t.Errorf("expected error %v, got %v", ErrVariantRequired, err)
+	}
+	_, err = or.CreateOrder(context.Background(), customerID, Request{Lines: []RequestLine{{ProductID: beer.GetID(), VariantSKU: "BEER-YARD", Quantity: 1}}})
+	if !errors.Is(err, product.ErrVariantNotFound) {
+		t.Errorf("expected error %v, got %v", product.ErrVariantNotFound, err)
+	}
+
+	placed, err := or.CreateOrder(context.Background(), customerID, Request{Lines: []RequestLine{
+		{ProductID: beer.GetID(), VariantSKU: "BEER-PINT", Quantity: 1},
+		{ProductID: beer.GetID(), VariantSKU: "BEER-PITCHER", Quantity: 1},
+	}})
+	if err != nil {
+		t.Fatalf("Error creating order: %v", err)
+	}
+	lines := placed.GetLines()
+	if lines[0].Size != "Pint" || !lines[1].UnitPrice.Equals(domain.MustNewMoney(1999, "EUR")) {
+		t.Errorf("expected a pint and a pitcher at 19.99 EUR, got %s and %s", lines[0].Size, lines[1].UnitPrice)
+	}
+	if !placed.GetTotal().Equals(domain.MustNewMoney(2598, "EUR")) {
+		t.Errorf("expected a total of 25.98 EUR, got %s", placed.GetTotal())
+	}
+
+	// a pint and a pitcher drew all 10 half-pints
+	_, err = or.CreateOrder(context.Background(), customerID, Request{Lines: []RequestLine{{ProductID: beer.GetID(), VariantSKU: "BEER-HALF", Quantity: 1}}})
+	if !errors.Is(err, product.ErrOutOfStock) {
+		t.Errorf("expected error %v, got %v", product.ErrOutOfStock, err)
+	}
+	if _, err := or.CancelOrder(context.Background(), placed.GetID()); err != nil {
+		t.Fatalf("Error cancelling order: %v", err)
+	}
+	stocked, err := or.products.GetByID(context.Background(), beer.GetID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stocked.GetQuantity() != 10 {
+		t.Errorf("expected 10 half-pints back in stock, got %d", stocked.GetQuantity())
+	}
+}
+
+func TestOrder_CreateOrderWithRecipe(t *testing.T) {
+	gin, err := product.NewProduct("Gin", "London dry gin, stocked in cl", domain.MustNewMoney(0, "EUR"), 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tonic, err := product.NewProduct("Tonic", "A bottle of tonic water", domain.MustNewMoney(250, "EUR"), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cocktail, err := product.NewProduct("Gin and tonic", "A long drink", domain.MustNewMoney(899, "EUR"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cocktail.SetRecipe(product.Ingredient{ProductID: gin.GetID(), Amount: 4}, product.Ingredient{ProductID: tonic.GetID(), Amount: 1}); err != nil {
+		t.Fatal(err)
+	}
+	or, err := NewOrderService(
+		WithMemoryCustomerRepository(),
+		WithMemoryOrderRepository(),
+		WithMemoryProductRepository([]product.Product{gin, tonic, cocktail}),
+	)
+	if err != nil {
+		t.Fatalf("Error creating order service: %v", err)
+	}
+	customerID, err := or.AddCustomer(context.Background(), "John Doe")
+	if err != nil {
+		t.Fatalf("Error creating customer: %v", err)
+	}
+	stock := func(p product.Product) int {
+		prd, err := or.products.GetByID(context.Background(), p.GetID())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return prd.GetQuantity()
+	}
+
+	placed, err := or.CreateOrder(context.Background(), customerID, Request{Lines: []RequestLine{{ProductID: cocktail.GetID(), Quantity: 2}}})
+	if err != nil {
+		t.Fatalf("Error creating order: %v", err)
+	}
+	if stock(gin) != 0 || stock(tonic) != 1 || stock(cocktail) != 0 {
+		t.Errorf("expected 0 cl of gin and 1 tonic left, got %d and %d", stock(gin), stock(tonic))
+	}
+
+	if err := or.CheckOrderable(context.Background(), cocktail.GetID()); !errors.Is(err, ErrIngredientsOut) {
+		t.Errorf("expected error %v, got %v", ErrIngredientsOut, err)
+	}
+	_, err = or.CreateOrder(context.Background(), customerID, Request{Lines: []RequestLine{{ProductID: cocktail.GetID(), Quantity: 1}}})
+	if !errors.Is(err, ErrIngredientsOut) || !errors.Is(err, product.ErrOutOfStock) {
+		t.Errorf("expected errors %v and %v, got %v", ErrIngredientsOut, product.ErrOutOfStock, err)
+	}
+	if stock(tonic) != 1 {
+		t.Errorf("expected the tonic to be put back, got %d left", stock(tonic))
+	}
+	orderable, err := or.GetOrderableProducts(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orderable) != 1 || orderable[0].GetID() != tonic.GetID() {
+		t.Errorf("expected only the tonic to be orderable, got %d products", len(orderable))
+	}
+
+	if _, err := or.CancelOrder(context.Background(), placed.GetID()); err != nil {
+		t.Fatalf("Error cancelling order: %v", err)
+	}
+	if stock(gin) != 8 || stock(tonic) != 3 {
+		t.Errorf("expected 8 cl of gin and 3 tonics back, got %d and %d", stock(gin), stock(tonic))
+	}
+	if err := or.CheckOrderable(context.Background(), cocktail.GetID()); err != nil {
+		t.Errorf("expected error %v, got %v", nil, err)
+	}
+}
+
+func TestOrder_CreateOrderByStaff(t *testing.T) {
+	products := init_products(t)
+	beer := products[0]
+	bartender, err := staff.NewMember("Sam", staff.RoleBartender)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager, err := staff.NewMember("Alex", staff.RoleManager)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cook, err := staff.NewMember("Kim", staff.RoleKitchen)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	or, err := NewOrderService(
+		WithMemoryCustomerRepository(),
+		WithMemoryOrderRepository(),
+		WithMemoryProductRepository(products),
+		WithMemoryStaffRepository(bartender, manager, cook),
+	)
+	if err != nil {
+		t.Fatalf("Error creating order service: %v", err)
+	}
+	customerID, err := or.AddCustomer(context.Background(), "John Doe")
+	if err != nil {
+		t.Fatalf("Error creating customer: %v", err)
+	}
+
+	type testCase struct {
+		test          string
+		staffID       uuid.UUID
+		line          RequestLine
+		expectedTotal domain.Money
+		expectedError error
+	}
+
+	testcases := []testCase{
+		{test: "Without staff", line: RequestLine{ProductID: beer.GetID(), Quantity: 2}, expectedError: ErrMissingStaff},
+		{test: "Unknown staff", staffID: uuid.New(), line: RequestLine{ProductID: beer.GetID(), Quantity: 2}, expectedError: staff.ErrMemberNotFound},
+		{test: "Kitchen", staffID: cook.GetID(), line: RequestLine{ProductID: beer.GetID(), Quantity: 2}, expectedError: staff.ErrNotPermitted},
+		{test: "Bartender", staffID: bartender.GetID(), line: RequestLine{ProductID: beer.GetID(), Quantity: 2}, expectedTotal: domain.MustNewMoney(398, "EUR")},
+		{test: "Bartender comps", staffID: bartender.GetID(), line: RequestLine{ProductID: beer.GetID(), Quantity: 2, Comp: true}, expectedError: staff.ErrNotPermitted},
+		{test: "Manager comps", staffID: manager.GetID(), line: RequestLine{ProductID: beer.GetID(), Quantity: 2, Comp: true}, expectedTotal: domain.MustNewMoney(0, "EUR")},
+		{test: "Bartender overrides", staffID: bartender.GetID(), line: RequestLine{ProductID: beer.GetID(), Quantity: 2, PriceOverride: domain.MustNewMoney(150, "EUR")}, expectedError: staff.ErrNotPermitted},
+		{test: "Manager overrides", staffID: manager.GetID(), line: RequestLine{ProductID: beer.GetID(), Quantity: 2, PriceOverride: domain.MustNewMoney(150, "EUR")}, expectedTotal: domain.MustNewMoney(300, "EUR")},
+		{test: "Override in another currency", staffID: manager.GetID(), line: RequestLine{ProductID: beer.GetID(), Quantity: 2, PriceOverride: domain.MustNewMoney(150, "USD")}, expectedError: domain.ErrCurrencyMismatch},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.test, func(t *testing.T) {
+			order, err := or.CreateOrder(context.Background(), customerID, Request{Lines: []RequestLine{tc.line}, StaffID: tc.staffID})
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("expected error %v, got %v", tc.expectedError, err)
+			}
+			if err != nil {
+				return
+			}
+			if order.GetTakenBy() != tc.staffID || !order.GetTotal().Equals(tc.expectedTotal) {
+				t.Errorf("expected %s taken by %s, got %s taken by %s", tc.expectedTotal, tc.staffID, order.GetTotal(), order.GetTakenBy())
+			}
+		})
+	}
+}
+
+func TestOrder_VoidOrder(t *testing.T) {
+	products := init_products(t)
+	beer := products[0]
+	bartender, _ := staff.NewMember("Sam", staff.RoleBartender)
+	manager, _ := staff.NewMember("Alex", staff.RoleManager)
+
+	or, err := NewOrderService(
+		WithMemoryCustomerRepository(),
+		WithMemoryOrderRepository(),
+		WithMemoryProductRepository(products),
+		WithMemoryStaffRepository(bartender, manager),
+	)
+	if err != nil {
+		t.Fatalf("Error creating order service: %v", err)
+	}
+	customerID, err := or.AddCustomer(context.Background(), "John Doe")
+	if err != nil {
+		t.Fatalf("Error creating customer: %v", err)
+	}
+	order, err := or.CreateOrder(context.Background(), customerID, Request{Lines: []RequestLine{{ProductID: beer.GetID(), Quantity: 4}}, StaffID: bartender.GetID()})
+	if err != nil {
+		t.Fatalf("Error creating order: %v", err)
+	}
+
+	if _, err := or.VoidOrder(context.Background(), order.GetID(), bartender.GetID()); !errors.Is(err, staff.ErrNotPermitted) {
+		t.Errorf("expected error %v, got %v", staff.ErrNotPermitted, err)
+	}
+	voided, err := or.VoidOrder(context.Background(), order.GetID(), manager.GetID())
+	if err != nil {
+		t.Fatalf("Error voiding order: %v", err)
+	}
+	if voided.GetStatus() != ord.StatusCancelled || voided.GetVoidedBy() != manager.GetID() {
+		t.Errorf("expected the order voided by %s, got %s by %s", manager.GetID(), voided.GetStatus(), voided.GetVoidedBy())
+	}
+	if prd, _ := or.products.GetByID(context.Background(), beer.GetID()); prd.GetQuantity() != 10 {
+		t.Errorf("expected the stock back to 10, got %d", prd.GetQuantity())
+	}
+
+	unchecked, err := NewOrderService(
+		WithMemoryCustomerRepository(),
+		WithMemoryOrderRepository(),
+		WithMemoryProductRepository(init_products(t)),
+	)
+	if err != nil {
+		t.Fatalf("Error creating order service: %v", err)
+	}
+	if _, err := unchecked.VoidOrder(context.Background(), order.GetID(), manager.GetID()); !errors.Is(err, ErrNoStaffRepository) {
+		t.Errorf("expected error %v, got %v", ErrNoStaffRepository, err)
+	}
+}
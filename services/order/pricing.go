@@ -0,0 +1,164 @@
+package order
+
+import (
+	"fmt"
+
+	"github.com/devsrivatsa/tavernDDD/domain"
+	"github.com/devsrivatsa/tavernDDD/domain/customer"
+	ord "github.com/devsrivatsa/tavernDDD/domain/order"
+	"github.com/devsrivatsa/tavernDDD/domain/product"
+	"github.com/devsrivatsa/tavernDDD/domain/promotion"
+)
+
+// priceLines snapshots the products into order lines, takes off the promotions, the comps and
+// the redeemed loyalty points and adds tax. products holds the product of every request line, in the same order.
+// Promotions only go to lines at the catalogue price, staff set the price of overridden and comped lines.
+func (o *OrderService) priceLines(cust customer.Customer, req Request, products []product.Product) ([]ord.Line, error) {
+	lines := make([]ord.Line, len(req.Lines))
+	cart := promotion.Cart{
+		CustomerID: cust.GetID(),
+		At:         o.now(),
+	}
+	//cartLines holds the order line of every cart line, Normalize leaves one per variant at the catalogue price
+	cartLines := make(map[promotion.LineKey]int)
+	for i, rl := range req.Lines {
+		var err error
+		lines[i], err = newLine(products[i], rl)
+		if err != nil {
+			return nil, err
+		}
+		if rl.overridesPrice() || rl.Comp {
+			continue
+		}
+		cl := promotion.CartLine{
+			ProductID: lines[i].ProductID,
+			SKU:       lines[i].SKU,
+			Quantity:  lines[i].Quantity,
+			UnitPrice: lines[i].UnitPrice,
+		}
+		cartLines[cl.Key()] = i
+		cart.Lines = append(cart.Lines, cl)
+	}
+
+	if o.promotions != nil {
+		discounts, err := o.promotions.Apply(cart)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range discounts {
+			i, ok := cartLines[promotion.LineKey{ProductID: d.ProductID, SKU: d.SKU}]
+			if !ok {
+				continue
+			}
+			lines[i].Discounts = append(lines[i].Discounts, ord.Discount{
+				PromotionID: d.PromotionID,
+				Name:        d.Name,
+				Amount:      d.Amount,
+			})
+		}
+	}
+
+	for i, rl := range req.Lines {
+		if err := comp(&lines[i], rl); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.RedeemPoints > 0 {
+		if err := o.redeemPoints(lines, cust, req.RedeemPoints); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range lines {
+		if err := o.addTax(&lines[i], products[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return lines, nil
+}
+
+// newLine snapshots the product, or the variant asked for, into an order line without amounts.
+// The recipe is snapshotted too, so a cancelled order returns the ingredients it took.
+func newLine(prd product.Product, rl RequestLine) (ord.Line, error) {
+	line := ord.Line{
+		ProductID: prd.GetID(),
+		Name:      prd.GetItem().Name,
+		UnitPrice: prd.GetPrice(),
+		Quantity:  rl.Quantity,
+		Notes:     rl.Notes,
+		Station:   string(prd.GetStation()),
+	}
+	switch {
+	case rl.VariantSKU != "":
+		v, err := prd.GetVariant(rl.VariantSKU)
+		if err != nil {
+			return ord.Line{}, err
+		}
+		line.SKU = v.SKU
+		line.Size = v.Size
+		line.UnitPrice = v.Price
+		line.Draw = v.Draw
+	case prd.HasVariants():
+		return ord.Line{}, fmt.Errorf("%s: %w", prd.GetItem().Name, ErrVariantRequired)
+	}
+	if rl.overridesPrice() {
+		if rl.PriceOverride.GetCurrency() != line.UnitPrice.GetCurrency() {
+			return ord.Line{}, fmt.Errorf("%s for %s: %w", rl.PriceOverride, line.UnitPrice, domain.ErrCurrencyMismatch)
+		}
+		line.UnitPrice = rl.PriceOverride
+		line.Overridden = true
+	}
+
+	// a variant of a product made to a recipe takes the ingredients of draw items
+	for _, i := range prd.GetRecipe() {
+		line.Ingredients = append(line.Ingredients, ord.Ingredient{
+			ProductID: i.ProductID,
+			Amount:    line.GetStockUnits(i.Amount),
+		})
+	}
+
+	return line, nil
+}
+
+// comp takes whatever is left of a comped line off it
+func comp(line *ord.Line, rl RequestLine) error {
+	if !rl.Comp {
+		return nil
+	}
+	discount, err := line.GetDiscount()
+	if err != nil {
+		return err
+	}
+	rest, err := line.GetSubtotal().Sub(discount)
+	if err != nil {
+		return err
+	}
+	if rest.IsPositive() {
+		line.Discounts = append(line.Discounts, ord.Discount{Name: "Comp", Amount: rest})
+	}
+
+	return nil
+}
+
+// addTax works out the tax on what is left of the line after discounts
+func (o *OrderService) addTax(line *ord.Line, prd product.Product) error {
+	discount, err := line.GetDiscount()
+	if err != nil {
+		return err
+	}
+	amount, err := line.GetSubtotal().Sub(discount)
+	if err != nil {
+		return err
+	}
+	amounts, err := o.tax.Apply(prd, amount)
+	if err != nil {
+		return err
+	}
+	line.Net = amounts.Net
+	line.Tax = amounts.Tax
+	line.Gross = amounts.Gross
+
+	return nil
+}
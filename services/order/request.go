@@ -0,0 +1,89 @@
+package order
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/devsrivatsa/tavernDDD/domain"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidQuantity   = errors.New("an order line needs a product and a quantity greater than zero")
+	ErrInvalidRedemption = errors.New("the points to redeem cannot be negative")
+	ErrInvalidOverride   = errors.New("a price override cannot be negative")
+)
+
+// RequestLine asks for quantity units of a product, notes are passed on to whoever prepares it.
+// Products sold in several sizes need the SKU of the variant.
+type RequestLine struct {
+	ProductID  uuid.UUID
+	VariantSKU string
+	Quantity   int
+	Notes      string
+	// PriceOverride replaces the unit price when set and Comp gives the line away,
+	// both need a manager
+	PriceOverride domain.Money
+	Comp          bool
+}
+
+// overridesPrice reports whether the line sets its own unit price
+func (l RequestLine) overridesPrice() bool {
+	return l.PriceOverride != (domain.Money{})
+}
+
+// Request is everything a customer asks for in one order
+type Request struct {
+	Lines []RequestLine
+	// RedeemPoints are loyalty points the customer spends on the order
+	RedeemPoints int64
+	// TableID is the table the order is served at, it is empty for orders at the bar
+	TableID uuid.UUID
+	// StaffID is the staff member taking the order
+	StaffID uuid.UUID
+}
+
+// Normalize validates the request and merges the lines asking for the same product variant
+// at the same price. Lines keep the position in which their variant was first asked for.
+func (r Request) Normalize() (Request, error) {
+	if len(r.Lines) == 0 {
+		return Request{}, ErrNoProducts
+	}
+	if r.RedeemPoints < 0 {
+		return Request{}, ErrInvalidRedemption
+	}
+
+	lines := make([]RequestLine, 0, len(r.Lines))
+	type variant struct {
+		productID uuid.UUID
+		sku       string
+		override  domain.Money
+		comp      bool
+	}
+	index := make(map[variant]int)
+	for i, l := range r.Lines {
+		if l.ProductID == uuid.Nil || l.Quantity <= 0 {
+			return Request{}, fmt.Errorf("line %d: %w", i, ErrInvalidQuantity)
+		}
+		if l.PriceOverride.IsNegative() {
+			return Request{}, fmt.Errorf("line %d: %w", i, ErrInvalidOverride)
+		}
+		key := variant{productID: l.ProductID, sku: l.VariantSKU, override: l.PriceOverride, comp: l.Comp}
+		j, ok := index[key]
+		if !ok {
+			index[key] = len(lines)
+			lines = append(lines, l)
+			continue
+		}
+		lines[j].Quantity += l.Quantity
+		switch {
+		case lines[j].Notes == "":
+			lines[j].Notes = l.Notes
+		case l.Notes != "":
+			lines[j].Notes += "; " + l.Notes
+		}
+	}
+
+	r.Lines = lines
+	return r, nil
+}
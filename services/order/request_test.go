@@ -0,0 +1,104 @@
+package order
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/devsrivatsa/tavernDDD/domain"
+	"github.com/google/uuid"
+)
+
+func TestRequest_Normalize(t *testing.T) {
+	beer := uuid.New()
+	wine := uuid.New()
+
+	type testCase struct {
+		test          string
+		lines         []RequestLine
+		expected      []RequestLine
+		expectedError error
+	}
+
+	testcases := []testCase{
+		{
+			test:          "No lines",
+			expectedError: ErrNoProducts,
+		},
+		{
+			test:          "Zero quantity",
+			lines:         []RequestLine{{ProductID: beer, Quantity: 0}},
+			expectedError: ErrInvalidQuantity,
+		},
+		{
+			test:          "Negative quantity",
+			lines:         []RequestLine{{ProductID: beer, Quantity: 2}, {ProductID: beer, Quantity: -1}},
+			expectedError: ErrInvalidQuantity,
+		},
+		{
+			test:          "Missing product",
+			lines:         []RequestLine{{Quantity: 1}},
+			expectedError: ErrInvalidQuantity,
+		},
+		{
+			test: "Duplicates are merged",
+			lines: []RequestLine{
+				{ProductID: beer, Quantity: 2, Notes: "no head"},
+				{ProductID: wine, Quantity: 1},
+				{ProductID: beer, Quantity: 8},
+				{ProductID: beer, Quantity: 1, Notes: "in a jug"},
+			},
+			expected: []RequestLine{
+				{ProductID: beer, Quantity: 11, Notes: "no head; in a jug"},
+				{ProductID: wine, Quantity: 1},
+			},
+		},
+		{
+			test: "Variants are kept apart",
+			lines: []RequestLine{
+				{ProductID: beer, VariantSKU: "BEER-PINT", Quantity: 1},
+				{ProductID: beer, VariantSKU: "BEER-HALF", Quantity: 1},
+				{ProductID: beer, VariantSKU: "BEER-PINT", Quantity: 2},
+			},
+			expected: []RequestLine{
+				{ProductID: beer, VariantSKU: "BEER-PINT", Quantity: 3},
+				{ProductID: beer, VariantSKU: "BEER-HALF", Quantity: 1},
+			},
+		},
+		{
+			test: "Comps and overrides are kept apart",
+			lines: []RequestLine{
+				{ProductID: beer, Quantity: 1},
+				{ProductID: beer, Quantity: 1, Comp: true},
+				{ProductID: beer, Quantity: 1, PriceOverride: domain.MustNewMoney(150, "EUR")},
+				{ProductID: beer, Quantity: 1, Comp: true},
+			},
+			expected: []RequestLine{
+				{ProductID: beer, Quantity: 1},
+				{ProductID: beer, Quantity: 2, Comp: true},
+				{ProductID: beer, Quantity: 1, PriceOverride: domain.MustNewMoney(150, "EUR")},
+			},
+		},
+		{
+			test:          "Negative override",
+			lines:         []RequestLine{{ProductID: beer, Quantity: 1, PriceOverride: domain.MustNewMoney(-1, "EUR")}},
+			expectedError: ErrInvalidOverride,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.test, func(t *testing.T) {
+			req, err := Request{Lines: tc.lines}.Normalize()
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("expected error %v, got %v", tc.expectedError, err)
+			}
+			if len(req.Lines) != len(tc.expected) {
+				t.Fatalf("expected %d lines, got %d", len(tc.expected), len(req.Lines))
+			}
+			for i, l := range req.Lines {
+				if l != tc.expected[i] {
+					t.Errorf("line %d: expected %+v, got %+v", i, tc.expected[i], l)
+				}
+			}
+		})
+	}
+}
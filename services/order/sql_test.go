@@ -0,0 +1,57 @@
+package order
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestOrder_WithSQLRepositories(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// every connection to :memory: is a database of its own
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+
+	products := init_products(t)
+	os, err := NewOrderService(
+		WithSQLRepositories(context.Background(), db),
+		WithMemoryOrderRepository(),
+	)
+	if err != nil {
+		t.Fatalf("Error creating order service: %v", err)
+	}
+	for _, p := range products {
+		if err := os.products.Add(context.Background(), p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	customerID, err := os.AddCustomer(context.Background(), "John Doe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = os.CreateOrder(context.Background(), customerID, Request{Lines: []RequestLine{{ProductID: products[0].GetID(), Quantity: 3}}})
+	if err != nil {
+		t.Fatalf("Error creating order: %v", err)
+	}
+
+	beer, err := os.products.GetByID(context.Background(), products[0].GetID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if beer.GetQuantity() != 7 {
+		t.Errorf("expected 7 beers left, got %d", beer.GetQuantity())
+	}
+	c, err := os.customers.Get(context.Background(), customerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.PurchaseHistory()) != 1 || c.GetVersion() == 0 {
+		t.Errorf("expected the purchase to be stored, got %d purchases at version %d", len(c.PurchaseHistory()), c.GetVersion())
+	}
+}
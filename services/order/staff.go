@@ -0,0 +1,76 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	ord "github.com/devsrivatsa/tavernDDD/domain/order"
+	"github.com/devsrivatsa/tavernDDD/domain/staff"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNoStaffRepository = errors.New("the order service does not know the staff")
+	ErrMissingStaff      = errors.New("the order needs the staff member taking it")
+)
+
+// checkStaff makes sure the staff member may take the order, and is allowed to comp its lines and
+// override their prices. Without a staff repository orders are not checked, but comps and
+// price overrides are refused.
+func (o *OrderService) checkStaff(ctx context.Context, req Request) error {
+	var actions []staff.Action
+	if o.staff != nil {
+		actions = append(actions, staff.ActionTakeOrder)
+	}
+	for _, rl := range req.Lines {
+		if rl.Comp {
+			actions = append(actions, staff.ActionComp)
+		}
+		if rl.overridesPrice() {
+			actions = append(actions, staff.ActionOverridePrice)
+		}
+	}
+	if len(actions) == 0 {
+		return nil
+	}
+
+	return o.authorize(ctx, req.StaffID, actions...)
+}
+
+// authorize reports why the staff member may not take all of the actions
+func (o *OrderService) authorize(ctx context.Context, staffID uuid.UUID, actions ...staff.Action) error {
+	if o.staff == nil {
+		return ErrNoStaffRepository
+	}
+	if staffID == uuid.Nil {
+		return ErrMissingStaff
+	}
+	member, err := o.staff.Get(ctx, staffID)
+	if err != nil {
+		return err
+	}
+	for _, a := range actions {
+		if err := member.Authorize(a); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// VoidOrder calls off an order that has not been paid yet on behalf of a manager and records who
+// voided it. The stock is returned and the customer is reimbursed, as for CancelOrder.
+func (o *OrderService) VoidOrder(ctx context.Context, orderID, staffID uuid.UUID) (ord.Order, error) {
+	if err := o.authorize(ctx, staffID, staff.ActionVoid); err != nil {
+		return ord.Order{}, fmt.Errorf("error voiding order %s: %w", orderID, err)
+	}
+	order, err := o.updateOrder(ctx, orderID, func(order *ord.Order) error {
+		return order.Void(staffID)
+	})
+	if err != nil {
+		return ord.Order{}, err
+	}
+
+	return o.rollBack(ctx, order)
+}
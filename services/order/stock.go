@@ -0,0 +1,141 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+
+	ord "github.com/devsrivatsa/tavernDDD/domain/order"
+	"github.com/devsrivatsa/tavernDDD/domain/product"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrIngredientsOut = errors.New("the product cannot be made, one of its ingredients ran out")
+)
+
+// takeStock takes what the lines need from stock, the ingredients for products made to
+// a recipe and the product itself otherwise. If one of the products runs out,
+// the stock already taken is put back.
+func (o *OrderService) takeStock(ctx context.Context, lines []ord.Line) error {
+	draws, ingredients := stockDraws(lines)
+	ids := sortedIDs(draws)
+	for i, id := range ids {
+		if _, err := o.products.AdjustStock(ctx, id, -draws[id]); err != nil {
+			for _, taken := range ids[:i] {
+				o.putBack(ctx, taken, draws[taken])
+			}
+			if ingredients[id] {
+				return fmt.Errorf("%w: %w", ErrIngredientsOut, err)
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// returnStock puts back what takeStock took for the lines
+func (o *OrderService) returnStock(ctx context.Context, lines []ord.Line) {
+	draws, _ := stockDraws(lines)
+	for _, id := range sortedIDs(draws) {
+		o.putBack(ctx, id, draws[id])
+	}
+}
+
+// putBack returns stock even when the request was called off, so nothing taken for it is lost
+func (o *OrderService) putBack(ctx context.Context, id uuid.UUID, units int) {
+	ctx = context.WithoutCancel(ctx)
+	if _, err := o.products.AdjustStock(ctx, id, units); err != nil {
+		log.Printf("error returning %d of product %s to stock: %v", units, id, err)
+	}
+}
+
+// stockDraws adds up the stock units the lines take per product and tells which products are ingredients
+func stockDraws(lines []ord.Line) (map[uuid.UUID]int, map[uuid.UUID]bool) {
+	draws := make(map[uuid.UUID]int)
+	ingredients := make(map[uuid.UUID]bool)
+	for _, l := range lines {
+		for id, units := range l.GetStockDraws(l.Quantity) {
+			draws[id] += units
+			if id != l.ProductID {
+				ingredients[id] = true
+			}
+		}
+	}
+	return draws, ingredients
+}
+
+// sortedIDs returns the products in a fixed order, so stock is always taken in the same order
+func sortedIDs(draws map[uuid.UUID]int) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(draws))
+	for id := range draws {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i].String() < ids[j].String()
+	})
+	return ids
+}
+
+// CheckOrderable tells whether one item of the product can be ordered from what is in stock.
+// Products made to a recipe fail with ErrIngredientsOut once one of their ingredients ran out,
+// other products fail with product.ErrOutOfStock.
+func (o *OrderService) CheckOrderable(ctx context.Context, productID uuid.UUID) error {
+	prd, err := o.products.GetByID(ctx, productID)
+	if err != nil {
+		return err
+	}
+	return o.checkOrderable(ctx, prd)
+}
+
+// GetOrderableProducts returns the products that can be ordered from what is in stock
+func (o *OrderService) GetOrderableProducts(ctx context.Context) ([]product.Product, error) {
+	products, err := o.products.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	orderable := make([]product.Product, 0, len(products))
+	for _, prd := range products {
+		err := o.checkOrderable(ctx, prd)
+		switch {
+		case err == nil:
+			orderable = append(orderable, prd)
+		case !errors.Is(err, ErrIngredientsOut) && !errors.Is(err, product.ErrOutOfStock):
+			return nil, err
+		}
+	}
+
+	return orderable, nil
+}
+
+// checkOrderable checks the stock for the smallest serving of the product
+func (o *OrderService) checkOrderable(ctx context.Context, prd product.Product) error {
+	draw := 1
+	for i, v := range prd.GetVariants() {
+		if i == 0 || v.Draw < draw {
+			draw = v.Draw
+		}
+	}
+	if !prd.HasRecipe() {
+		if prd.GetQuantity() < draw {
+			return fmt.Errorf("%s has %d on hand: %w", prd.GetItem().Name, prd.GetQuantity(), product.ErrOutOfStock)
+		}
+		return nil
+	}
+
+	for _, i := range prd.GetRecipe() {
+		ingredient, err := o.products.GetByID(ctx, i.ProductID)
+		if err != nil {
+			return fmt.Errorf("ingredient %s of %s: %w", i.ProductID, prd.GetItem().Name, err)
+		}
+		if ingredient.GetQuantity() < i.Amount*draw {
+			return fmt.Errorf("%s needs %d of %s, %d on hand: %w", prd.GetItem().Name, i.Amount*draw, ingredient.GetItem().Name, ingredient.GetQuantity(), ErrIngredientsOut)
+		}
+	}
+
+	return nil
+}
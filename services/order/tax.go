@@ -0,0 +1,134 @@
+package order
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/devsrivatsa/tavernDDD/domain"
+	"github.com/devsrivatsa/tavernDDD/domain/product"
+)
+
+var (
+	ErrInvalidTaxRate = errors.New("a tax rate must be between 0 and 10000 basis points")
+)
+
+// TaxAmounts breaks an amount down into the part before tax, the tax and the total
+type TaxAmounts struct {
+	Net   domain.Money
+	Tax   domain.Money
+	Gross domain.Money
+}
+
+// TaxPolicy works out the tax on an amount charged for a product
+type TaxPolicy interface {
+	Apply(prd product.Product, amount domain.Money) (TaxAmounts, error)
+}
+
+// TaxRate gives the tax rate of a product in basis points, 2000 is 20%
+type TaxRate interface {
+	Rate(prd product.Product) int64
+}
+
+// noTax is used when no tax policy is configured, prices are charged as they are
+type noTax struct{}
+
+func (noTax) Apply(_ product.Product, amount domain.Money) (TaxAmounts, error) {
+	return TaxAmounts{Net: amount, Tax: amount.Multiply(0), Gross: amount}, nil
+}
+
+// FlatRateTax adds the same tax rate to every product
+type FlatRateTax struct {
+	basisPoints int64
+}
+
+func NewFlatRateTax(basisPoints int64) (*FlatRateTax, error) {
+	if err := validateRate(basisPoints); err != nil {
+		return nil, err
+	}
+	return &FlatRateTax{basisPoints: basisPoints}, nil
+}
+
+func (f *FlatRateTax) Rate(_ product.Product) int64 {
+	return f.basisPoints
+}
+
+func (f *FlatRateTax) Apply(prd product.Product, amount domain.Money) (TaxAmounts, error) {
+	return addTax(amount, f.Rate(prd))
+}
+
+// ClassRateTax adds a tax rate depending on the tax class of the product, e.g. food vs alcohol
+type ClassRateTax struct {
+	rates    map[product.TaxClass]int64
+	fallback int64
+}
+
+// NewClassRateTax uses fallback for the classes missing from rates
+func NewClassRateTax(rates map[product.TaxClass]int64, fallback int64) (*ClassRateTax, error) {
+	if err := validateRate(fallback); err != nil {
+		return nil, err
+	}
+	copied := make(map[product.TaxClass]int64, len(rates))
+	for class, rate := range rates {
+		if err := validateRate(rate); err != nil {
+			return nil, fmt.Errorf("tax class %s: %w", class, err)
+		}
+		copied[class] = rate
+	}
+
+	return &ClassRateTax{rates: copied, fallback: fallback}, nil
+}
+
+func (c *ClassRateTax) Rate(prd product.Product) int64 {
+	if rate, ok := c.rates[prd.GetTaxClass()]; ok {
+		return rate
+	}
+	return c.fallback
+}
+
+func (c *ClassRateTax) Apply(prd product.Product, amount domain.Money) (TaxAmounts, error) {
+	return addTax(amount, c.Rate(prd))
+}
+
+// InclusiveTax is for menus where prices already include tax. The tax is taken out of the
+// price instead of being added on top, so the customer pays exactly the listed price.
+type InclusiveTax struct {
+	rates TaxRate
+}
+
+func NewInclusiveTax(rates TaxRate) (*InclusiveTax, error) {
+	if rates == nil {
+		return nil, ErrInvalidTaxRate
+	}
+	return &InclusiveTax{rates: rates}, nil
+}
+
+func (i *InclusiveTax) Apply(prd product.Product, amount domain.Money) (TaxAmounts, error) {
+	rate := i.rates.Rate(prd)
+	if err := validateRate(rate); err != nil {
+		return TaxAmounts{}, err
+	}
+	tax := amount.MultiplyFraction(rate, 10000+rate)
+	net, err := amount.Sub(tax)
+	if err != nil {
+		return TaxAmounts{}, err
+	}
+
+	return TaxAmounts{Net: net, Tax: tax, Gross: amount}, nil
+}
+
+func addTax(amount domain.Money, rate int64) (TaxAmounts, error) {
+	tax := amount.MultiplyFraction(rate, 10000)
+	gross, err := amount.Add(tax)
+	if err != nil {
+		return TaxAmounts{}, err
+	}
+
+	return TaxAmounts{Net: amount, Tax: tax, Gross: gross}, nil
+}
+
+func validateRate(basisPoints int64) error {
+	if basisPoints < 0 || basisPoints > 10000 {
+		return ErrInvalidTaxRate
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package order
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/devsrivatsa/tavernDDD/domain"
+	"github.com/devsrivatsa/tavernDDD/domain/product"
+)
+
+func TestTax_Policies(t *testing.T) {
+	products := init_products(t)
+	beer, peanuts := products[0], products[1]
+	if err := beer.SetTaxClass(product.TaxClassAlcohol); err != nil {
+		t.Fatal(err)
+	}
+	if err := peanuts.SetTaxClass(product.TaxClassFood); err != nil {
+		t.Fatal(err)
+	}
+
+	flat, err := NewFlatRateTax(2000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	classes, err := NewClassRateTax(map[product.TaxClass]int64{
+		product.TaxClassFood:    700,
+		product.TaxClassAlcohol: 2000,
+	}, 1900)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inclusive, err := NewInclusiveTax(classes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type testCase struct {
+		test     string
+		policy   TaxPolicy
+		prd      product.Product
+		amount   int64
+		expected [3]int64
+	}
+	testcases := []testCase{
+		{test: "No tax", policy: noTax{}, prd: beer, amount: 1000, expected: [3]int64{1000, 0, 1000}},
+		{test: "Flat rate", policy: flat, prd: peanuts, amount: 199, expected: [3]int64{199, 40, 239}},
+		{test: "Food class", policy: classes, prd: peanuts, amount: 1000, expected: [3]int64{1000, 70, 1070}},
+		{test: "Alcohol class", policy: classes, prd: beer, amount: 1000, expected: [3]int64{1000, 200, 1200}},
+		{test: "Tax inclusive", policy: inclusive, prd: beer, amount: 1200, expected: [3]int64{1000, 200, 1200}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.test, func(t *testing.T) {
+			amounts, err := tc.policy.Apply(tc.prd, domain.MustNewMoney(tc.amount, "EUR"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := [3]int64{amounts.Net.GetAmount(), amounts.Tax.GetAmount(), amounts.Gross.GetAmount()}
+			if got != tc.expected {
+				t.Errorf("expected net, tax and gross of %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestTax_InvalidRate(t *testing.T) {
+	if _, err := NewFlatRateTax(-1); !errors.Is(err, ErrInvalidTaxRate) {
+		t.Errorf("expected error %v, got %v", ErrInvalidTaxRate, err)
+	}
+	_, err := NewClassRateTax(map[product.TaxClass]int64{product.TaxClassFood: 10001}, 0)
+	if !errors.Is(err, ErrInvalidTaxRate) {
+		t.Errorf("expected error %v, got %v", ErrInvalidTaxRate, err)
+	}
+}
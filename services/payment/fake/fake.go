@@ -0,0 +1,177 @@
+package fake
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/devsrivatsa/tavernDDD/domain"
+	"github.com/devsrivatsa/tavernDDD/services/payment"
+	"github.com/google/uuid"
+)
+
+// Outcome is what the fake provider answers to a call
+type Outcome int
+
+const (
+	Approve Outcome = iota
+	Decline
+	Timeout
+)
+
+// FakeGateway is a deterministic in-process payment provider. It approves everything
+// unless outcomes are scripted, and hands out authorization IDs auth-1, auth-2, ...
+type FakeGateway struct {
+	authorizations map[string]payment.Authorization
+	script         []Outcome
+	next           int
+	sync.Mutex
+}
+
+func New() *FakeGateway {
+	return &FakeGateway{
+		authorizations: make(map[string]payment.Authorization),
+	}
+}
+
+// Script queues the outcomes of the next calls, one outcome per call of any kind
+func (f *FakeGateway) Script(outcomes ...Outcome) {
+	f.Lock()
+	defer f.Unlock()
+
+	f.script = append(f.script, outcomes...)
+}
+
+// Get returns the authorization as the provider sees it
+func (f *FakeGateway) Get(authorizationID string) (payment.Authorization, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	if auth, ok := f.authorizations[authorizationID]; ok {
+		return auth, nil
+	}
+
+	return payment.Authorization{}, payment.ErrAuthorizationNotFound
+}
+
+func (f *FakeGateway) Authorize(customerID uuid.UUID, amount domain.Money) (payment.Authorization, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	if err := f.outcome(); err != nil {
+		return payment.Authorization{}, err
+	}
+	if !amount.IsPositive() {
+		return payment.Authorization{}, payment.ErrInvalidAmount
+	}
+	zero, err := domain.NewMoney(0, amount.GetCurrency())
+	if err != nil {
+		return payment.Authorization{}, err
+	}
+	f.next++
+	auth := payment.Authorization{
+		ID:         fmt.Sprintf("auth-%d", f.next),
+		CustomerID: customerID,
+		Amount:     amount,
+		Captured:   zero,
+		Refunded:   zero,
+		Status:     payment.StatusAuthorized,
+	}
+	f.authorizations[auth.ID] = auth
+
+	return auth, nil
+}
+
+func (f *FakeGateway) Capture(authorizationID string, amount domain.Money) (payment.Authorization, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	auth, err := f.get(authorizationID, payment.StatusAuthorized)
+	if err != nil {
+		return payment.Authorization{}, err
+	}
+	if err := f.outcome(); err != nil {
+		return payment.Authorization{}, err
+	}
+	tooMuch, err := amount.GreaterThan(auth.Amount)
+	if err != nil || tooMuch || !amount.IsPositive() {
+		return payment.Authorization{}, payment.ErrInvalidAmount
+	}
+	auth.Captured = amount
+	auth.Status = payment.StatusCaptured
+	f.authorizations[auth.ID] = auth
+
+	return auth, nil
+}
+
+func (f *FakeGateway) Void(authorizationID string) (payment.Authorization, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	auth, err := f.get(authorizationID, payment.StatusAuthorized)
+	if err != nil {
+		return payment.Authorization{}, err
+	}
+	if err := f.outcome(); err != nil {
+		return payment.Authorization{}, err
+	}
+	auth.Status = payment.StatusVoided
+	f.authorizations[auth.ID] = auth
+
+	return auth, nil
+}
+
+func (f *FakeGateway) Refund(authorizationID string, amount domain.Money) (payment.Authorization, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	auth, err := f.get(authorizationID, payment.StatusCaptured)
+	if err != nil {
+		return payment.Authorization{}, err
+	}
+	if err := f.outcome(); err != nil {
+		return payment.Authorization{}, err
+	}
+	refunded, err := auth.Refunded.Add(amount)
+	if err != nil {
+		return payment.Authorization{}, payment.ErrInvalidAmount
+	}
+	if tooMuch, _ := refunded.GreaterThan(auth.Captured); tooMuch || !amount.IsPositive() {
+		return payment.Authorization{}, payment.ErrInvalidAmount
+	}
+	auth.Refunded = refunded
+	if refunded.Equals(auth.Captured) {
+		auth.Status = payment.StatusRefunded
+	}
+	f.authorizations[auth.ID] = auth
+
+	return auth, nil
+}
+
+func (f *FakeGateway) get(authorizationID string, status payment.Status) (payment.Authorization, error) {
+	auth, ok := f.authorizations[authorizationID]
+	if !ok {
+		return payment.Authorization{}, payment.ErrAuthorizationNotFound
+	}
+	if auth.Status != status {
+		return payment.Authorization{}, fmt.Errorf("authorization %s is %s: %w", auth.ID, auth.Status, payment.ErrInvalidState)
+	}
+
+	return auth, nil
+}
+
+// outcome pops the next scripted outcome
+func (f *FakeGateway) outcome() error {
+	if len(f.script) == 0 {
+		return nil
+	}
+	o := f.script[0]
+	f.script = f.script[1:]
+
+	switch o {
+	case Decline:
+		return payment.ErrDeclined
+	case Timeout:
+		return payment.ErrTimeout
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package fake
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/devsrivatsa/tavernDDD/domain"
+	"github.com/devsrivatsa/tavernDDD/services/payment"
+	"github.com/google/uuid"
+)
+
+func TestFakeGateway_Script(t *testing.T) {
+	gw := New()
+	gw.Script(Decline, Timeout)
+
+	amount := domain.MustNewMoney(897, "EUR")
+	if _, err := gw.Authorize(uuid.New(), amount); !errors.Is(err, payment.ErrDeclined) {
+		t.Errorf("expected error %v, got %v", payment.ErrDeclined, err)
+	}
+	if _, err := gw.Authorize(uuid.New(), amount); !errors.Is(err, payment.ErrTimeout) {
+		t.Errorf("expected error %v, got %v", payment.ErrTimeout, err)
+	}
+	auth, err := gw.Authorize(uuid.New(), amount)
+	if err != nil {
+		t.Fatalf("expected the script to be used up, got %v", err)
+	}
+	if auth.ID != "auth-1" {
+		t.Errorf("expected authorization auth-1, got %s", auth.ID)
+	}
+}
+
+func TestFakeGateway_Lifecycle(t *testing.T) {
+	gw := New()
+	amount := domain.MustNewMoney(897, "EUR")
+
+	auth, err := gw.Authorize(uuid.New(), amount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gw.Refund(auth.ID, amount); !errors.Is(err, payment.ErrInvalidState) {
+		t.Errorf("expected error %v, got %v", payment.ErrInvalidState, err)
+	}
+	if _, err := gw.Capture(auth.ID, domain.MustNewMoney(1000, "EUR")); !errors.Is(err, payment.ErrInvalidAmount) {
+		t.Errorf("expected error %v, got %v", payment.ErrInvalidAmount, err)
+	}
+	if _, err := gw.Capture(auth.ID, amount); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gw.Void(auth.ID); !errors.Is(err, payment.ErrInvalidState) {
+		t.Errorf("expected error %v, got %v", payment.ErrInvalidState, err)
+	}
+	if _, err := gw.Refund(auth.ID, domain.MustNewMoney(500, "EUR")); err != nil {
+		t.Fatal(err)
+	}
+	auth, err = gw.Refund(auth.ID, domain.MustNewMoney(397, "EUR"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth.Status != payment.StatusRefunded {
+		t.Errorf("expected a fully refunded authorization, got %s", auth.Status)
+	}
+}
@@ -0,0 +1,47 @@
+package payment
+
+import (
+	"errors"
+
+	"github.com/devsrivatsa/tavernDDD/domain"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrDeclined              = errors.New("payment declined")
+	ErrTimeout               = errors.New("payment provider timed out")
+	ErrAuthorizationNotFound = errors.New("authorization not found")
+	ErrInvalidState          = errors.New("operation not allowed for the authorization in its current state")
+	ErrInvalidAmount         = errors.New("amount must be greater than zero and within the authorized amount")
+)
+
+type Status string
+
+const (
+	StatusAuthorized Status = "authorized"
+	StatusCaptured   Status = "captured"
+	StatusVoided     Status = "voided"
+	StatusRefunded   Status = "refunded"
+)
+
+// Authorization is a hold on the card of a customer as reported by the payment provider
+type Authorization struct {
+	ID         string
+	CustomerID uuid.UUID
+	Amount     domain.Money
+	Captured   domain.Money
+	Refunded   domain.Money
+	Status     Status
+}
+
+// Gateway talks to the card payment provider
+type Gateway interface {
+	// Authorize puts a hold over amount on the card of the customer
+	Authorize(customerID uuid.UUID, amount domain.Money) (Authorization, error)
+	// Capture takes up to the authorized amount from the card
+	Capture(authorizationID string, amount domain.Money) (Authorization, error)
+	// Void releases an authorization that has not been captured
+	Void(authorizationID string) (Authorization, error)
+	// Refund gives back (part of) a captured amount
+	Refund(authorizationID string, amount domain.Money) (Authorization, error)
+}
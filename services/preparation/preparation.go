@@ -0,0 +1,192 @@
+package preparation
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	ord "github.com/devsrivatsa/tavernDDD/domain/order"
+	"github.com/devsrivatsa/tavernDDD/domain/product"
+	"github.com/devsrivatsa/tavernDDD/domain/ticket"
+	tickMem "github.com/devsrivatsa/tavernDDD/domain/ticket/memory"
+	"github.com/google/uuid"
+)
+
+// subscriptionBuffer is how many new tickets a subscriber can fall behind before tickets are
+// no longer pushed to it. Missed tickets stay pending and can be fetched with GetPending.
+const subscriptionBuffer = 32
+
+type PreparationConfiguration func(ps *PreparationService) error
+
+// PreparationService turns placed orders into tickets for the bar and the kitchen
+// and follows them until they are served
+type PreparationService struct {
+	tickets ticket.TicketRepository
+	//ticketsLock keeps two stations from moving the same ticket at once
+	ticketsLock sync.Mutex
+	now         func() time.Time
+	//subscribers lock guards the channels of the stations listening for new tickets
+	subscribersLock sync.Mutex
+	subscribers     map[product.Station]map[int]chan ticket.Ticket
+	nextSubscriber  int
+}
+
+// factory function to create a new preparation service, tickets are kept in memory unless
+// another repository is configured
+func NewPreparationService(cfgs ...PreparationConfiguration) (*PreparationService, error) {
+	ps := &PreparationService{
+		tickets:     tickMem.New(),
+		now:         time.Now,
+		subscribers: make(map[product.Station]map[int]chan ticket.Ticket),
+	}
+	for _, cfg := range cfgs {
+		if err := cfg(ps); err != nil {
+			return nil, err
+		}
+	}
+
+	return ps, nil
+}
+
+func WithTicketRepository(tr ticket.TicketRepository) PreparationConfiguration {
+	return func(ps *PreparationService) error {
+		ps.tickets = tr
+		return nil
+	}
+}
+
+// WithClock replaces the clock used to time tickets
+func WithClock(now func() time.Time) PreparationConfiguration {
+	return func(ps *PreparationService) error {
+		ps.now = now
+		return nil
+	}
+}
+
+// Enqueue splits the order into a ticket per station, queues them and pushes them to
+// the subscribers of their station
+func (ps *PreparationService) Enqueue(ctx context.Context, o ord.Order) ([]ticket.Ticket, error) {
+	tickets, err := ticket.Split(o, ps.now())
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tickets {
+		if err := ps.tickets.Add(ctx, t); err != nil {
+			return nil, err
+		}
+	}
+	for _, t := range tickets {
+		ps.publish(t)
+	}
+
+	return tickets, nil
+}
+
+// Subscribe returns a channel receiving the new tickets of the station and a function to stop
+// the subscription, which closes the channel. Stations can subscribe concurrently.
+func (ps *PreparationService) Subscribe(station product.Station) (<-chan ticket.Ticket, func()) {
+	ps.subscribersLock.Lock()
+	defer ps.subscribersLock.Unlock()
+
+	id := ps.nextSubscriber
+	ps.nextSubscriber++
+	ch := make(chan ticket.Ticket, subscriptionBuffer)
+	if ps.subscribers[station] == nil {
+		ps.subscribers[station] = make(map[int]chan ticket.Ticket)
+	}
+	ps.subscribers[station][id] = ch
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			ps.subscribersLock.Lock()
+			defer ps.subscribersLock.Unlock()
+			delete(ps.subscribers[station], id)
+			close(ch)
+		})
+	}
+}
+
+// publish pushes the ticket to the subscribers of its station without waiting for slow ones
+func (ps *PreparationService) publish(t ticket.Ticket) {
+	ps.subscribersLock.Lock()
+	defer ps.subscribersLock.Unlock()
+
+	for _, ch := range ps.subscribers[t.GetStation()] {
+		select {
+		case ch <- t:
+		default:
+			log.Printf("a subscriber of the %s is behind, ticket %s stays pending", t.GetStation(), t.GetID())
+		}
+	}
+}
+
+// GetPending returns the tickets of the station that have not been served or cancelled yet,
+// oldest first
+func (ps *PreparationService) GetPending(ctx context.Context, station product.Station) ([]ticket.Ticket, error) {
+	return ps.tickets.GetPending(ctx, station)
+}
+
+func (ps *PreparationService) GetOrderTickets(ctx context.Context, orderID uuid.UUID) ([]ticket.Ticket, error) {
+	return ps.tickets.GetByOrder(ctx, orderID)
+}
+
+// Start marks a queued ticket as being prepared
+func (ps *PreparationService) Start(ctx context.Context, ticketID uuid.UUID) (ticket.Ticket, error) {
+	return ps.update(ctx, ticketID, (*ticket.Ticket).Start)
+}
+
+// MarkReady marks a ticket as prepared and waiting to be served
+func (ps *PreparationService) MarkReady(ctx context.Context, ticketID uuid.UUID) (ticket.Ticket, error) {
+	return ps.update(ctx, ticketID, (*ticket.Ticket).MarkReady)
+}
+
+func (ps *PreparationService) Serve(ctx context.Context, ticketID uuid.UUID) (ticket.Ticket, error) {
+	return ps.update(ctx, ticketID, (*ticket.Ticket).Serve)
+}
+
+// Withdraw cancels the tickets of the order that have not been served, e.g. when the order is
+// voided, and returns them. Served tickets are left as they are.
+func (ps *PreparationService) Withdraw(ctx context.Context, orderID uuid.UUID) ([]ticket.Ticket, error) {
+	ps.ticketsLock.Lock()
+	defer ps.ticketsLock.Unlock()
+
+	tickets, err := ps.tickets.GetByOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	var withdrawn []ticket.Ticket
+	for _, t := range tickets {
+		if t.GetStatus() == ticket.StatusServed || t.GetStatus() == ticket.StatusCancelled {
+			continue
+		}
+		if err := t.Cancel(ps.now()); err != nil {
+			return withdrawn, err
+		}
+		if err := ps.tickets.Update(ctx, t); err != nil {
+			return withdrawn, err
+		}
+		withdrawn = append(withdrawn, t)
+	}
+
+	return withdrawn, nil
+}
+
+func (ps *PreparationService) update(ctx context.Context, id uuid.UUID, change func(t *ticket.Ticket, at time.Time) error) (ticket.Ticket, error) {
+	ps.ticketsLock.Lock()
+	defer ps.ticketsLock.Unlock()
+
+	t, err := ps.tickets.Get(ctx, id)
+	if err != nil {
+		return ticket.Ticket{}, err
+	}
+	if err := change(&t, ps.now()); err != nil {
+		return ticket.Ticket{}, err
+	}
+	if err := ps.tickets.Update(ctx, t); err != nil {
+		return ticket.Ticket{}, err
+	}
+
+	return t, nil
+}
@@ -0,0 +1,170 @@
+package preparation
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/devsrivatsa/tavernDDD/domain"
+	ord "github.com/devsrivatsa/tavernDDD/domain/order"
+	"github.com/devsrivatsa/tavernDDD/domain/product"
+	"github.com/devsrivatsa/tavernDDD/domain/ticket"
+	"github.com/google/uuid"
+)
+
+func newOrder(t *testing.T) ord.Order {
+	o, err := ord.NewOrder(uuid.New(), []ord.Line{
+		{ProductID: uuid.New(), Name: "Beer", UnitPrice: domain.MustNewMoney(199, "EUR"), Quantity: 2, Station: "bar"},
+		{ProductID: uuid.New(), Name: "Stew", UnitPrice: domain.MustNewMoney(899, "EUR"), Quantity: 1, Station: "kitchen"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return o
+}
+
+func TestPreparationService_Subscribe(t *testing.T) {
+	ps, err := NewPreparationService()
+	if err != nil {
+		t.Fatalf("Error creating preparation service: %v", err)
+	}
+
+	const orders = 10
+	stations := []product.Station{product.StationBar, product.StationBar, product.StationKitchen}
+	var wg sync.WaitGroup
+	received := make([]int, len(stations))
+	for i, station := range stations {
+		tickets, stop := ps.Subscribe(station)
+		wg.Add(1)
+		go func(i int, station product.Station) {
+			defer wg.Done()
+			for tk := range tickets {
+				if tk.GetStation() != station {
+					t.Errorf("expected a ticket for the %s, got one for the %s", station, tk.GetStation())
+				}
+				received[i]++
+				if received[i] == orders {
+					stop()
+				}
+			}
+		}(i, station)
+	}
+
+	var placed sync.WaitGroup
+	for i := 0; i < orders; i++ {
+		placed.Add(1)
+		go func() {
+			defer placed.Done()
+			if _, err := ps.Enqueue(context.Background(), newOrder(t)); err != nil {
+				t.Errorf("Error enqueuing order: %v", err)
+			}
+		}()
+	}
+	placed.Wait()
+	wg.Wait()
+
+	for i, n := range received {
+		if n != orders {
+			t.Errorf("expected subscriber %d of the %s to receive %d tickets, got %d", i, stations[i], orders, n)
+		}
+	}
+}
+
+func TestPreparationService_Lifecycle(t *testing.T) {
+	now := time.Date(2024, 3, 1, 20, 0, 0, 0, time.UTC)
+	ps, err := NewPreparationService(WithClock(func() time.Time { return now }))
+	if err != nil {
+		t.Fatalf("Error creating preparation service: %v", err)
+	}
+	o := newOrder(t)
+	tickets, err := ps.Enqueue(context.Background(), o)
+	if err != nil {
+		t.Fatalf("Error enqueuing order: %v", err)
+	}
+	if len(tickets) != 2 {
+		t.Fatalf("expected a ticket for the bar and one for the kitchen, got %d", len(tickets))
+	}
+	bar := tickets[0]
+
+	if _, err := ps.Serve(context.Background(), bar.GetID()); !errors.Is(err, ticket.ErrInvalidStatus) {
+		t.Errorf("expected error %v, got %v", ticket.ErrInvalidStatus, err)
+	}
+	if _, err := ps.Start(context.Background(), uuid.New()); !errors.Is(err, ticket.ErrTicketNotFound) {
+		t.Errorf("expected error %v, got %v", ticket.ErrTicketNotFound, err)
+	}
+	for _, step := range []func(context.Context, uuid.UUID) (ticket.Ticket, error){ps.Start, ps.MarkReady, ps.Serve} {
+		now = now.Add(time.Minute)
+		if _, err := step(context.Background(), bar.GetID()); err != nil {
+			t.Fatalf("Error moving ticket: %v", err)
+		}
+	}
+
+	pending, err := ps.GetPending(context.Background(), product.StationBar)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("expected nothing pending at the bar, got %d tickets", len(pending))
+	}
+	byOrder, err := ps.GetOrderTickets(context.Background(), o.GetID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tk := range byOrder {
+		if tk.GetID() == bar.GetID() && (tk.GetStatus() != ticket.StatusServed || !tk.GetUpdatedAt().Equal(now)) {
+			t.Errorf("expected the bar ticket served at %s, got %s at %s", now, tk.GetStatus(), tk.GetUpdatedAt())
+		}
+	}
+}
+
+func TestPreparationService_Withdraw(t *testing.T) {
+	now := time.Date(2024, 3, 1, 20, 0, 0, 0, time.UTC)
+	ps, err := NewPreparationService(WithClock(func() time.Time { return now }))
+	if err != nil {
+		t.Fatalf("Error creating preparation service: %v", err)
+	}
+	o := newOrder(t)
+	tickets, err := ps.Enqueue(context.Background(), o)
+	if err != nil {
+		t.Fatalf("Error enqueuing order: %v", err)
+	}
+	bar, kitchen := tickets[0], tickets[1]
+	for _, step := range []func(context.Context, uuid.UUID) (ticket.Ticket, error){ps.Start, ps.MarkReady, ps.Serve} {
+		if _, err := step(context.Background(), bar.GetID()); err != nil {
+			t.Fatalf("Error moving ticket: %v", err)
+		}
+	}
+	if _, err := ps.Start(context.Background(), kitchen.GetID()); err != nil {
+		t.Fatalf("Error starting ticket: %v", err)
+	}
+
+	now = now.Add(time.Minute)
+	withdrawn, err := ps.Withdraw(context.Background(), o.GetID())
+	if err != nil {
+		t.Fatalf("Error withdrawing order: %v", err)
+	}
+	if len(withdrawn) != 1 || withdrawn[0].GetID() != kitchen.GetID() {
+		t.Fatalf("expected the kitchen ticket withdrawn, got %d tickets", len(withdrawn))
+	}
+	if withdrawn[0].GetStatus() != ticket.StatusCancelled || !withdrawn[0].GetUpdatedAt().Equal(now) {
+		t.Errorf("expected the kitchen ticket cancelled at %s, got %s at %s", now, withdrawn[0].GetStatus(), withdrawn[0].GetUpdatedAt())
+	}
+	pending, err := ps.GetPending(context.Background(), product.StationKitchen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("expected nothing pending in the kitchen, got %d tickets", len(pending))
+	}
+	if _, err := ps.MarkReady(context.Background(), kitchen.GetID()); !errors.Is(err, ticket.ErrInvalidStatus) {
+		t.Errorf("expected error %v, got %v", ticket.ErrInvalidStatus, err)
+	}
+
+	// withdrawing again leaves nothing to cancel
+	withdrawn, err = ps.Withdraw(context.Background(), o.GetID())
+	if err != nil || len(withdrawn) != 0 {
+		t.Errorf("expected nothing withdrawn, got %d tickets (%v)", len(withdrawn), err)
+	}
+}
@@ -0,0 +1,214 @@
+package tavern
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/devsrivatsa/tavernDDD/domain/seating"
+	"github.com/devsrivatsa/tavernDDD/services/order"
+	"github.com/google/uuid"
+)
+
+// walkInStay is how long a walk-in party is expected to stay, they are not seated at a
+// table reserved within that time
+const walkInStay = 2 * time.Hour
+
+// GetTables returns every table with its status
+func (t *Tavern) GetTables(ctx context.Context) ([]seating.Table, error) {
+	if t.tables == nil {
+		return nil, ErrNoTableRepository
+	}
+	return t.tables.GetAll(ctx)
+}
+
+// Reserve books the smallest table the party fits at that is free for the whole reservation
+func (t *Tavern) Reserve(ctx context.Context, r seating.Reservation) (seating.Table, error) {
+	if t.tables == nil {
+		return seating.Table{}, ErrNoTableRepository
+	}
+	t.seatingLock.Lock()
+	defer t.seatingLock.Unlock()
+
+	tables, err := t.tables.GetAll(ctx)
+	if err != nil {
+		return seating.Table{}, err
+	}
+	var best seating.Table
+	for _, table := range tables {
+		if table.GetCapacity() < r.PartySize || !table.IsFreeBetween(r.From, r.To) {
+			continue
+		}
+		if best.GetID() == uuid.Nil || table.GetCapacity() < best.GetCapacity() {
+			best = table
+		}
+	}
+	if best.GetID() == uuid.Nil {
+		return seating.Table{}, fmt.Errorf("party of %d at %s: %w", r.PartySize, r.From.Format(time.Kitchen), ErrNoTableAvailable)
+	}
+	if err := best.Reserve(r); err != nil {
+		return seating.Table{}, err
+	}
+	if err := t.tables.Update(ctx, best); err != nil {
+		return seating.Table{}, err
+	}
+
+	return best, nil
+}
+
+func (t *Tavern) CancelReservation(ctx context.Context, tableID, reservationID uuid.UUID) error {
+	return t.changeTable(ctx, tableID, func(table *seating.Table) error {
+		return table.CancelReservation(reservationID)
+	})
+}
+
+// SeatWalkIn seats a party without a reservation. Their orders are billed to the customer,
+// or to a new guest customer when customerID is empty.
+func (t *Tavern) SeatWalkIn(ctx context.Context, tableID uuid.UUID, partySize int, customerID uuid.UUID) (seating.Table, error) {
+	if t.tables == nil {
+		return seating.Table{}, ErrNoTableRepository
+	}
+	t.seatingLock.Lock()
+	defer t.seatingLock.Unlock()
+
+	table, err := t.tables.Get(ctx, tableID)
+	if err != nil {
+		return seating.Table{}, err
+	}
+	now := t.now()
+	err = t.seat(ctx, &table, customerID, func(table *seating.Table, hostID uuid.UUID) error {
+		return table.SeatWalkIn(hostID, partySize, now, now.Add(walkInStay))
+	})
+	if err != nil {
+		return seating.Table{}, err
+	}
+	if err := t.tables.Update(ctx, table); err != nil {
+		return seating.Table{}, err
+	}
+
+	return table, nil
+}
+
+// SeatReservation seats the party of a reservation. Their orders are billed to the customer
+// who reserved, or to a new guest customer when the reservation was made by name only.
+func (t *Tavern) SeatReservation(ctx context.Context, tableID, reservationID uuid.UUID) (seating.Table, error) {
+	if t.tables == nil {
+		return seating.Table{}, ErrNoTableRepository
+	}
+	t.seatingLock.Lock()
+	defer t.seatingLock.Unlock()
+
+	table, err := t.tables.Get(ctx, tableID)
+	if err != nil {
+		return seating.Table{}, err
+	}
+	var reservation seating.Reservation
+	for _, r := range table.GetReservations() {
+		if r.ID == reservationID {
+			reservation = r
+		}
+	}
+	if reservation.ID == uuid.Nil {
+		return seating.Table{}, fmt.Errorf("reservation %s at table %s: %w", reservationID, table.GetName(), seating.ErrReservationNotFound)
+	}
+	err = t.seat(ctx, &table, reservation.CustomerID, func(table *seating.Table, hostID uuid.UUID) error {
+		return table.SeatReservation(reservationID, hostID, t.now())
+	})
+	if err != nil {
+		return seating.Table{}, err
+	}
+	if err := t.tables.Update(ctx, table); err != nil {
+		return seating.Table{}, err
+	}
+
+	return table, nil
+}
+
+// ClearTable sees the party off, the table is free again once it is marked clean
+func (t *Tavern) ClearTable(ctx context.Context, tableID uuid.UUID) error {
+	return t.changeTable(ctx, tableID, (*seating.Table).Clear)
+}
+
+func (t *Tavern) MarkTableClean(ctx context.Context, tableID uuid.UUID) error {
+	return t.changeTable(ctx, tableID, (*seating.Table).MarkClean)
+}
+
+// orderForTable places the order for the party seated at the table. It is billed to the
+// given customer, or to the host of the table when customerID is empty. The seating is only
+// locked while the table is read and updated, not while the order is paid for.
+func (t *Tavern) orderForTable(ctx context.Context, customerID uuid.UUID, req order.Request) (Receipt, error) {
+	if t.tables == nil {
+		return Receipt{}, ErrNoTableRepository
+	}
+	table, err := t.occupiedTable(ctx, req.TableID)
+	if err != nil {
+		return Receipt{}, err
+	}
+	if customerID == uuid.Nil {
+		customerID = table.GetHostID()
+	}
+
+	receipt, err := t.order(ctx, customerID, req)
+	if err != nil {
+		return Receipt{}, err
+	}
+	// the order keeps its table either way, only the list on the table would be out of date
+	err = t.changeTable(ctx, req.TableID, func(table *seating.Table) error {
+		return table.AddOrder(receipt.Order.GetID())
+	})
+	if err != nil {
+		log.Printf("error adding order %s to table %s: %v", receipt.Order.GetID(), table.GetName(), err)
+	}
+
+	return receipt, nil
+}
+
+func (t *Tavern) occupiedTable(ctx context.Context, tableID uuid.UUID) (seating.Table, error) {
+	t.seatingLock.Lock()
+	defer t.seatingLock.Unlock()
+
+	table, err := t.tables.Get(ctx, tableID)
+	if err != nil {
+		return seating.Table{}, err
+	}
+	if table.GetStatus() != seating.StatusOccupied {
+		return seating.Table{}, fmt.Errorf("table %s is %s: %w", table.GetName(), table.GetStatus(), seating.ErrTableNotOccupied)
+	}
+	return table, nil
+}
+
+// seat seats the party with the customer as host or, without one, with a new guest customer.
+// The party is seated on a copy of the table first, so no guest is added for a party that is
+// turned away.
+func (t *Tavern) seat(ctx context.Context, table *seating.Table, customerID uuid.UUID, seat func(table *seating.Table, hostID uuid.UUID) error) error {
+	check := *table
+	if err := seat(&check, customerID); err != nil {
+		return err
+	}
+	if customerID == uuid.Nil {
+		guestID, err := t.orderService.AddCustomer(ctx, fmt.Sprintf("Guest at table %s", table.GetName()))
+		if err != nil {
+			return err
+		}
+		customerID = guestID
+	}
+	return seat(table, customerID)
+}
+
+func (t *Tavern) changeTable(ctx context.Context, tableID uuid.UUID, change func(table *seating.Table) error) error {
+	if t.tables == nil {
+		return ErrNoTableRepository
+	}
+	t.seatingLock.Lock()
+	defer t.seatingLock.Unlock()
+
+	table, err := t.tables.Get(ctx, tableID)
+	if err != nil {
+		return err
+	}
+	if err := change(&table); err != nil {
+		return err
+	}
+	return t.tables.Update(ctx, table)
+}
@@ -1,23 +1,68 @@
 package tavern
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"sync"
+	"time"
 
+	"github.com/devsrivatsa/tavernDDD/domain"
+	ord "github.com/devsrivatsa/tavernDDD/domain/order"
+	"github.com/devsrivatsa/tavernDDD/domain/seating"
+	seatMem "github.com/devsrivatsa/tavernDDD/domain/seating/memory"
+	"github.com/devsrivatsa/tavernDDD/domain/tab"
+	tabMem "github.com/devsrivatsa/tavernDDD/domain/tab/memory"
+	"github.com/devsrivatsa/tavernDDD/services/billing"
+	billMem "github.com/devsrivatsa/tavernDDD/services/billing/memory"
 	"github.com/devsrivatsa/tavernDDD/services/order"
+	"github.com/devsrivatsa/tavernDDD/services/payment"
+	"github.com/devsrivatsa/tavernDDD/services/preparation"
 	"github.com/google/uuid"
 )
 
+var (
+	ErrNoBillingService  = errors.New("the tavern has no billing service")
+	ErrNoTabRepository   = errors.New("the tavern does not run tabs")
+	ErrNoTableRepository = errors.New("the tavern has no tables")
+	ErrNoTableAvailable  = errors.New("no table is free for the party at that time")
+)
+
 type TavernConfiguration func(t *Tavern) error
 
 type Tavern struct {
 	orderService *order.OrderService
 	//billing service
-	billingService interface{}
+	billingService billing.Service
+	//payment gateway, orders are not paid by card without one
+	paymentGateway payment.Gateway
+	//tabs of the customers, orders are billed one by one without it
+	tabs tab.TabRepository
+	//tabLock keeps orders from being added to a tab while it is being closed
+	tabLock sync.Mutex
+	//closingTime is the time of day the tavern closes, as the duration since midnight
+	closingTime time.Duration
+	//tables of the tavern, orders are only served at the bar without it
+	tables seating.TableRepository
+	//seatingLock keeps two parties from getting the same table
+	seatingLock sync.Mutex
+	//preparation queues orders for the bar and the kitchen, nobody is told to make them without it
+	preparation *preparation.PreparationService
+	now         func() time.Time
+}
+
+// Receipt is what the customer gets for an order. Invoice is empty when the order went on a tab.
+type Receipt struct {
+	Order   ord.Order
+	Invoice billing.Invoice
+	TabID   uuid.UUID
 }
 
 func NewTavern(configs ...TavernConfiguration) (*Tavern, error) {
-	t := &Tavern{}
+	t := &Tavern{
+		now: time.Now,
+	}
 
 	for _, config := range configs {
 		if err := config(t); err != nil {
@@ -36,13 +81,510 @@ func WithOrderService(os *order.OrderService) TavernConfiguration {
 
 //if you have a billing service, you can add it to the tavern
 
-func (t *Tavern) Order(customerID uuid.UUID, products []uuid.UUID) error {
-	price, err := t.orderService.CreateOrder(customerID, products)
+func WithBillingService(bs billing.Service) TavernConfiguration {
+	return func(t *Tavern) error {
+		t.billingService = bs
+		return nil
+	}
+}
+
+func WithMemoryBillingService() TavernConfiguration {
+	return WithBillingService(billMem.New())
+}
+
+func WithPaymentGateway(pg payment.Gateway) TavernConfiguration {
+	return func(t *Tavern) error {
+		t.paymentGateway = pg
+		return nil
+	}
+}
+
+func WithTabRepository(tr tab.TabRepository) TavernConfiguration {
+	return func(t *Tavern) error {
+		t.tabs = tr
+		return nil
+	}
+}
+
+func WithMemoryTabRepository() TavernConfiguration {
+	return WithTabRepository(tabMem.New())
+}
+
+func WithPreparationService(ps *preparation.PreparationService) TavernConfiguration {
+	return func(t *Tavern) error {
+		t.preparation = ps
+		return nil
+	}
+}
+
+func WithTableRepository(tr seating.TableRepository) TavernConfiguration {
+	return func(t *Tavern) error {
+		t.tables = tr
+		return nil
+	}
+}
+
+func WithMemoryTableRepository(tables ...seating.Table) TavernConfiguration {
+	return func(t *Tavern) error {
+		tr := seatMem.New()
+		for _, table := range tables {
+			if err := tr.Add(context.Background(), table); err != nil {
+				return err
+			}
+		}
+		t.tables = tr
+		return nil
+	}
+}
+
+// WithClosingTime sets the time of day the tavern closes, e.g. 2 * time.Hour for 2 in the morning.
+// Tabs still open after it are flagged by FlagOverdueTabs. The default is midnight.
+func WithClosingTime(closing time.Duration) TavernConfiguration {
+	return func(t *Tavern) error {
+		t.closingTime = closing
+		return nil
+	}
+}
+
+// WithClock replaces the clock used to open, close and flag tabs
+func WithClock(now func() time.Time) TavernConfiguration {
+	return func(t *Tavern) error {
+		t.now = now
+		return nil
+	}
+}
+
+// Order places the order. If the customer has an open tab the order goes on it, otherwise the
+// card payment is authorized and the customer is billed right away. If the payment is not
+// authorized the order is rolled back. An order for a table goes on the table, and is billed
+// to the host of the table when customerID is empty. Placed orders are queued for preparation.
+func (t *Tavern) Order(ctx context.Context, customerID uuid.UUID, req order.Request) (Receipt, error) {
+	if t.billingService == nil {
+		return Receipt{}, ErrNoBillingService
+	}
+	var receipt Receipt
+	var err error
+	if req.TableID != uuid.Nil {
+		receipt, err = t.orderForTable(ctx, customerID, req)
+	} else {
+		receipt, err = t.order(ctx, customerID, req)
+	}
+	if err != nil {
+		return Receipt{}, err
+	}
+	// the order is placed, the bar and the kitchen hear of it even when the request is called off
+	t.prepare(context.WithoutCancel(ctx), receipt.Order)
+
+	return receipt, nil
+}
+
+// prepare sends the order to the bar and the kitchen. The order stands when it cannot be
+// queued, staff have to be told some other way.
+func (t *Tavern) prepare(ctx context.Context, o ord.Order) {
+	if t.preparation == nil {
+		return
+	}
+	if _, err := t.preparation.Enqueue(ctx, o); err != nil {
+		log.Printf("error queueing order %s for preparation: %v", o.GetID(), err)
+	}
+}
+
+// withdraw tells the bar and the kitchen to stop on a voided order. The order stays void when
+// its tickets cannot be withdrawn, staff have to be told some other way.
+func (t *Tavern) withdraw(ctx context.Context, orderID uuid.UUID) {
+	if t.preparation == nil {
+		return
+	}
+	if _, err := t.preparation.Withdraw(ctx, orderID); err != nil {
+		log.Printf("error withdrawing order %s from preparation: %v", orderID, err)
+	}
+}
+
+func (t *Tavern) order(ctx context.Context, customerID uuid.UUID, req order.Request) (Receipt, error) {
+	if t.tabs != nil {
+		t.tabLock.Lock()
+		openTab, err := t.tabs.GetOpenByCustomer(ctx, customerID)
+		if err == nil {
+			defer t.tabLock.Unlock()
+			return t.orderOnTab(ctx, openTab, req)
+		}
+		t.tabLock.Unlock()
+		if !errors.Is(err, tab.ErrTabNotFound) {
+			return Receipt{}, err
+		}
+	}
+
+	o, err := t.orderService.CreateOrder(ctx, customerID, req)
+	if err != nil {
+		return Receipt{}, fmt.Errorf("error creating order: %w", err)
+	}
+
+	if !o.GetTotal().IsPositive() {
+		return t.free(ctx, o)
+	}
+
+	var authorizationID string
+	if t.paymentGateway != nil {
+		auth, err := t.paymentGateway.Authorize(customerID, o.GetTotal())
+		if err != nil {
+			t.reject(ctx, o.GetID())
+			return Receipt{}, fmt.Errorf("error authorizing payment for order %s: %w", o.GetID(), err)
+		}
+		authorizationID = auth.ID
+	}
+	// the order is billed before it is confirmed, so it can still be rejected when billing fails
+	invoice, err := t.billingService.Issue(customerID, o.GetTotal(), o.GetID())
+	if err != nil {
+		t.reject(ctx, o.GetID())
+		t.voidAuthorization(authorizationID)
+		return Receipt{}, fmt.Errorf("error billing order %s: %w", o.GetID(), err)
+	}
+	confirmed, err := t.orderService.ConfirmOrder(ctx, o.GetID(), authorizationID)
+	if err != nil {
+		t.voidInvoice(invoice.ID)
+		t.reject(ctx, o.GetID())
+		t.voidAuthorization(authorizationID)
+		return Receipt{}, fmt.Errorf("error confirming order %s: %w", o.GetID(), err)
+	}
+	log.Printf("\nBilled the customer %s for the amount of %s\n", customerID, invoice.Amount)
+
+	return Receipt{Order: confirmed, Invoice: invoice}, nil
+}
+
+// reject rolls back an order that could not be paid for, even when the request was called off
+func (t *Tavern) reject(ctx context.Context, orderID uuid.UUID) {
+	if _, err := t.orderService.RejectOrder(context.WithoutCancel(ctx), orderID); err != nil {
+		log.Printf("error rolling back order %s: %v", orderID, err)
+	}
+}
+
+// free settles an order with nothing to pay, e.g. a comped round, without billing the customer
+func (t *Tavern) free(ctx context.Context, o ord.Order) (Receipt, error) {
+	settled, err := t.orderService.ConfirmOrder(ctx, o.GetID(), "")
+	if err == nil {
+		settled, err = t.orderService.MarkOrderPaid(ctx, o.GetID())
+	}
+	if err != nil {
+		return Receipt{}, fmt.Errorf("error settling order %s: %w", o.GetID(), err)
+	}
+
+	return Receipt{Order: settled}, nil
+}
+
+// orderOnTab places the order and puts it on the open tab, the caller holds the tab lock.
+// Nothing is authorized yet, the card is charged for the whole tab when it is closed.
+func (t *Tavern) orderOnTab(ctx context.Context, openTab tab.Tab, req order.Request) (Receipt, error) {
+	o, err := t.orderService.CreateOrder(ctx, openTab.GetCustomerID(), req)
+	if err != nil {
+		return Receipt{}, fmt.Errorf("error creating order: %w", err)
+	}
+	// the order goes on the tab while it is still placed, so it can be rejected if that fails
+	previous := openTab
+	err = openTab.AddOrder(o)
+	if err == nil {
+		err = t.tabs.Update(ctx, openTab)
+	}
+	if err != nil {
+		t.reject(ctx, o.GetID())
+		return Receipt{}, fmt.Errorf("error adding order %s to tab %s: %w", o.GetID(), openTab.GetID(), err)
+	}
+	confirmed, err := t.orderService.ConfirmOrder(ctx, o.GetID(), "")
+	if err != nil {
+		if uerr := t.tabs.Update(context.WithoutCancel(ctx), previous); uerr != nil {
+			log.Printf("error taking order %s off tab %s: %v", o.GetID(), openTab.GetID(), uerr)
+		}
+		t.reject(ctx, o.GetID())
+		return Receipt{}, fmt.Errorf("error confirming order %s: %w", o.GetID(), err)
+	}
+
+	return Receipt{Order: confirmed, TabID: openTab.GetID()}, nil
+}
+
+// OpenTab opens a tab for the customer, their orders go on it until it is closed
+func (t *Tavern) OpenTab(ctx context.Context, customerID uuid.UUID) (tab.Tab, error) {
+	if t.tabs == nil {
+		return tab.Tab{}, ErrNoTabRepository
+	}
+	t.tabLock.Lock()
+	defer t.tabLock.Unlock()
+
+	openTab, err := tab.NewTab(customerID, t.now())
+	if err != nil {
+		return tab.Tab{}, err
+	}
+	if err := t.tabs.Add(ctx, openTab); err != nil {
+		return tab.Tab{}, err
+	}
+
+	return openTab, nil
+}
+
+// CloseTab bills the customer for everything on the tab and settles the bill. The card
+// is charged for the tab total in one go. If the payment fails or the bill cannot be settled,
+// the tab stays open and the card is not charged. Orders called off while they were on the tab
+// are taken off it first.
+// Closing an empty tab returns an empty invoice.
+func (t *Tavern) CloseTab(ctx context.Context, tabID uuid.UUID) (billing.Invoice, error) {
+	if t.tabs == nil {
+		return billing.Invoice{}, ErrNoTabRepository
+	}
+	if t.billingService == nil {
+		return billing.Invoice{}, ErrNoBillingService
+	}
+	t.tabLock.Lock()
+	defer t.tabLock.Unlock()
+
+	openTab, err := t.tabs.Get(ctx, tabID)
+	if err != nil {
+		return billing.Invoice{}, err
+	}
+	if !openTab.IsOpen() {
+		return billing.Invoice{}, fmt.Errorf("tab %s: %w", tabID, tab.ErrTabClosed)
+	}
+	// orders called off while on the tab, e.g. voided, are taken off before the card is charged
+	for _, orderID := range openTab.GetOrderIDs() {
+		o, err := t.orderService.GetOrder(ctx, orderID)
+		if err != nil {
+			return billing.Invoice{}, err
+		}
+		if o.GetStatus() == ord.StatusConfirmed {
+			continue
+		}
+		if err := openTab.RemoveOrder(o); err != nil {
+			return billing.Invoice{}, err
+		}
+		log.Printf("order %s is %s, it is taken off tab %s", orderID, o.GetStatus(), tabID)
+	}
+	if len(openTab.GetOrderIDs()) == 0 {
+		if err := openTab.Close(uuid.Nil, t.now()); err != nil {
+			return billing.Invoice{}, err
+		}
+		return billing.Invoice{}, t.tabs.Update(ctx, openTab)
+	}
+
+	customerID, total := openTab.GetCustomerID(), openTab.GetTotal()
+	var auth payment.Authorization
+	if t.paymentGateway != nil {
+		auth, err = t.paymentGateway.Authorize(customerID, total)
+		if err != nil {
+			return billing.Invoice{}, fmt.Errorf("error authorizing payment for tab %s: %w", tabID, err)
+		}
+	}
+	invoice, err := t.billingService.Issue(customerID, total, openTab.GetOrderIDs()...)
+	if err != nil {
+		t.voidAuthorization(auth.ID)
+		return billing.Invoice{}, fmt.Errorf("error billing tab %s: %w", tabID, err)
+	}
+	// the tab is closed before the card is charged, so closing it again cannot charge twice
+	closed := openTab
+	err = closed.Close(invoice.ID, t.now())
+	if err == nil {
+		err = t.tabs.Update(ctx, closed)
+	}
+	if err != nil {
+		t.voidInvoice(invoice.ID)
+		t.voidAuthorization(auth.ID)
+		return billing.Invoice{}, fmt.Errorf("error closing tab %s: %w", tabID, err)
+	}
+	if t.paymentGateway != nil {
+		if _, err := t.paymentGateway.Capture(auth.ID, total); err != nil {
+			t.reopen(ctx, openTab)
+			t.voidInvoice(invoice.ID)
+			t.voidAuthorization(auth.ID)
+			return billing.Invoice{}, fmt.Errorf("error capturing payment for tab %s: %w", tabID, err)
+		}
+	}
+	// the card is charged, the tab is settled even when the request is called off
+	ctx = context.WithoutCancel(ctx)
+	settled, err := t.billingService.Settle(invoice.ID, total)
+	if err != nil {
+		t.refundPayment(auth.ID, total)
+		t.reopen(ctx, openTab)
+		t.voidInvoice(invoice.ID)
+		return billing.Invoice{}, fmt.Errorf("error settling tab %s: %w", tabID, err)
+	}
+	invoice = settled
+
+	// the orders keep the card payment of the tab, so they can be refunded on the card
+	t.markPaid(ctx, invoice.OrderIDs, auth.ID)
+	log.Printf("\nClosed the tab of customer %s for the amount of %s\n", customerID, invoice.Amount)
+
+	return invoice, nil
+}
+
+// FlagOverdueTabs flags the tabs still open after closing time and returns all flagged open tabs
+func (t *Tavern) FlagOverdueTabs(ctx context.Context) ([]tab.Tab, error) {
+	if t.tabs == nil {
+		return nil, ErrNoTabRepository
+	}
+	t.tabLock.Lock()
+	defer t.tabLock.Unlock()
+
+	open, err := t.tabs.GetOpen(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := t.now()
+	var flagged []tab.Tab
+	for _, openTab := range open {
+		wasFlagged := openTab.IsFlagged()
+		if !openTab.Flag(now, t.closingTime) {
+			continue
+		}
+		if !wasFlagged {
+			if err := t.tabs.Update(ctx, openTab); err != nil {
+				return nil, err
+			}
+			log.Printf("tab %s of customer %s is still open after closing time", openTab.GetID(), openTab.GetCustomerID())
+		}
+		flagged = append(flagged, openTab)
+	}
+
+	return flagged, nil
+}
+
+func (t *Tavern) voidAuthorization(id string) {
+	if id == "" || t.paymentGateway == nil {
+		return
+	}
+	if _, err := t.paymentGateway.Void(id); err != nil {
+		log.Printf("error voiding payment %s: %v", id, err)
+	}
+}
+
+// refundPayment gives back a card payment captured for a bill that could not be settled
+func (t *Tavern) refundPayment(id string, amount domain.Money) {
+	if id == "" || t.paymentGateway == nil {
+		return
+	}
+	if _, err := t.paymentGateway.Refund(id, amount); err != nil {
+		log.Printf("error refunding payment %s: %v", id, err)
+	}
+}
+
+func (t *Tavern) refundOrders(orders []ord.Order) {
+	for _, o := range orders {
+		t.refundPayment(o.GetAuthorizationID(), o.GetTotal())
+	}
+}
+
+// reopen puts the tab back as it was before it was closed
+func (t *Tavern) reopen(ctx context.Context, openTab tab.Tab) {
+	if err := t.tabs.Update(context.WithoutCancel(ctx), openTab); err != nil {
+		log.Printf("error reopening tab %s: %v", openTab.GetID(), err)
+	}
+}
+
+func (t *Tavern) voidInvoice(id uuid.UUID) {
+	if _, err := t.billingService.Void(id); err != nil {
+		log.Printf("error voiding invoice %s: %v", id, err)
+	}
+}
+
+// Pay captures the card payments behind the invoice, settles it and marks its orders as paid.
+// If a payment cannot be captured or the invoice settled, the payments already captured are
+// refunded and the invoice stays unpaid. Once it is settled, the payment stands even when an
+// order cannot be marked as paid.
+func (t *Tavern) Pay(ctx context.Context, invoiceID uuid.UUID) (billing.Invoice, error) {
+	if t.billingService == nil {
+		return billing.Invoice{}, ErrNoBillingService
+	}
+	invoice, err := t.billingService.Get(invoiceID)
+	if err != nil {
+		return billing.Invoice{}, err
+	}
+	due, err := invoice.AmountDue()
+	if err != nil {
+		return billing.Invoice{}, err
+	}
+
+	var captured []ord.Order
+	for _, orderID := range invoice.OrderIDs {
+		o, err := t.orderService.GetOrder(ctx, orderID)
+		if err != nil {
+			t.refundOrders(captured)
+			return billing.Invoice{}, err
+		}
+		if o.GetAuthorizationID() == "" || t.paymentGateway == nil {
+			continue
+		}
+		if _, err := t.paymentGateway.Capture(o.GetAuthorizationID(), o.GetTotal()); err != nil {
+			t.refundOrders(captured)
+			return billing.Invoice{}, fmt.Errorf("error capturing payment for order %s: %w", orderID, err)
+		}
+		captured = append(captured, o)
+	}
+	// the cards are charged, the invoice is settled even when the request is called off
+	ctx = context.WithoutCancel(ctx)
+
+	settled, err := t.billingService.Settle(invoiceID, due)
 	if err != nil {
-		return fmt.Errorf("error creating order: %w", err)
+		t.refundOrders(captured)
+		return billing.Invoice{}, fmt.Errorf("error settling invoice %s: %w", invoiceID, err)
+	}
+	invoice = settled
+	t.markPaid(ctx, invoice.OrderIDs, "")
+
+	return invoice, nil
+}
+
+// markPaid marks the orders of a settled invoice paid, with the card payment when it was taken
+// for all of them. The money is in already, so an order that cannot be marked is logged and
+// the others are marked anyway.
+func (t *Tavern) markPaid(ctx context.Context, orderIDs []uuid.UUID, authorizationID string) {
+	for _, orderID := range orderIDs {
+		if _, err := t.orderService.MarkOrderPaidBy(ctx, orderID, authorizationID); err != nil {
+			log.Printf("error marking order %s paid: %v", orderID, err)
+		}
+	}
+}
+
+// Cancel voids the unpaid orders on an invoice on behalf of a manager, takes them off the bar and
+// the kitchen, releases their card payments and voids the invoice
+func (t *Tavern) Cancel(ctx context.Context, invoiceID, staffID uuid.UUID) (billing.Invoice, error) {
+	if t.billingService == nil {
+		return billing.Invoice{}, ErrNoBillingService
+	}
+	invoice, err := t.billingService.Get(invoiceID)
+	if err != nil {
+		return billing.Invoice{}, err
+	}
+	if invoice.Status != billing.StatusUnpaid || len(invoice.Settlements) > 0 {
+		return billing.Invoice{}, fmt.Errorf("invoice %s: %w", invoiceID, billing.ErrInvoiceAlreadyPaid)
 	}
 
-	log.Printf("\nBill the customer %s for the amount of %.2f\n", customerID, price)
+	for _, orderID := range invoice.OrderIDs {
+		o, err := t.orderService.VoidOrder(ctx, orderID, staffID)
+		if err != nil {
+			return billing.Invoice{}, fmt.Errorf("error cancelling order %s: %w", orderID, err)
+		}
+		t.withdraw(context.WithoutCancel(ctx), orderID)
+		if o.GetAuthorizationID() == "" || t.paymentGateway == nil {
+			continue
+		}
+		if _, err := t.paymentGateway.Void(o.GetAuthorizationID()); err != nil {
+			log.Printf("error voiding payment %s of order %s: %v", o.GetAuthorizationID(), orderID, err)
+		}
+	}
+
+	return t.billingService.Void(invoiceID)
+}
+
+// Refund gives the customer their money back for part of a paid order. The refund is recorded
+// on the order before the card is refunded, and undone if the card cannot be.
+func (t *Tavern) Refund(ctx context.Context, orderID uuid.UUID, refunds []ord.LineRefund) (domain.Money, error) {
+	_, amount, err := t.orderService.RefundLinesWith(ctx, orderID, refunds, func(o ord.Order, amount domain.Money) error {
+		if o.GetAuthorizationID() == "" || t.paymentGateway == nil {
+			return nil
+		}
+		if _, err := t.paymentGateway.Refund(o.GetAuthorizationID(), amount); err != nil {
+			return fmt.Errorf("error refunding payment for order %s: %w", orderID, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return domain.Money{}, err
+	}
 
-	return nil
+	return amount, nil
 }
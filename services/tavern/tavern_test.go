@@ -1,23 +1,42 @@
 package tavern
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/devsrivatsa/tavernDDD/domain"
+	"github.com/devsrivatsa/tavernDDD/domain/customer"
+	custMem "github.com/devsrivatsa/tavernDDD/domain/customer/memory"
+	ord "github.com/devsrivatsa/tavernDDD/domain/order"
+	ordMem "github.com/devsrivatsa/tavernDDD/domain/order/memory"
 	"github.com/devsrivatsa/tavernDDD/domain/product"
+	prdMem "github.com/devsrivatsa/tavernDDD/domain/product/memory"
+	"github.com/devsrivatsa/tavernDDD/domain/seating"
+	"github.com/devsrivatsa/tavernDDD/domain/staff"
+	"github.com/devsrivatsa/tavernDDD/domain/tab"
+	"github.com/devsrivatsa/tavernDDD/domain/ticket"
+	"github.com/devsrivatsa/tavernDDD/services/billing"
+	billMem "github.com/devsrivatsa/tavernDDD/services/billing/memory"
 	"github.com/devsrivatsa/tavernDDD/services/order"
+	"github.com/devsrivatsa/tavernDDD/services/payment"
+	"github.com/devsrivatsa/tavernDDD/services/payment/fake"
+	"github.com/devsrivatsa/tavernDDD/services/preparation"
 	"github.com/google/uuid"
 )
 
 func init_products(t *testing.T) []product.Product {
-	beer, err := product.NewProduct("Beer", "A refreshing beer", 1.99)
+	beer, err := product.NewProduct("Beer", "A refreshing beer", domain.MustNewMoney(199, "EUR"), 10)
 	if err != nil {
 		t.Fatalf("Error initializing product: %v \nCannot proceed with tests", err)
 	}
-	peanuts, err := product.NewProduct("Peanuts", "A delicious snack", 0.99)
+	peanuts, err := product.NewProduct("Peanuts", "A delicious snack", domain.MustNewMoney(99, "EUR"), 10)
 	if err != nil {
 		t.Fatalf("Error initializing product: %v \nCannot proceed with tests", err)
 	}
-	wine, err := product.NewProduct("Wine", "A fine wine", 5.99)
+	wine, err := product.NewProduct("Wine", "A fine wine", domain.MustNewMoney(599, "EUR"), 10)
 	if err != nil {
 		t.Fatalf("Error initializing product: %v \nCannot proceed with tests", err)
 	}
@@ -35,19 +54,987 @@ func TestTavern_Order(t *testing.T) {
 		t.Fatalf("%v: Error creating order service: %v", t.Name(), err)
 	}
 
-	tavern, err := NewTavern(WithOrderService(ordSrvc))
+	tavern, err := NewTavern(
+		WithOrderService(ordSrvc),
+		WithMemoryBillingService(),
+	)
 	if err != nil {
 		t.Fatalf("%v: Error creating tavern: %v", t.Name(), err)
 	}
 
-	customerID, err := ordSrvc.AddCustomer("John Doe")
+	customerID, err := ordSrvc.AddCustomer(context.Background(), "John Doe")
 	if err != nil {
 		t.Fatalf("%v: Error adding customer: %v", t.Name(), err)
 	}
-	order := []uuid.UUID{products[0].GetID()}
-	err = tavern.Order(customerID, order)
+	req := order.Request{Lines: []order.RequestLine{{ProductID: products[0].GetID(), Quantity: 1}}}
+	receipt, err := tavern.Order(context.Background(), customerID, req)
 	if err != nil {
 		t.Fatalf("%v: Error ordering: %v", t.Name(), err)
 	}
+	invoice := receipt.Invoice
+	if !invoice.Amount.Equals(products[0].GetPrice()) || invoice.Status != billing.StatusUnpaid {
+		t.Errorf("%v: expected an unpaid invoice over %s, got %s %s", t.Name(), products[0].GetPrice(), invoice.Status, invoice.Amount)
+	}
 	t.Logf("%v: Order successful", t.Name())
 }
+
+func TestTavern_OrderPaymentDeclined(t *testing.T) {
+	products := init_products(t)
+	ordSrvc, err := order.NewOrderService(
+		order.WithMemoryProductRepository(products),
+		order.WithMemoryCustomerRepository(),
+		order.WithMemoryOrderRepository(),
+	)
+	if err != nil {
+		t.Fatalf("%v: Error creating order service: %v", t.Name(), err)
+	}
+	gateway := fake.New()
+	tavern, err := NewTavern(
+		WithOrderService(ordSrvc),
+		WithMemoryBillingService(),
+		WithPaymentGateway(gateway),
+	)
+	if err != nil {
+		t.Fatalf("%v: Error creating tavern: %v", t.Name(), err)
+	}
+	customerID, err := ordSrvc.AddCustomer(context.Background(), "John Doe")
+	if err != nil {
+		t.Fatalf("%v: Error adding customer: %v", t.Name(), err)
+	}
+
+	gateway.Script(fake.Decline)
+	_, err = tavern.Order(context.Background(), customerID, order.Request{Lines: []order.RequestLine{{ProductID: products[0].GetID(), Quantity: 1}}})
+	if !errors.Is(err, payment.ErrDeclined) {
+		t.Fatalf("%v: expected error %v, got %v", t.Name(), payment.ErrDeclined, err)
+	}
+	orders, err := ordSrvc.GetCustomerOrders(context.Background(), customerID)
+	if err != nil {
+		t.Fatalf("%v: Error listing orders: %v", t.Name(), err)
+	}
+	if len(orders) != 1 || orders[0].GetStatus() != ord.StatusRejected {
+		t.Fatalf("%v: expected the declined order to be rejected", t.Name())
+	}
+
+	receipt, err := tavern.Order(context.Background(), customerID, order.Request{Lines: []order.RequestLine{{ProductID: products[0].GetID(), Quantity: 1}}})
+	if err != nil {
+		t.Fatalf("%v: Error ordering: %v", t.Name(), err)
+	}
+	invoice, err := tavern.Pay(context.Background(), receipt.Invoice.ID)
+	if err != nil {
+		t.Fatalf("%v: Error paying: %v", t.Name(), err)
+	}
+	if invoice.Status != billing.StatusPaid {
+		t.Errorf("%v: expected a paid invoice, got %s", t.Name(), invoice.Status)
+	}
+	paid, err := ordSrvc.GetOrder(context.Background(), invoice.OrderIDs[0])
+	if err != nil {
+		t.Fatalf("%v: Error fetching order: %v", t.Name(), err)
+	}
+	auth, err := gateway.Get(paid.GetAuthorizationID())
+	if err != nil {
+		t.Fatalf("%v: Error fetching authorization: %v", t.Name(), err)
+	}
+	if paid.GetStatus() != ord.StatusPaid || auth.Status != payment.StatusCaptured {
+		t.Errorf("%v: expected a paid order and a captured payment, got %s and %s", t.Name(), paid.GetStatus(), auth.Status)
+	}
+}
+
+func TestTavern_CancelAndRefund(t *testing.T) {
+	products := init_products(t)
+	bartender, err := staff.NewMember("Sam", staff.RoleBartender)
+	if err != nil {
+		t.Fatalf("%v: Error creating staff member: %v", t.Name(), err)
+	}
+	manager, err := staff.NewMember("Alex", staff.RoleManager)
+	if err != nil {
+		t.Fatalf("%v: Error creating staff member: %v", t.Name(), err)
+	}
+	ordSrvc, err := order.NewOrderService(
+		order.WithMemoryProductRepository(products),
+		order.WithMemoryCustomerRepository(),
+		order.WithMemoryOrderRepository(),
+		order.WithMemoryStaffRepository(bartender, manager),
+	)
+	if err != nil {
+		t.Fatalf("%v: Error creating order service: %v", t.Name(), err)
+	}
+	gateway := fake.New()
+	tavern, err := NewTavern(
+		WithOrderService(ordSrvc),
+		WithMemoryBillingService(),
+		WithPaymentGateway(gateway),
+	)
+	if err != nil {
+		t.Fatalf("%v: Error creating tavern: %v", t.Name(), err)
+	}
+	customerID, err := ordSrvc.AddCustomer(context.Background(), "John Doe")
+	if err != nil {
+		t.Fatalf("%v: Error adding customer: %v", t.Name(), err)
+	}
+
+	receipt, err := tavern.Order(context.Background(), customerID, order.Request{Lines: []order.RequestLine{{ProductID: products[0].GetID(), Quantity: 1}}, StaffID: bartender.GetID()})
+	if err != nil {
+		t.Fatalf("%v: Error ordering: %v", t.Name(), err)
+	}
+	if _, err := tavern.Cancel(context.Background(), receipt.Invoice.ID, bartender.GetID()); !errors.Is(err, staff.ErrNotPermitted) {
+		t.Errorf("%v: expected error %v, got %v", t.Name(), staff.ErrNotPermitted, err)
+	}
+	cancelled, err := tavern.Cancel(context.Background(), receipt.Invoice.ID, manager.GetID())
+	if err != nil {
+		t.Fatalf("%v: Error cancelling: %v", t.Name(), err)
+	}
+	if cancelled.Status != billing.StatusVoid {
+		t.Errorf("%v: expected a void invoice, got %s", t.Name(), cancelled.Status)
+	}
+	if o, err := ordSrvc.GetOrder(context.Background(), receipt.Order.GetID()); err != nil || o.GetTakenBy() != bartender.GetID() || o.GetVoidedBy() != manager.GetID() {
+		t.Errorf("%v: expected the order taken by %s and voided by %s", t.Name(), bartender.GetName(), manager.GetName())
+	}
+
+	comped, err := tavern.Order(context.Background(), customerID, order.Request{Lines: []order.RequestLine{{ProductID: products[0].GetID(), Quantity: 1, Comp: true}}, StaffID: manager.GetID()})
+	if err != nil {
+		t.Fatalf("%v: Error ordering a comped round: %v", t.Name(), err)
+	}
+	if comped.Order.GetStatus() != ord.StatusPaid || comped.Invoice.ID != uuid.Nil {
+		t.Errorf("%v: expected a comped round paid without an invoice, got %s", t.Name(), comped.Order.GetStatus())
+	}
+
+	receipt, err = tavern.Order(context.Background(), customerID, order.Request{Lines: []order.RequestLine{
+		{ProductID: products[0].GetID(), Quantity: 1},
+		{ProductID: products[2].GetID(), Quantity: 1},
+	}, StaffID: bartender.GetID()})
+	if err != nil {
+		t.Fatalf("%v: Error ordering: %v", t.Name(), err)
+	}
+	paid := receipt.Invoice
+	if _, err := tavern.Pay(context.Background(), paid.ID); err != nil {
+		t.Fatalf("%v: Error paying: %v", t.Name(), err)
+	}
+	if _, err := tavern.Cancel(context.Background(), paid.ID, manager.GetID()); !errors.Is(err, billing.ErrInvoiceAlreadyPaid) {
+		t.Errorf("%v: expected error %v, got %v", t.Name(), billing.ErrInvoiceAlreadyPaid, err)
+	}
+	amount, err := tavern.Refund(context.Background(), paid.OrderIDs[0], []ord.LineRefund{{ProductID: products[2].GetID(), Quantity: 1}})
+	if err != nil {
+		t.Fatalf("%v: Error refunding: %v", t.Name(), err)
+	}
+	if !amount.Equals(products[2].GetPrice()) {
+		t.Errorf("%v: expected a refund of %s, got %s", t.Name(), products[2].GetPrice(), amount)
+	}
+	if auth, err := gateway.Get("auth-2"); err != nil || !auth.Refunded.Equals(amount) {
+		t.Errorf("%v: expected %s refunded on the card, got %s (%v)", t.Name(), amount, auth.Refunded, err)
+	}
+
+	// the beer is not refunded when the card refund is declined
+	gateway.Script(fake.Decline)
+	if _, err := tavern.Refund(context.Background(), paid.OrderIDs[0], []ord.LineRefund{{ProductID: products[0].GetID(), Quantity: 1}}); !errors.Is(err, payment.ErrDeclined) {
+		t.Errorf("%v: expected error %v, got %v", t.Name(), payment.ErrDeclined, err)
+	}
+	if o, _ := ordSrvc.GetOrder(context.Background(), paid.OrderIDs[0]); o.GetLines()[0].Refunded != 0 {
+		t.Errorf("%v: expected the beer not refunded on the order, got %d", t.Name(), o.GetLines()[0].Refunded)
+	}
+	if _, err := tavern.Refund(context.Background(), paid.OrderIDs[0], []ord.LineRefund{{ProductID: products[0].GetID(), Quantity: 1}}); err != nil {
+		t.Errorf("%v: Error refunding: %v", t.Name(), err)
+	}
+}
+
+func TestTavern_Tab(t *testing.T) {
+	products := init_products(t)
+	ordSrvc, err := order.NewOrderService(
+		order.WithMemoryProductRepository(products),
+		order.WithMemoryCustomerRepository(),
+		order.WithMemoryOrderRepository(),
+	)
+	if err != nil {
+		t.Fatalf("%v: Error creating order service: %v", t.Name(), err)
+	}
+	gateway := fake.New()
+	tavern, err := NewTavern(
+		WithOrderService(ordSrvc),
+		WithMemoryBillingService(),
+		WithPaymentGateway(gateway),
+		WithMemoryTabRepository(),
+	)
+	if err != nil {
+		t.Fatalf("%v: Error creating tavern: %v", t.Name(), err)
+	}
+	customerID, err := ordSrvc.AddCustomer(context.Background(), "John Doe")
+	if err != nil {
+		t.Fatalf("%v: Error adding customer: %v", t.Name(), err)
+	}
+
+	openTab, err := tavern.OpenTab(context.Background(), customerID)
+	if err != nil {
+		t.Fatalf("%v: Error opening tab: %v", t.Name(), err)
+	}
+	if _, err := tavern.OpenTab(context.Background(), customerID); !errors.Is(err, tab.ErrTabAlreadyOpen) {
+		t.Errorf("%v: expected error %v, got %v", t.Name(), tab.ErrTabAlreadyOpen, err)
+	}
+	for _, p := range []product.Product{products[0], products[1], products[0]} {
+		receipt, err := tavern.Order(context.Background(), customerID, order.Request{Lines: []order.RequestLine{{ProductID: p.GetID(), Quantity: 1}}})
+		if err != nil {
+			t.Fatalf("%v: Error ordering: %v", t.Name(), err)
+		}
+		if receipt.TabID != openTab.GetID() || receipt.Invoice.ID != uuid.Nil {
+			t.Errorf("%v: expected the order on tab %s without an invoice", t.Name(), openTab.GetID())
+		}
+	}
+
+	gateway.Script(fake.Decline)
+	if _, err := tavern.CloseTab(context.Background(), openTab.GetID()); !errors.Is(err, payment.ErrDeclined) {
+		t.Fatalf("%v: expected error %v, got %v", t.Name(), payment.ErrDeclined, err)
+	}
+	invoice, err := tavern.CloseTab(context.Background(), openTab.GetID())
+	if err != nil {
+		t.Fatalf("%v: Error closing tab: %v", t.Name(), err)
+	}
+	if !invoice.Amount.Equals(domain.MustNewMoney(497, "EUR")) || invoice.Status != billing.StatusPaid || len(invoice.OrderIDs) != 3 {
+		t.Errorf("%v: expected a paid invoice over 4.97 EUR for 3 orders, got %s %s for %d", t.Name(), invoice.Status, invoice.Amount, len(invoice.OrderIDs))
+	}
+	for _, orderID := range invoice.OrderIDs {
+		if o, err := ordSrvc.GetOrder(context.Background(), orderID); err != nil || o.GetStatus() != ord.StatusPaid {
+			t.Errorf("%v: expected order %s to be paid, got %s (%v)", t.Name(), orderID, o.GetStatus(), err)
+		}
+	}
+	// the peanuts are refunded on the card the tab was paid with
+	amount, err := tavern.Refund(context.Background(), invoice.OrderIDs[1], []ord.LineRefund{{ProductID: products[1].GetID(), Quantity: 1}})
+	if err != nil {
+		t.Fatalf("%v: Error refunding: %v", t.Name(), err)
+	}
+	peanuts, err := ordSrvc.GetOrder(context.Background(), invoice.OrderIDs[1])
+	if err != nil || peanuts.GetAuthorizationID() == "" {
+		t.Fatalf("%v: expected the order to keep the card payment of the tab (%v)", t.Name(), err)
+	}
+	if auth, err := gateway.Get(peanuts.GetAuthorizationID()); err != nil || !amount.Equals(products[1].GetPrice()) || !auth.Refunded.Equals(amount) {
+		t.Errorf("%v: expected %s refunded on the card, got %s of %s (%v)", t.Name(), products[1].GetPrice(), auth.Refunded, amount, err)
+	}
+	if _, err := tavern.CloseTab(context.Background(), openTab.GetID()); !errors.Is(err, tab.ErrTabClosed) {
+		t.Errorf("%v: expected error %v, got %v", t.Name(), tab.ErrTabClosed, err)
+	}
+
+	receipt, err := tavern.Order(context.Background(), customerID, order.Request{Lines: []order.RequestLine{{ProductID: products[0].GetID(), Quantity: 1}}})
+	if err != nil {
+		t.Fatalf("%v: Error ordering: %v", t.Name(), err)
+	}
+	if receipt.TabID != uuid.Nil || receipt.Invoice.Status != billing.StatusUnpaid {
+		t.Errorf("%v: expected an order billed on its own once the tab is closed", t.Name())
+	}
+}
+
+func TestTavern_TabOrderVoided(t *testing.T) {
+	products := init_products(t)
+	manager, err := staff.NewMember("Alex", staff.RoleManager)
+	if err != nil {
+		t.Fatalf("%v: Error creating staff member: %v", t.Name(), err)
+	}
+	ordSrvc, err := order.NewOrderService(
+		order.WithMemoryProductRepository(products),
+		order.WithMemoryCustomerRepository(),
+		order.WithMemoryStaffRepository(manager),
+	)
+	if err != nil {
+		t.Fatalf("%v: Error creating order service: %v", t.Name(), err)
+	}
+	gateway := fake.New()
+	tavern, err := NewTavern(
+		WithOrderService(ordSrvc),
+		WithMemoryBillingService(),
+		WithPaymentGateway(gateway),
+		WithMemoryTabRepository(),
+	)
+	if err != nil {
+		t.Fatalf("%v: Error creating tavern: %v", t.Name(), err)
+	}
+	customerID, err := ordSrvc.AddCustomer(context.Background(), "John Doe")
+	if err != nil {
+		t.Fatalf("%v: Error adding customer: %v", t.Name(), err)
+	}
+	openTab, err := tavern.OpenTab(context.Background(), customerID)
+	if err != nil {
+		t.Fatalf("%v: Error opening tab: %v", t.Name(), err)
+	}
+	var receipts []Receipt
+	for _, p := range []product.Product{products[0], products[2]} {
+		receipt, err := tavern.Order(context.Background(), customerID, order.Request{Lines: []order.RequestLine{{ProductID: p.GetID(), Quantity: 1}}, StaffID: manager.GetID()})
+		if err != nil {
+			t.Fatalf("%v: Error ordering: %v", t.Name(), err)
+		}
+		receipts = append(receipts, receipt)
+	}
+	if _, err := ordSrvc.VoidOrder(context.Background(), receipts[1].Order.GetID(), manager.GetID()); err != nil {
+		t.Fatalf("%v: Error voiding order: %v", t.Name(), err)
+	}
+
+	// only the beer is charged, the voided wine is taken off the tab
+	invoice, err := tavern.CloseTab(context.Background(), openTab.GetID())
+	if err != nil {
+		t.Fatalf("%v: Error closing tab: %v", t.Name(), err)
+	}
+	if !invoice.Amount.Equals(products[0].GetPrice()) || len(invoice.OrderIDs) != 1 || invoice.OrderIDs[0] != receipts[0].Order.GetID() {
+		t.Errorf("%v: expected an invoice over %s for the beer, got %s for %d orders", t.Name(), products[0].GetPrice(), invoice.Amount, len(invoice.OrderIDs))
+	}
+	beer, err := ordSrvc.GetOrder(context.Background(), receipts[0].Order.GetID())
+	if err != nil || beer.GetStatus() != ord.StatusPaid {
+		t.Fatalf("%v: expected the beer to be paid, got %s (%v)", t.Name(), beer.GetStatus(), err)
+	}
+	if auth, err := gateway.Get(beer.GetAuthorizationID()); err != nil || !auth.Captured.Equals(products[0].GetPrice()) {
+		t.Errorf("%v: expected %s captured on the card, got %s (%v)", t.Name(), products[0].GetPrice(), auth.Captured, err)
+	}
+	if closed, _ := tavern.tabs.Get(context.Background(), openTab.GetID()); closed.IsOpen() || len(closed.GetOrderIDs()) != 1 {
+		t.Errorf("%v: expected the tab closed with the beer, got %s with %d orders", t.Name(), closed.GetStatus(), len(closed.GetOrderIDs()))
+	}
+}
+
+var errStore = errors.New("the store is down")
+
+// failingOrderRepository fails to save orders moving to the given status
+type failingOrderRepository struct {
+	ord.OrderRepository
+	status ord.Status
+}
+
+func (f *failingOrderRepository) Update(ctx context.Context, o ord.Order) error {
+	if o.GetStatus() == f.status {
+		return errStore
+	}
+	return f.OrderRepository.Update(ctx, o)
+}
+
+// init_failing_tavern returns a tavern whose orders cannot be saved once they reach the status
+func init_failing_tavern(t *testing.T, status ord.Status, configs ...TavernConfiguration) (*Tavern, *order.OrderService, product.ProductRepository, []product.Product) {
+	products := init_products(t)
+	productRepo := prdMem.New()
+	for _, p := range products {
+		if err := productRepo.Add(context.Background(), p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ordSrvc, err := order.NewOrderService(
+		order.WithProductRepository(productRepo),
+		order.WithMemoryCustomerRepository(),
+		order.WithOrderRepository(&failingOrderRepository{OrderRepository: ordMem.New(), status: status}),
+	)
+	if err != nil {
+		t.Fatalf("%v: Error creating order service: %v", t.Name(), err)
+	}
+	tavern, err := NewTavern(append([]TavernConfiguration{WithOrderService(ordSrvc), WithMemoryBillingService()}, configs...)...)
+	if err != nil {
+		t.Fatalf("%v: Error creating tavern: %v", t.Name(), err)
+	}
+
+	return tavern, ordSrvc, productRepo, products
+}
+
+func TestTavern_TabOrderNotConfirmed(t *testing.T) {
+	tavern, ordSrvc, productRepo, products := init_failing_tavern(t, ord.StatusConfirmed, WithMemoryTabRepository())
+	customerID, err := ordSrvc.AddCustomer(context.Background(), "John Doe")
+	if err != nil {
+		t.Fatalf("%v: Error adding customer: %v", t.Name(), err)
+	}
+	openTab, err := tavern.OpenTab(context.Background(), customerID)
+	if err != nil {
+		t.Fatalf("%v: Error opening tab: %v", t.Name(), err)
+	}
+
+	_, orderErr := tavern.Order(context.Background(), customerID, order.Request{Lines: []order.RequestLine{{ProductID: products[0].GetID(), Quantity: 3}}})
+	if !errors.Is(orderErr, errStore) {
+		t.Fatalf("%v: expected error %v, got %v", t.Name(), errStore, orderErr)
+	}
+	orders, err := ordSrvc.GetCustomerOrders(context.Background(), customerID)
+	if err != nil || len(orders) != 1 {
+		t.Fatalf("%v: expected the order to be kept, got %d orders (%v)", t.Name(), len(orders), err)
+	}
+	if orders[0].GetStatus() != ord.StatusRejected || !strings.Contains(orderErr.Error(), orders[0].GetID().String()) {
+		t.Errorf("%v: expected order %s to be rolled back and named in the error, got %s: %v", t.Name(), orders[0].GetID(), orders[0].GetStatus(), orderErr)
+	}
+	if beer, _ := productRepo.GetByID(context.Background(), products[0].GetID()); beer.GetQuantity() != 10 {
+		t.Errorf("%v: expected 10 beers in stock, got %d", t.Name(), beer.GetQuantity())
+	}
+	if updated, _ := tavern.tabs.Get(context.Background(), openTab.GetID()); len(updated.GetOrderIDs()) != 0 {
+		t.Errorf("%v: expected nothing on the tab, got %d orders", t.Name(), len(updated.GetOrderIDs()))
+	}
+}
+
+// failingBillingService fails to issue or to settle invoices
+type failingBillingService struct {
+	billing.Service
+	issue, settle bool
+}
+
+func (f failingBillingService) Issue(customerID uuid.UUID, amount domain.Money, orderIDs ...uuid.UUID) (billing.Invoice, error) {
+	if f.issue {
+		return billing.Invoice{}, errStore
+	}
+	return f.Service.Issue(customerID, amount, orderIDs...)
+}
+
+func (f failingBillingService) Settle(id uuid.UUID, amount domain.Money) (billing.Invoice, error) {
+	if f.settle {
+		return billing.Invoice{}, errStore
+	}
+	return f.Service.Settle(id, amount)
+}
+
+func TestTavern_OrderRolledBack(t *testing.T) {
+	type testCase struct {
+		test    string
+		status  ord.Status
+		billing billing.Service
+	}
+	testcases := []testCase{
+		{test: "Not billed", billing: failingBillingService{Service: billMem.New(), issue: true}},
+		{test: "Not confirmed", status: ord.StatusConfirmed, billing: billMem.New()},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.test, func(t *testing.T) {
+			gateway := fake.New()
+			tavern, ordSrvc, productRepo, products := init_failing_tavern(t, tc.status, WithBillingService(tc.billing), WithPaymentGateway(gateway))
+			customerID, err := ordSrvc.AddCustomer(context.Background(), "John Doe")
+			if err != nil {
+				t.Fatalf("%v: Error adding customer: %v", t.Name(), err)
+			}
+
+			_, err = tavern.Order(context.Background(), customerID, order.Request{Lines: []order.RequestLine{{ProductID: products[0].GetID(), Quantity: 3}}})
+			if !errors.Is(err, errStore) {
+				t.Fatalf("%v: expected error %v, got %v", t.Name(), errStore, err)
+			}
+			orders, err := ordSrvc.GetCustomerOrders(context.Background(), customerID)
+			if err != nil || len(orders) != 1 || orders[0].GetStatus() != ord.StatusRejected {
+				t.Fatalf("%v: expected the order to be rejected, got %d orders (%v)", t.Name(), len(orders), err)
+			}
+			if auth, err := gateway.Get("auth-1"); err != nil || auth.Status != payment.StatusVoided {
+				t.Errorf("%v: expected the payment to be voided, got %s (%v)", t.Name(), auth.Status, err)
+			}
+			if unpaid, err := tc.billing.Unpaid(customerID); err != nil || len(unpaid) != 0 {
+				t.Errorf("%v: expected no unpaid invoices, got %d (%v)", t.Name(), len(unpaid), err)
+			}
+			if beer, _ := productRepo.GetByID(context.Background(), products[0].GetID()); beer.GetQuantity() != 10 {
+				t.Errorf("%v: expected 10 beers in stock, got %d", t.Name(), beer.GetQuantity())
+			}
+		})
+	}
+}
+
+func TestTavern_CloseTabRolledBack(t *testing.T) {
+	type testCase struct {
+		test          string
+		outcomes      []fake.Outcome
+		settle        bool
+		expectedError error
+		expectedAuth  payment.Status
+	}
+	testcases := []testCase{
+		{test: "Capture declined", outcomes: []fake.Outcome{fake.Approve, fake.Decline}, expectedError: payment.ErrDeclined, expectedAuth: payment.StatusVoided},
+		{test: "Not settled", settle: true, expectedError: errStore, expectedAuth: payment.StatusRefunded},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.test, func(t *testing.T) {
+			gateway := fake.New()
+			bills := failingBillingService{Service: billMem.New(), settle: tc.settle}
+			tavern, ordSrvc, _, products := init_failing_tavern(t, "", WithBillingService(bills), WithPaymentGateway(gateway), WithMemoryTabRepository())
+			customerID, err := ordSrvc.AddCustomer(context.Background(), "John Doe")
+			if err != nil {
+				t.Fatalf("%v: Error adding customer: %v", t.Name(), err)
+			}
+			openTab, err := tavern.OpenTab(context.Background(), customerID)
+			if err != nil {
+				t.Fatalf("%v: Error opening tab: %v", t.Name(), err)
+			}
+			if _, err := tavern.Order(context.Background(), customerID, order.Request{Lines: []order.RequestLine{{ProductID: products[0].GetID(), Quantity: 2}}}); err != nil {
+				t.Fatalf("%v: Error ordering: %v", t.Name(), err)
+			}
+
+			gateway.Script(tc.outcomes...)
+			if _, err := tavern.CloseTab(context.Background(), openTab.GetID()); !errors.Is(err, tc.expectedError) {
+				t.Fatalf("%v: expected error %v, got %v", t.Name(), tc.expectedError, err)
+			}
+			if auth, err := gateway.Get("auth-1"); err != nil || auth.Status != tc.expectedAuth {
+				t.Errorf("%v: expected the payment to be %s, got %s (%v)", t.Name(), tc.expectedAuth, auth.Status, err)
+			}
+			if unpaid, err := bills.Unpaid(customerID); err != nil || len(unpaid) != 0 {
+				t.Errorf("%v: expected no unpaid invoices, got %d (%v)", t.Name(), len(unpaid), err)
+			}
+			if stored, err := tavern.tabs.Get(context.Background(), openTab.GetID()); err != nil || !stored.IsOpen() {
+				t.Fatalf("%v: expected the tab to stay open (%v)", t.Name(), err)
+			}
+			if tc.settle {
+				return
+			}
+			if _, err := tavern.CloseTab(context.Background(), openTab.GetID()); err != nil {
+				t.Errorf("%v: Error closing tab again: %v", t.Name(), err)
+			}
+		})
+	}
+}
+
+func TestTavern_PayRolledBack(t *testing.T) {
+	gateway := fake.New()
+	bills := failingBillingService{Service: billMem.New(), settle: true}
+	tavern, ordSrvc, _, products := init_failing_tavern(t, "", WithBillingService(bills), WithPaymentGateway(gateway))
+	customerID, err := ordSrvc.AddCustomer(context.Background(), "John Doe")
+	if err != nil {
+		t.Fatalf("%v: Error adding customer: %v", t.Name(), err)
+	}
+	receipt, err := tavern.Order(context.Background(), customerID, order.Request{Lines: []order.RequestLine{{ProductID: products[0].GetID(), Quantity: 2}}})
+	if err != nil {
+		t.Fatalf("%v: Error ordering: %v", t.Name(), err)
+	}
+
+	if _, err := tavern.Pay(context.Background(), receipt.Invoice.ID); !errors.Is(err, errStore) {
+		t.Fatalf("%v: expected error %v, got %v", t.Name(), errStore, err)
+	}
+	if auth, err := gateway.Get(receipt.Order.GetAuthorizationID()); err != nil || auth.Status != payment.StatusRefunded {
+		t.Errorf("%v: expected the payment to be refunded, got %s (%v)", t.Name(), auth.Status, err)
+	}
+	if o, err := ordSrvc.GetOrder(context.Background(), receipt.Order.GetID()); err != nil || o.GetStatus() != ord.StatusConfirmed {
+		t.Errorf("%v: expected the order to stay confirmed, got %s (%v)", t.Name(), o.GetStatus(), err)
+	}
+}
+
+func TestTavern_PaidOrdersNotSaved(t *testing.T) {
+	type testCase struct {
+		test  string
+		onTab bool
+	}
+	testcases := []testCase{
+		{test: "Pay", onTab: false},
+		{test: "Close tab", onTab: true},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.test, func(t *testing.T) {
+			gateway := fake.New()
+			tavern, ordSrvc, _, products := init_failing_tavern(t, ord.StatusPaid, WithPaymentGateway(gateway), WithMemoryTabRepository())
+			customerID, err := ordSrvc.AddCustomer(context.Background(), "John Doe")
+			if err != nil {
+				t.Fatalf("%v: Error adding customer: %v", t.Name(), err)
+			}
+			var openTab tab.Tab
+			if tc.onTab {
+				if openTab, err = tavern.OpenTab(context.Background(), customerID); err != nil {
+					t.Fatalf("%v: Error opening tab: %v", t.Name(), err)
+				}
+			}
+			receipt, err := tavern.Order(context.Background(), customerID, order.Request{Lines: []order.RequestLine{{ProductID: products[0].GetID(), Quantity: 2}}})
+			if err != nil {
+				t.Fatalf("%v: Error ordering: %v", t.Name(), err)
+			}
+
+			// the card is charged and the invoice settled, the orders that cannot be saved are only logged
+			var invoice billing.Invoice
+			if tc.onTab {
+				invoice, err = tavern.CloseTab(context.Background(), openTab.GetID())
+			} else {
+				invoice, err = tavern.Pay(context.Background(), receipt.Invoice.ID)
+			}
+			if err != nil {
+				t.Fatalf("%v: expected the payment to stand, got %v", t.Name(), err)
+			}
+			if invoice.Status != billing.StatusPaid {
+				t.Errorf("%v: expected a paid invoice, got %s", t.Name(), invoice.Status)
+			}
+			if auth, err := gateway.Get("auth-1"); err != nil || auth.Status != payment.StatusCaptured {
+				t.Errorf("%v: expected the payment to be captured, got %s (%v)", t.Name(), auth.Status, err)
+			}
+		})
+	}
+}
+
+func TestTavern_RefundNotRecorded(t *testing.T) {
+	gateway := fake.New()
+	tavern, ordSrvc, productRepo, products := init_failing_tavern(t, ord.StatusPartiallyRefunded, WithPaymentGateway(gateway))
+	customerID, err := ordSrvc.AddCustomer(context.Background(), "John Doe")
+	if err != nil {
+		t.Fatalf("%v: Error adding customer: %v", t.Name(), err)
+	}
+	receipt, err := tavern.Order(context.Background(), customerID, order.Request{Lines: []order.RequestLine{{ProductID: products[0].GetID(), Quantity: 2}}})
+	if err != nil {
+		t.Fatalf("%v: Error ordering: %v", t.Name(), err)
+	}
+	if _, err := tavern.Pay(context.Background(), receipt.Invoice.ID); err != nil {
+		t.Fatalf("%v: Error paying: %v", t.Name(), err)
+	}
+
+	// the card is not refunded for a refund the order could not take
+	if _, err := tavern.Refund(context.Background(), receipt.Order.GetID(), []ord.LineRefund{{ProductID: products[0].GetID(), Quantity: 1}}); !errors.Is(err, errStore) {
+		t.Fatalf("%v: expected error %v, got %v", t.Name(), errStore, err)
+	}
+	if auth, err := gateway.Get(receipt.Order.GetAuthorizationID()); err != nil || auth.Refunded.IsPositive() {
+		t.Errorf("%v: expected nothing refunded on the card, got %s (%v)", t.Name(), auth.Refunded, err)
+	}
+	if beer, _ := productRepo.GetByID(context.Background(), products[0].GetID()); beer.GetQuantity() != 8 {
+		t.Errorf("%v: expected 8 beers in stock, got %d", t.Name(), beer.GetQuantity())
+	}
+}
+
+func TestTavern_FlagOverdueTabs(t *testing.T) {
+	now := time.Date(2024, 3, 1, 22, 0, 0, 0, time.UTC)
+	tavern, err := NewTavern(
+		WithMemoryTabRepository(),
+		WithClosingTime(2*time.Hour),
+		WithClock(func() time.Time { return now }),
+	)
+	if err != nil {
+		t.Fatalf("%v: Error creating tavern: %v", t.Name(), err)
+	}
+	if _, err := tavern.OpenTab(context.Background(), uuid.New()); err != nil {
+		t.Fatalf("%v: Error opening tab: %v", t.Name(), err)
+	}
+
+	now = now.Add(3 * time.Hour)
+	flagged, err := tavern.FlagOverdueTabs(context.Background())
+	if err != nil {
+		t.Fatalf("%v: Error flagging tabs: %v", t.Name(), err)
+	}
+	if len(flagged) != 0 {
+		t.Errorf("%v: expected no tab flagged before closing time, got %d", t.Name(), len(flagged))
+	}
+
+	now = now.Add(2 * time.Hour)
+	flagged, err = tavern.FlagOverdueTabs(context.Background())
+	if err != nil {
+		t.Fatalf("%v: Error context.Background(), flagging tabs: %v", t.Name(), err)
+	}
+	if len(flagged) != 1 || !flagged[0].IsFlagged() {
+		t.Errorf("%v: expected 1 flagged tab after closing time, got %d", t.Name(), len(flagged))
+	}
+}
+
+func TestTavern_Seating(t *testing.T) {
+	products := init_products(t)
+	ordSrvc, err := order.NewOrderService(
+		order.WithMemoryProductRepository(products),
+		order.WithMemoryCustomerRepository(),
+		order.WithMemoryOrderRepository(),
+	)
+	if err != nil {
+		t.Fatalf("%v: Error creating order service: %v", t.Name(), err)
+	}
+	small, err := seating.NewTable("T1", 2)
+	if err != nil {
+		t.Fatalf("%v: Error creating table: %v", t.Name(), err)
+	}
+	large, err := seating.NewTable("T2", 6)
+	if err != nil {
+		t.Fatalf("%v: Error creating table: %v", t.Name(), err)
+	}
+	now := time.Date(2024, 3, 1, 18, 0, 0, 0, time.UTC)
+	tavern, err := NewTavern(
+		WithOrderService(ordSrvc),
+		WithMemoryBillingService(),
+		WithMemoryTableRepository(small, large),
+		WithClock(func() time.Time { return now }),
+	)
+	if err != nil {
+		t.Fatalf("%v: Error creating tavern: %v", t.Name(), err)
+	}
+
+	reservation, err := seating.NewReservation("Percy", uuid.Nil, 2, now.Add(time.Hour), now.Add(3*time.Hour))
+	if err != nil {
+		t.Fatalf("%v: Error creating reservation: %v", t.Name(), err)
+	}
+	table, err := tavern.Reserve(context.Background(), reservation)
+	if err != nil {
+		t.Fatalf("%v: Error reserving: %v", t.Name(), err)
+	}
+	if table.GetID() != small.GetID() {
+		t.Errorf("%v: expected the smallest table %s, got %s", t.Name(), small.GetName(), table.GetName())
+	}
+	second, _ := seating.NewReservation("Annabeth", uuid.Nil, 2, now.Add(2*time.Hour), now.Add(4*time.Hour))
+	if table, err := tavern.Reserve(context.Background(), second); err != nil || table.GetID() != large.GetID() {
+		t.Errorf("%v: expected the overlapping reservation at %s, got %s (%v)", t.Name(), large.GetName(), table.GetName(), err)
+	}
+	third, _ := seating.NewReservation("Grover", uuid.Nil, 2, now.Add(2*time.Hour), now.Add(4*time.Hour))
+	if _, err := tavern.Reserve(context.Background(), third); !errors.Is(err, ErrNoTableAvailable) {
+		t.Errorf("%v: expected error %v, got %v", t.Name(), ErrNoTableAvailable, err)
+	}
+
+	req := order.Request{Lines: []order.RequestLine{{ProductID: products[0].GetID(), Quantity: 2}}, TableID: small.GetID()}
+	if _, err := tavern.Order(context.Background(), uuid.Nil, req); !errors.Is(err, seating.ErrTableNotOccupied) {
+		t.Errorf("%v: expected error %v, got %v", t.Name(), seating.ErrTableNotOccupied, err)
+	}
+	if _, err := tavern.SeatWalkIn(context.Background(), small.GetID(), 2, uuid.Nil); !errors.Is(err, seating.ErrReservationConflict) {
+		t.Errorf("%v: expected error %v, got %v", t.Name(), seating.ErrReservationConflict, err)
+	}
+
+	now = now.Add(45 * time.Minute)
+	table, err = tavern.SeatReservation(context.Background(), small.GetID(), reservation.ID)
+	if err != nil {
+		t.Fatalf("%v: Error seating reservation: %v", t.Name(), err)
+	}
+	receipt, err := tavern.Order(context.Background(), uuid.Nil, req)
+	if err != nil {
+		t.Fatalf("%v: Error ordering for table: %v", t.Name(), err)
+	}
+	if receipt.Order.GetTableID() != small.GetID() || receipt.Order.GetCustomerID() != table.GetHostID() || receipt.Invoice.CustomerID != table.GetHostID() {
+		t.Errorf("%v: expected the order billed to the host of table %s", t.Name(), small.GetName())
+	}
+
+	tables, err := tavern.GetTables(context.Background())
+	if err != nil {
+		t.Fatalf("%v: Error getting tables: %v", t.Name(), err)
+	}
+	if tables[0].GetStatus() != seating.StatusOccupied || len(tables[0].GetOrderIDs()) != 1 || tables[0].GetOrderIDs()[0] != receipt.Order.GetID() {
+		t.Errorf("%v: expected table %s occupied with the order, got %s with %v", t.Name(), small.GetName(), tables[0].GetStatus(), tables[0].GetOrderIDs())
+	}
+
+	if err := tavern.ClearTable(context.Background(), small.GetID()); err != nil {
+		t.Fatalf("%v: Error clearing table: %v", t.Name(), err)
+	}
+	if _, err := tavern.SeatWalkIn(context.Background(), small.GetID(), 1, uuid.Nil); !errors.Is(err, seating.ErrTableNotFree) {
+		t.Errorf("%v: expected error %v, got %v", t.Name(), seating.ErrTableNotFree, err)
+	}
+	if err := tavern.MarkTableClean(context.Background(), small.GetID()); err != nil {
+		t.Fatalf("%v: Error marking table clean: %v", t.Name(), err)
+	}
+	customerID, err := ordSrvc.AddCustomer(context.Background(), "John Doe")
+	if err != nil {
+		t.Fatalf("%v: Error adding customer: %v", t.Name(), err)
+	}
+	table, err = tavern.SeatWalkIn(context.Background(), small.GetID(), 1, customerID)
+	if err != nil {
+		t.Fatalf("%v: Error seating walk-in: %v", t.Name(), err)
+	}
+	if table.GetHostID() != customerID || table.GetPartySize() != 1 {
+		t.Errorf("%v: expected customer %s seated alone, got %s with %d", t.Name(), customerID, table.GetHostID(), table.GetPartySize())
+	}
+}
+
+// countingCustomerRepository counts the customers added to it
+type countingCustomerRepository struct {
+	customer.CustomerRepository
+	added int
+}
+
+func (r *countingCustomerRepository) Add(ctx context.Context, c customer.Customer) error {
+	r.added++
+	return r.CustomerRepository.Add(ctx, c)
+}
+
+func TestTavern_SeatingTurnedAway(t *testing.T) {
+	customers := &countingCustomerRepository{CustomerRepository: custMem.New()}
+	ordSrvc, err := order.NewOrderService(
+		order.WithMemoryProductRepository(init_products(t)),
+		order.WithCustomerRepository(customers),
+	)
+	if err != nil {
+		t.Fatalf("%v: Error creating order service: %v", t.Name(), err)
+	}
+	table, err := seating.NewTable("T1", 2)
+	if err != nil {
+		t.Fatalf("%v: Error creating table: %v", t.Name(), err)
+	}
+	now := time.Date(2024, 3, 1, 18, 0, 0, 0, time.UTC)
+	reservation, err := seating.NewReservation("Percy", uuid.Nil, 2, now.Add(90*time.Minute), now.Add(3*time.Hour))
+	if err != nil {
+		t.Fatalf("%v: Error creating reservation: %v", t.Name(), err)
+	}
+	if err := table.Reserve(reservation); err != nil {
+		t.Fatalf("%v: Error reserving: %v", t.Name(), err)
+	}
+	tavern, err := NewTavern(
+		WithOrderService(ordSrvc),
+		WithMemoryBillingService(),
+		WithMemoryTableRepository(table),
+		WithClock(func() time.Time { return now }),
+	)
+	if err != nil {
+		t.Fatalf("%v: Error creating tavern: %v", t.Name(), err)
+	}
+
+	// no guest is added for a party that is turned away
+	if _, err := tavern.SeatWalkIn(context.Background(), table.GetID(), 3, uuid.Nil); !errors.Is(err, seating.ErrPartyTooLarge) {
+		t.Errorf("%v: expected error %v, got %v", t.Name(), seating.ErrPartyTooLarge, err)
+	}
+	if _, err := tavern.SeatWalkIn(context.Background(), table.GetID(), 2, uuid.Nil); !errors.Is(err, seating.ErrReservationConflict) {
+		t.Errorf("%v: expected error %v, got %v", t.Name(), seating.ErrReservationConflict, err)
+	}
+	if _, err := tavern.SeatReservation(context.Background(), table.GetID(), reservation.ID); !errors.Is(err, seating.ErrReservationNotStarted) {
+		t.Errorf("%v: expected error %v, got %v", t.Name(), seating.ErrReservationNotStarted, err)
+	}
+	if customers.added != 0 {
+		t.Errorf("%v: expected no guest customers, got %d", t.Name(), customers.added)
+	}
+
+	now = now.Add(45 * time.Minute)
+	seated, err := tavern.SeatReservation(context.Background(), table.GetID(), reservation.ID)
+	if err != nil {
+		t.Fatalf("%v: Error seating reservation: %v", t.Name(), err)
+	}
+	if customers.added != 1 || seated.GetHostID() == uuid.Nil {
+		t.Errorf("%v: expected the party seated with a guest customer, got %d added and host %s", t.Name(), customers.added, seated.GetHostID())
+	}
+}
+
+// slowGateway holds authorizations until it is released
+type slowGateway struct {
+	payment.Gateway
+	authorizing chan struct{}
+	release     chan struct{}
+}
+
+func (g slowGateway) Authorize(customerID uuid.UUID, amount domain.Money) (payment.Authorization, error) {
+	g.authorizing <- struct{}{}
+	<-g.release
+	return g.Gateway.Authorize(customerID, amount)
+}
+
+func TestTavern_SeatingWhileOrdering(t *testing.T) {
+	ordSrvc, err := order.NewOrderService(
+		order.WithMemoryProductRepository(init_products(t)),
+		order.WithMemoryCustomerRepository(),
+	)
+	if err != nil {
+		t.Fatalf("%v: Error creating order service: %v", t.Name(), err)
+	}
+	products, err := ordSrvc.GetOrderableProducts(context.Background())
+	if err != nil {
+		t.Fatalf("%v: Error getting products: %v", t.Name(), err)
+	}
+	first, err := seating.NewTable("T1", 2)
+	if err != nil {
+		t.Fatalf("%v: Error creating table: %v", t.Name(), err)
+	}
+	second, err := seating.NewTable("T2", 2)
+	if err != nil {
+		t.Fatalf("%v: Error creating table: %v", t.Name(), err)
+	}
+	gateway := slowGateway{Gateway: fake.New(), authorizing: make(chan struct{}), release: make(chan struct{})}
+	tavern, err := NewTavern(
+		WithOrderService(ordSrvc),
+		WithMemoryBillingService(),
+		WithPaymentGateway(gateway),
+		WithMemoryTableRepository(first, second),
+	)
+	if err != nil {
+		t.Fatalf("%v: Error creating tavern: %v", t.Name(), err)
+	}
+	if _, err := tavern.SeatWalkIn(context.Background(), first.GetID(), 2, uuid.Nil); err != nil {
+		t.Fatalf("%v: Error seating walk-in: %v", t.Name(), err)
+	}
+
+	ordered := make(chan error)
+	go func() {
+		_, err := tavern.Order(context.Background(), uuid.Nil, order.Request{Lines: []order.RequestLine{{ProductID: products[0].GetID(), Quantity: 1}}, TableID: first.GetID()})
+		ordered <- err
+	}()
+	<-gateway.authorizing
+
+	// the second party is seated while the card of the first one is still being authorized
+	seated := make(chan error)
+	go func() {
+		_, err := tavern.SeatWalkIn(context.Background(), second.GetID(), 2, uuid.Nil)
+		seated <- err
+	}()
+	select {
+	case err := <-seated:
+		if err != nil {
+			t.Errorf("%v: Error seating walk-in: %v", t.Name(), err)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("%v: expected the party seated while the order is paid for", t.Name())
+	}
+	close(gateway.release)
+	if err := <-ordered; err != nil {
+		t.Fatalf("%v: Error ordering: %v", t.Name(), err)
+	}
+	if table, _ := tavern.tables.Get(context.Background(), first.GetID()); len(table.GetOrderIDs()) != 1 {
+		t.Errorf("%v: expected the order on table %s, got %d orders", t.Name(), first.GetName(), len(table.GetOrderIDs()))
+	}
+}
+
+func TestTavern_Preparation(t *testing.T) {
+	products := init_products(t)
+	stew, err := product.NewProduct("Stew", "A hearty stew", domain.MustNewMoney(899, "EUR"), 10)
+	if err != nil {
+		t.Fatalf("%v: Error creating product: %v", t.Name(), err)
+	}
+	if err := stew.SetStation(product.StationKitchen); err != nil {
+		t.Fatalf("%v: Error setting station: %v", t.Name(), err)
+	}
+	manager, err := staff.NewMember("Alex", staff.RoleManager)
+	if err != nil {
+		t.Fatalf("%v: Error creating staff member: %v", t.Name(), err)
+	}
+	ordSrvc, err := order.NewOrderService(
+		order.WithMemoryProductRepository(append(products, stew)),
+		order.WithMemoryCustomerRepository(),
+		order.WithMemoryOrderRepository(),
+		order.WithMemoryStaffRepository(manager),
+	)
+	if err != nil {
+		t.Fatalf("%v: Error creating order service: %v", t.Name(), err)
+	}
+	prep, err := preparation.NewPreparationService()
+	if err != nil {
+		t.Fatalf("%v: Error creating preparation service: %v", t.Name(), err)
+	}
+	table, err := seating.NewTable("T1", 4)
+	if err != nil {
+		t.Fatalf("%v: Error creating table: %v", t.Name(), err)
+	}
+	tavern, err := NewTavern(
+		WithOrderService(ordSrvc),
+		WithMemoryBillingService(),
+		WithMemoryTableRepository(table),
+		WithPreparationService(prep),
+	)
+	if err != nil {
+		t.Fatalf("%v: Error creating tavern: %v", t.Name(), err)
+	}
+	if _, err := tavern.SeatWalkIn(context.Background(), table.GetID(), 2, uuid.Nil); err != nil {
+		t.Fatalf("%v: Error seating walk-in: %v", t.Name(), err)
+	}
+
+	kitchen, stop := prep.Subscribe(product.StationKitchen)
+	defer stop()
+	receipt, err := tavern.Order(context.Background(), uuid.Nil, order.Request{Lines: []order.RequestLine{
+		{ProductID: products[0].GetID(), Quantity: 2},
+		{ProductID: stew.GetID(), Quantity: 1, Notes: "no onions"},
+	}, TableID: table.GetID(), StaffID: manager.GetID()})
+	if err != nil {
+		t.Fatalf("%v: Error ordering: %v", t.Name(), err)
+	}
+
+	select {
+	case tk := <-kitchen:
+		if tk.GetOrderID() != receipt.Order.GetID() || tk.GetTableID() != table.GetID() || len(tk.GetItems()) != 1 || tk.GetItems()[0].Notes != "no onions" {
+			t.Errorf("%v: expected the stew for table %s, got %v", t.Name(), table.GetName(), tk.GetItems())
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("%v: expected a ticket for the kitchen", t.Name())
+	}
+	pending, err := prep.GetPending(context.Background(), product.StationBar)
+	if err != nil {
+		t.Fatalf("%v: Error getting bar tickets: %v", t.Name(), err)
+	}
+	if len(pending) != 1 || pending[0].GetItems()[0].Quantity != 2 {
+		t.Errorf("%v: expected the beers queued at the bar, got %d tickets", t.Name(), len(pending))
+	}
+
+	// the beers are served before the order is voided, the stew is still to be made
+	for _, step := range []func(context.Context, uuid.UUID) (ticket.Ticket, error){prep.Start, prep.MarkReady, prep.Serve} {
+		if _, err := step(context.Background(), pending[0].GetID()); err != nil {
+			t.Fatalf("%v: Error moving ticket: %v", t.Name(), err)
+		}
+	}
+	if _, err := tavern.Cancel(context.Background(), receipt.Invoice.ID, manager.GetID()); err != nil {
+		t.Fatalf("%v: Error cancelling order: %v", t.Name(), err)
+	}
+	if pending, _ := prep.GetPending(context.Background(), product.StationKitchen); len(pending) != 0 {
+		t.Errorf("%v: expected nothing pending in the kitchen, got %d tickets", t.Name(), len(pending))
+	}
+	tickets, err := prep.GetOrderTickets(context.Background(), receipt.Order.GetID())
+	if err != nil {
+		t.Fatalf("%v: Error getting order tickets: %v", t.Name(), err)
+	}
+	for _, tk := range tickets {
+		expected := ticket.StatusCancelled
+		if tk.GetStation() == product.StationBar {
+			expected = ticket.StatusServed
+		}
+		if tk.GetStatus() != expected {
+			t.Errorf("%v: expected the %s ticket %s, got %s", t.Name(), tk.GetStation(), expected, tk.GetStatus())
+		}
+	}
+}